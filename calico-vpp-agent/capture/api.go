@@ -0,0 +1,220 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	captureproto "github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/capture/proto"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+/**
+ * The capture API is the gRPC service defined in proto/capture.proto,
+ * served on a unix socket.
+ */
+
+const (
+	DefaultMaxPackets        = 1000
+	MaxMaxPackets            = 100000
+	DefaultMaxBytesPerPacket = 512
+	MaxBytesPerPacket        = 9216
+	DefaultDuration          = 10 * time.Second
+	MaxDuration              = 5 * time.Minute
+
+	chunkSize = 64 * 1024
+)
+
+// CaptureFilter restricts the capture to the IP packets matching all the
+// fields that are set
+type CaptureFilter struct {
+	IP6      bool
+	Protocol types.IPProto
+	SrcIP    net.IP
+	DstIP    net.IP
+	SrcPort  uint16
+	DstPort  uint16
+}
+
+func (f *CaptureFilter) FiveTuple() types.FiveTuple {
+	srcIP, dstIP := f.SrcIP, f.DstIP
+	// Unspecified IPv6 addresses select IPv6 packets
+	if f.IP6 && srcIP == nil {
+		srcIP = net.IPv6zero
+	}
+	if f.IP6 && dstIP == nil {
+		dstIP = net.IPv6zero
+	}
+	return types.New5Tuple(f.Protocol, srcIP, f.SrcPort, dstIP, f.DstPort)
+}
+
+// CaptureRequest is a capture request checked by ParseCaptureRequest
+type CaptureRequest struct {
+	Namespace     string
+	Name          string
+	InterfaceName string
+	InterfaceType storage.VppInterfaceType
+
+	Rx   bool
+	Tx   bool
+	Drop bool
+
+	MaxPackets        uint32
+	MaxBytesPerPacket uint32
+	Duration          time.Duration
+	Filter            *CaptureFilter
+}
+
+func parseFilterIP(s string, ip6 *bool) (net.IP, error) {
+	if s == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.Errorf("invalid address %s in filter", s)
+	}
+	if ip.To4() == nil {
+		*ip6 = true
+	}
+	return ip, nil
+}
+
+func parseCaptureFilter(filter *captureproto.CaptureFilter) (*CaptureFilter, error) {
+	if filter.Protocol > math.MaxUint8 {
+		return nil, errors.Errorf("invalid protocol %d in filter", filter.Protocol)
+	}
+	if filter.SrcPort > math.MaxUint16 || filter.DstPort > math.MaxUint16 {
+		return nil, errors.Errorf("invalid port in filter")
+	}
+	f := &CaptureFilter{
+		IP6:      filter.Ip6,
+		Protocol: types.IPProto(filter.Protocol),
+		SrcPort:  uint16(filter.SrcPort),
+		DstPort:  uint16(filter.DstPort),
+	}
+	var err error
+	f.SrcIP, err = parseFilterIP(filter.SrcIp, &f.IP6)
+	if err != nil {
+		return nil, err
+	}
+	f.DstIP, err = parseFilterIP(filter.DstIp, &f.IP6)
+	if err != nil {
+		return nil, err
+	}
+	if f.IP6 {
+		for _, ip := range []net.IP{f.SrcIP, f.DstIP} {
+			if ip != nil && ip.To4() != nil {
+				return nil, errors.Errorf("cannot mix IPv4 and IPv6 in filters")
+			}
+		}
+	}
+	return f, nil
+}
+
+// ParseCaptureRequest checks a request and fills in the defaults
+func ParseCaptureRequest(request *captureproto.CaptureRequest) (*CaptureRequest, error) {
+	if request.Namespace == "" || request.Name == "" {
+		return nil, errors.Errorf("pod namespace and name are required")
+	}
+	r := &CaptureRequest{
+		Namespace:         request.Namespace,
+		Name:              request.Name,
+		InterfaceName:     request.InterfaceName,
+		Rx:                request.Rx,
+		Tx:                request.Tx,
+		Drop:              request.Drop,
+		MaxPackets:        request.MaxPackets,
+		MaxBytesPerPacket: request.MaxBytesPerPacket,
+		Duration:          DefaultDuration,
+	}
+	if request.InterfaceType != "" {
+		err := r.InterfaceType.UnmarshalText([]byte(request.InterfaceType))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !r.Rx && !r.Tx && !r.Drop {
+		r.Rx, r.Tx = true, true
+	}
+	if r.MaxPackets == 0 {
+		r.MaxPackets = DefaultMaxPackets
+	}
+	if r.MaxPackets > MaxMaxPackets {
+		return nil, errors.Errorf("cannot capture more than %d packets", MaxMaxPackets)
+	}
+	if r.MaxBytesPerPacket == 0 {
+		r.MaxBytesPerPacket = DefaultMaxBytesPerPacket
+	}
+	if r.MaxBytesPerPacket > MaxBytesPerPacket {
+		return nil, errors.Errorf("cannot capture more than %d bytes per packet", MaxBytesPerPacket)
+	}
+	if request.Duration != nil {
+		err := request.Duration.CheckValid()
+		if err != nil {
+			return nil, err
+		}
+		if duration := request.Duration.AsDuration(); duration != 0 {
+			r.Duration = duration
+		}
+	}
+	if r.Duration < 0 || r.Duration > MaxDuration {
+		return nil, errors.Errorf("capture duration should be between 0 and %s", MaxDuration)
+	}
+	if request.Filter != nil {
+		filter, err := parseCaptureFilter(request.Filter)
+		if err != nil {
+			return nil, err
+		}
+		r.Filter = filter
+	}
+	return r, nil
+}
+
+// Capture runs a capture through the agent listening on socket, and
+// writes the resulting pcap file to w
+func Capture(ctx context.Context, socket string, request *captureproto.CaptureRequest, w io.Writer) error {
+	conn, err := grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return errors.Wrapf(err, "cannot connect to %s", socket)
+	}
+	defer conn.Close()
+
+	stream, err := captureproto.NewCaptureClient(conn).Capture(ctx, request)
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		_, err = w.Write(chunk.Data)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	gerrors "errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"gopkg.in/tomb.v2"
+
+	captureproto "github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/capture/proto"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+const ethernetHeaderLen = 14
+
+// PodInterfaceLookup gives the interfaces handled by the CNI server
+type PodInterfaceLookup interface {
+	GetPodInterfaces(namespace, name string) []storage.LocalPodSpec
+}
+
+// CaptureServer runs pcap captures on pod interfaces on behalf of the
+// capture CLI
+type CaptureServer struct {
+	captureproto.UnimplementedCaptureServer
+	log        *logrus.Entry
+	vpp        *vpplink.VppLink
+	pods       PodInterfaceLookup
+	grpcServer *grpc.Server
+	// VPP runs a single pcap capture at a time
+	lock sync.Mutex
+}
+
+func NewCaptureServer(vpp *vpplink.VppLink, pods PodInterfaceLookup, log *logrus.Entry) *CaptureServer {
+	return &CaptureServer{
+		log:        log,
+		vpp:        vpp,
+		pods:       pods,
+		grpcServer: grpc.NewServer(),
+	}
+}
+
+// findPodInterface returns the spec of the requested pod interface
+func (s *CaptureServer) findPodInterface(request *CaptureRequest) (*storage.LocalPodSpec, error) {
+	podSpecs := s.pods.GetPodInterfaces(request.Namespace, request.Name)
+	if len(podSpecs) == 0 {
+		return nil, errors.Errorf("pod %s/%s not found", request.Namespace, request.Name)
+	}
+	for _, podSpec := range podSpecs {
+		if request.InterfaceName == "" && podSpec.NetworkName == "" {
+			return &podSpec, nil
+		} else if request.InterfaceName != "" && podSpec.InterfaceName == request.InterfaceName {
+			return &podSpec, nil
+		}
+	}
+	return nil, errors.Errorf("interface %q not found in pod %s/%s", request.InterfaceName, request.Namespace, request.Name)
+}
+
+// getSwIfIndex returns the VPP interface to capture on, and whether its
+// packets start with an ethernet header
+func getSwIfIndex(podSpec *storage.LocalPodSpec, ifType storage.VppInterfaceType) (swIfIndex uint32, isEthernet bool) {
+	if ifType == storage.VppIfTypeUnknown {
+		switch {
+		case podSpec.EnableVhostUser:
+			ifType = storage.VppIfTypeVhostUser
+		case podSpec.EnableSriov:
+			ifType = storage.VppIfTypeSriov
+		case podSpec.IsMemifOnly():
+			ifType = storage.VppIfTypeMemif
+		default:
+			ifType = storage.VppIfTypeTunTap
+		}
+	}
+	swIfIndex, isL3 := podSpec.GetParamsForIfType(ifType)
+	// vhost-user interfaces are reported as L3 for neighbor resolution
+	return swIfIndex, !isL3 || ifType == storage.VppIfTypeVhostUser
+}
+
+// addFilter creates the classify table selecting the captured packets
+func (s *CaptureServer) addFilter(filter *CaptureFilter, isEthernet bool) (uint32, error) {
+	tuple := filter.FiveTuple()
+	getMask, getMatch := tuple.GetMask, tuple.GetMatch
+	if tuple.IsIP6() {
+		getMask, getMatch = tuple.GetIP6Mask, tuple.GetIP6Match
+	}
+	mask, err := getMask()
+	if err != nil {
+		return types.InvalidID, err
+	}
+	match, err := getMatch()
+	if err != nil {
+		return types.InvalidID, err
+	}
+	table := &types.ClassifyTable{
+		Mask:           mask,
+		NextTableIndex: types.InvalidID,
+		MaxNumEntries:  1,
+		MissNextIndex:  types.InvalidID,
+	}
+	if isEthernet {
+		table.CurrentDataOffset = ethernetHeaderLen
+	}
+	tableIndex, err := s.vpp.AddClassifyTable(table)
+	if err != nil {
+		return types.InvalidID, err
+	}
+	err = s.vpp.AddClassifySession(tableIndex, match)
+	if err != nil {
+		if derr := s.vpp.DelClassifyTable(tableIndex); derr != nil {
+			s.log.Errorf("Error deleting capture filter table %d: %s", tableIndex, derr)
+		}
+		return types.InvalidID, err
+	}
+	return tableIndex, nil
+}
+
+// runCapture captures packets in VPP for the requested duration, and
+// returns the resulting pcap file
+func (s *CaptureServer) runCapture(request *CaptureRequest, swIfIndex uint32, isEthernet bool, done <-chan struct{}) ([]byte, error) {
+	trace := &types.PcapTrace{
+		SwIfIndex:         swIfIndex,
+		Rx:                request.Rx,
+		Tx:                request.Tx,
+		Drop:              request.Drop,
+		MaxPackets:        request.MaxPackets,
+		MaxBytesPerPacket: request.MaxBytesPerPacket,
+		Filename:          fmt.Sprintf("calico-vpp-capture-%d.pcap", time.Now().UnixNano()),
+	}
+	if request.Filter != nil {
+		tableIndex, err := s.addFilter(request.Filter, isEthernet)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating capture filter")
+		}
+		defer func() {
+			if err := s.vpp.SetPcapClassifyTable(swIfIndex, types.InvalidID); err != nil {
+				s.log.Errorf("Error removing capture filter: %s", err)
+			}
+			if err := s.vpp.DelClassifyTable(tableIndex); err != nil {
+				s.log.Errorf("Error deleting capture filter table %d: %s", tableIndex, err)
+			}
+		}()
+		err = s.vpp.SetPcapClassifyTable(swIfIndex, tableIndex)
+		if err != nil {
+			return nil, errors.Wrap(err, "error setting capture filter")
+		}
+		trace.Filter = true
+	}
+
+	s.log.Infof("Starting capture %s", trace.String())
+	err := s.vpp.PcapTraceOn(trace)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-time.After(request.Duration):
+	case <-done:
+		s.log.Infof("Capture cancelled by the client")
+	}
+	err = s.vpp.PcapTraceOff()
+	if err != nil {
+		// VPP also fails when no packet was captured
+		return nil, errors.Wrap(err, "error stopping capture")
+	}
+
+	// VPP writes the file in /tmp of its own container
+	file := filepath.Join(fmt.Sprintf("/proc/%d/root/tmp", common.GetVppManagerInfo().VppPid), trace.Filename)
+	defer os.Remove(file)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read capture file %s", file)
+	}
+	return data, nil
+}
+
+func (s *CaptureServer) Capture(protoRequest *captureproto.CaptureRequest, stream captureproto.Capture_CaptureServer) error {
+	request, err := ParseCaptureRequest(protoRequest)
+	if err != nil {
+		return err
+	}
+	podSpec, err := s.findPodInterface(request)
+	if err != nil {
+		return err
+	}
+	swIfIndex, isEthernet := getSwIfIndex(podSpec, request.InterfaceType)
+	if swIfIndex == types.InvalidID {
+		return errors.Errorf("pod interface %s has no %s interface in VPP", podSpec.Key(), request.InterfaceType)
+	}
+	if !s.lock.TryLock() {
+		return errors.Errorf("another capture is running")
+	}
+	data, err := s.runCapture(request, swIfIndex, isEthernet, stream.Context().Done())
+	s.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	s.log.Infof("Sending capture of %s (%d bytes)", podSpec.Key(), len(data))
+	for len(data) > 0 {
+		n := min(len(data), chunkSize)
+		err = stream.Send(&captureproto.CaptureChunk{Data: data[:n]})
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (s *CaptureServer) ServeCapture(t *tomb.Tomb) error {
+	err := syscall.Unlink(config.CaptureServerSocket)
+	if err != nil && !gerrors.Is(err, os.ErrNotExist) {
+		s.log.Warnf("unable to unlink capture server socket: %+v", err)
+	}
+
+	socketListener, err := net.Listen("unix", config.CaptureServerSocket)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", config.CaptureServerSocket)
+	}
+	captureproto.RegisterCaptureServer(s.grpcServer, s)
+
+	s.log.Infof("Serve() capture")
+	go func() {
+		err := s.grpcServer.Serve(socketListener)
+		if err != nil {
+			s.log.Errorf("Capture server returned %s", err)
+		}
+	}()
+
+	<-t.Dying()
+	s.log.Infof("Capture server exiting")
+	s.grpcServer.Stop()
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	captureproto "github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/capture/proto"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+func TestCapture(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Capture API tests")
+}
+
+var _ = Describe("Capture requests", func() {
+	It("fills in the defaults", func() {
+		request, err := ParseCaptureRequest(&captureproto.CaptureRequest{Namespace: "default", Name: "pod"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request.Rx).To(BeTrue())
+		Expect(request.Tx).To(BeTrue())
+		Expect(request.MaxPackets).To(Equal(uint32(DefaultMaxPackets)))
+		Expect(request.MaxBytesPerPacket).To(Equal(uint32(DefaultMaxBytesPerPacket)))
+		Expect(request.Duration).To(Equal(DefaultDuration))
+		Expect(request.Filter).To(BeNil())
+	})
+
+	It("rejects unbounded captures", func() {
+		for _, request := range []*captureproto.CaptureRequest{
+			{Namespace: "default"},
+			{Namespace: "default", Name: "pod", MaxPackets: MaxMaxPackets + 1},
+			{Namespace: "default", Name: "pod", Duration: durationpb.New(time.Hour)},
+			{Namespace: "default", Name: "pod", Duration: durationpb.New(-time.Second)},
+			{Namespace: "default", Name: "pod", InterfaceType: "Tap"},
+		} {
+			_, err := ParseCaptureRequest(request)
+			Expect(err).To(HaveOccurred(), request.String())
+		}
+	})
+
+	It("parses filters", func() {
+		request, err := ParseCaptureRequest(&captureproto.CaptureRequest{
+			Namespace:     "default",
+			Name:          "pod",
+			InterfaceType: "Memif",
+			Duration:      durationpb.New(time.Minute),
+			Filter:        &captureproto.CaptureFilter{Protocol: uint32(types.TCP), DstIp: "10.0.0.1", DstPort: 80},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request.InterfaceType).To(Equal(storage.VppIfTypeMemif))
+		Expect(request.Duration).To(Equal(time.Minute))
+		Expect(request.Filter.IP6).To(BeFalse())
+		Expect(request.Filter.Protocol).To(Equal(types.TCP))
+		Expect(request.Filter.DstIP.Equal(net.ParseIP("10.0.0.1"))).To(BeTrue())
+		Expect(request.Filter.DstPort).To(Equal(uint16(80)))
+
+		request, err = ParseCaptureRequest(&captureproto.CaptureRequest{
+			Namespace: "default",
+			Name:      "pod",
+			Filter:    &captureproto.CaptureFilter{SrcIp: "fd00::1"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request.Filter.IP6).To(BeTrue())
+
+		for _, filter := range []*captureproto.CaptureFilter{
+			{SrcIp: "not-an-ip"},
+			{SrcIp: "10.0.0.1", DstIp: "fd00::1"},
+			{Ip6: true, DstIp: "10.0.0.1"},
+			{Protocol: 256},
+			{DstPort: 65536},
+		} {
+			_, err = ParseCaptureRequest(&captureproto.CaptureRequest{Namespace: "default", Name: "pod", Filter: filter})
+			Expect(err).To(HaveOccurred(), filter.String())
+		}
+	})
+
+	It("matches IPv6 packets", func() {
+		filter := &CaptureFilter{IP6: true, Protocol: types.UDP, DstPort: 53}
+		tuple := filter.FiveTuple()
+		Expect(tuple.IsIP6()).To(BeTrue())
+		mask, err := tuple.GetIP6Mask()
+		Expect(err).ToNot(HaveOccurred())
+		match, err := tuple.GetIP6Match()
+		Expect(err).ToNot(HaveOccurred())
+		// IPv6 header and UDP ports
+		Expect(mask).To(HaveLen(48))
+		Expect(mask[0]).To(Equal(byte(0xf0)))
+		Expect(match[0]).To(Equal(byte(0x60)))
+		Expect(mask[6]).To(Equal(byte(0xff)))
+		Expect(match[6]).To(Equal(byte(types.UDP)))
+		Expect(mask[8:40]).To(Equal(make([]byte, 32)))
+		Expect(mask[42:44]).To(Equal([]byte{0xff, 0xff}))
+		Expect(match[42:44]).To(Equal([]byte{0, 53}))
+
+		filter = &CaptureFilter{IP6: true, SrcIP: net.ParseIP("fd00::1")}
+		tuple = filter.FiveTuple()
+		mask, err = tuple.GetIP6Mask()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mask[8:24]).To(Equal(bytes.Repeat([]byte{0xff}, 16)))
+		Expect(mask[24:40]).To(Equal(make([]byte, 16)))
+
+		filter = &CaptureFilter{Protocol: types.TCP}
+		tuple = filter.FiveTuple()
+		Expect(tuple.IsIP6()).To(BeFalse())
+	})
+
+	It("captures on the interface carrying the pod traffic", func() {
+		isL3 := true
+		podSpec := &storage.LocalPodSpec{
+			TunTapSwIfIndex:    1,
+			VhostUserSwIfIndex: types.InvalidID,
+			SriovSwIfIndex:     types.InvalidID,
+		}
+		podSpec.IfSpec.IsL3 = &isL3
+		swIfIndex, isEthernet := getSwIfIndex(podSpec, storage.VppIfTypeUnknown)
+		Expect(swIfIndex).To(Equal(uint32(1)))
+		Expect(isEthernet).To(BeFalse())
+
+		podSpec.EnableVhostUser = true
+		podSpec.VhostUserSwIfIndex = 2
+		swIfIndex, isEthernet = getSwIfIndex(podSpec, storage.VppIfTypeUnknown)
+		Expect(swIfIndex).To(Equal(uint32(2)))
+		Expect(isEthernet).To(BeTrue())
+
+		swIfIndex, _ = getSwIfIndex(podSpec, storage.VppIfTypeSriov)
+		Expect(swIfIndex).To(Equal(types.InvalidID))
+	})
+})
@@ -0,0 +1,128 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.5.0
+// source: capture.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Capture_Capture_FullMethodName = "/capture.Capture/Capture"
+)
+
+// CaptureClient is the client API for Capture service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CaptureClient interface {
+	// Capture runs a bounded pcap capture on an interface of a pod, and
+	// streams the resulting pcap file once the capture is over
+	Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CaptureChunk], error)
+}
+
+type captureClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCaptureClient(cc grpc.ClientConnInterface) CaptureClient {
+	return &captureClient{cc}
+}
+
+func (c *captureClient) Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CaptureChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Capture_ServiceDesc.Streams[0], Capture_Capture_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CaptureRequest, CaptureChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Capture_CaptureClient = grpc.ServerStreamingClient[CaptureChunk]
+
+// CaptureServer is the server API for Capture service.
+// All implementations must embed UnimplementedCaptureServer
+// for forward compatibility.
+type CaptureServer interface {
+	// Capture runs a bounded pcap capture on an interface of a pod, and
+	// streams the resulting pcap file once the capture is over
+	Capture(*CaptureRequest, grpc.ServerStreamingServer[CaptureChunk]) error
+	mustEmbedUnimplementedCaptureServer()
+}
+
+// UnimplementedCaptureServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCaptureServer struct{}
+
+func (UnimplementedCaptureServer) Capture(*CaptureRequest, grpc.ServerStreamingServer[CaptureChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
+}
+func (UnimplementedCaptureServer) mustEmbedUnimplementedCaptureServer() {}
+func (UnimplementedCaptureServer) testEmbeddedByValue()                 {}
+
+// UnsafeCaptureServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CaptureServer will
+// result in compilation errors.
+type UnsafeCaptureServer interface {
+	mustEmbedUnimplementedCaptureServer()
+}
+
+func RegisterCaptureServer(s grpc.ServiceRegistrar, srv CaptureServer) {
+	// If the following call pancis, it indicates UnimplementedCaptureServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Capture_ServiceDesc, srv)
+}
+
+func _Capture_Capture_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaptureServer).Capture(m, &grpc.GenericServerStream[CaptureRequest, CaptureChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Capture_CaptureServer = grpc.ServerStreamingServer[CaptureChunk]
+
+// Capture_ServiceDesc is the grpc.ServiceDesc for Capture service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Capture_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "capture.Capture",
+	HandlerType: (*CaptureServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Capture",
+			Handler:       _Capture_Capture_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "capture.proto",
+}
@@ -34,10 +34,13 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/capture"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/connectivity"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/egress"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/felix"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/health"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/prometheus"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/routing"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/services"
@@ -54,14 +57,16 @@ import (
  */
 
 var (
-	t   tomb.Tomb
+	// t is the tomb of the servers programming the current VPP
+	t   *tomb.Tomb
 	log *logrus.Logger
 )
 
 func Go(f func(t *tomb.Tomb) error) {
+	t := t
 	if t.Alive() {
 		t.Go(func() error {
-			err := f(&t)
+			err := f(t)
 			if err != nil {
 				log.Warnf("Tomb function errored with %s", err)
 			}
@@ -83,21 +88,6 @@ func main() {
 		log.Fatalf("Error writing pidfile: %v", err)
 	}
 
-	/**
-	 * Connect to VPP & wait for it to be up
-	 */
-	vpp, err := common.CreateVppLink(config.VppAPISocket, log.WithFields(logrus.Fields{"component": "vpp-api"}))
-	if err != nil {
-		log.Fatalf("Cannot create VPP client: %v", err)
-	}
-	// Once we have the api connection, we know vpp & vpp-manager are running and the
-	// state is accurately reported. Wait for vpp-manager to finish the config.
-	common.VppManagerInfo, err = common.WaitForVppManager()
-	if err != nil {
-		log.Fatalf("Vpp Manager not started: %v", err)
-	}
-	common.ThePubSub = common.NewPubSub(log.WithFields(logrus.Fields{"component": "pubsub"}))
-
 	/**
 	 * Create the API clients we need
 	 */
@@ -132,30 +122,119 @@ func main() {
 	/* Start the BGP listener, it never returns */
 	go bgpServer.Serve()
 
+	err = felix.InstallFelixPlugin()
+	if err != nil {
+		log.Fatalf("could not install felix plugin: %s", err)
+	}
+
+	healthServer := health.NewHealthServer("agent", config.GetCalicoVppHealth().AgentListenEndpoint, log.WithFields(logrus.Fields{"component": "health"}))
+	health.NewVppChecker(health.GetReadyUplinks, log.WithFields(logrus.Fields{"subcomponent": "health-vpp"})).AddChecks(healthServer)
+	healthServer.AddReadinessCheck("cni", health.GrpcCheck(config.CNIServerSocket))
+	healthServer.AddReadinessCheck("bgp", health.BGPCheck(bgpServer))
+	felixReady := healthServer.AddReadyFlag("felix")
+	// The health server outlives VPP restarts
+	var healthTomb tomb.Tomb
+	healthTomb.Go(func() error { return healthServer.ServeHealth(&healthTomb) })
+
+	interruptSignalChannel := make(chan os.Signal, 2)
+	signal.Notify(interruptSignalChannel, os.Interrupt, syscall.SIGTERM)
+
+	usr1SignalChannel := make(chan os.Signal, 2)
+	signal.Notify(usr1SignalChannel, syscall.SIGUSR1)
+
+	restartConf := config.GetCalicoVppInitialConfig().VppRestart
+	var vppStoppedAt time.Time
+	for {
+		var info *config.VppManagerInfo
+		t = &tomb.Tomb{}
+		felixReady.Reset()
+		if vppStoppedAt.IsZero() {
+			/**
+			 * Connect to VPP & wait for it to be up
+			 */
+			info, err = common.WaitForVppManager()
+		} else {
+			info, err = common.WaitForRestartedVpp(common.GetVppManagerInfo().VppPid, *restartConf.AgentWaitTimeout)
+		}
+		if err != nil {
+			log.Fatalf("Vpp Manager not started: %v", err)
+		}
+		common.SetVppManagerInfo(info)
+		startAgent(client, clientv3, k8sclient, bgpServer)
+		felixReady.Set()
+		log.Infof("Agent started")
+		if !vppStoppedAt.IsZero() {
+			recovery := time.Since(vppStoppedAt)
+			prometheus.SetAgentRecoveryTime(recovery)
+			log.Infof("Restarted VPP reprogrammed %s after the previous one stopped", recovery.Round(time.Millisecond))
+		}
+
+		restarting := false
+		select {
+		case <-usr1SignalChannel:
+			/* vpp-manager pokes us with USR1 if VPP terminates */
+			if restartConf != nil {
+				log.Warnf("Vpp stopped, waiting for vpp-manager to restart it...")
+				restarting = true
+			} else {
+				log.Warnf("Vpp stopped, exiting...")
+			}
+			t.Kill(errors.Errorf("Caught signal USR1"))
+		case <-interruptSignalChannel:
+			log.Infof("SIG received, exiting")
+			t.Kill(errors.Errorf("Caught INT signal"))
+		case <-t.Dying():
+			log.Errorf("tomb Dying %s", t.Err())
+		}
+		gracefulTimer := time.AfterFunc(*config.CalicoVppGracefulShutdownTimeout, func() {
+			panic("Graceful shutdown took too long")
+		})
+		e := t.Wait()
+		log.Infof("Tomb exited with %v", e)
+		if !restarting {
+			return
+		}
+		gracefulTimer.Stop()
+		vppStoppedAt = time.Now()
+	}
+}
+
+// startAgent connects to the VPP reported by vpp-manager and starts
+// the servers programming it, replaying the persisted and watched state
+func startAgent(client *calicocli.Client, clientv3 calicov3cli.Interface, k8sclient *kubernetes.Clientset, bgpServer *bgpserver.BgpServer) {
+	vpp, err := common.CreateVppLink(config.VppAPISocket, log.WithFields(logrus.Fields{"component": "vpp-api"}))
+	if err != nil {
+		log.Fatalf("Cannot create VPP client: %v", err)
+	}
+	common.ThePubSub = common.NewPubSub(log.WithFields(logrus.Fields{"component": "pubsub"}))
+
 	/**
 	 * Start watching nodes & fetch our BGP spec
 	 */
 	routeWatcher := watchers.NewRouteWatcher(log.WithFields(logrus.Fields{"subcomponent": "host-route-watcher"}))
-	linkWatcher := watchers.NewLinkWatcher(common.VppManagerInfo.UplinkStatuses, log.WithFields(logrus.Fields{"subcomponent": "host-link-watcher"}))
+	linkWatcher := watchers.NewLinkWatcher(common.GetVppManagerInfo().UplinkStatuses, log.WithFields(logrus.Fields{"subcomponent": "host-link-watcher"}))
+	uplinkFailoverWatcher := watchers.NewUplinkFailoverWatcher(vpp, log.WithFields(logrus.Fields{"subcomponent": "uplink-failover-watcher"}))
 	bgpConfigurationWatcher := watchers.NewBGPConfigurationWatcher(clientv3, log.WithFields(logrus.Fields{"subcomponent": "bgp-conf-watch"}))
 	prefixWatcher := watchers.NewPrefixWatcher(client, log.WithFields(logrus.Fields{"subcomponent": "prefix-watcher"}))
 	peerWatcher := watchers.NewPeerWatcher(clientv3, k8sclient, log.WithFields(logrus.Fields{"subcomponent": "peer-watcher"}))
 	bgpFilterWatcher := watchers.NewBGPFilterWatcher(clientv3, k8sclient, log.WithFields(logrus.Fields{"subcomponent": "BGPFilter-watcher"}))
 	netWatcher := watchers.NewNetWatcher(vpp, log.WithFields(logrus.Fields{"component": "net-watcher"}))
 	routingServer := routing.NewRoutingServer(vpp, bgpServer, log.WithFields(logrus.Fields{"component": "routing"}))
+	nodeStatusReporter := routing.NewNodeStatusReporter(routingServer, clientv3, log.WithFields(logrus.Fields{"subcomponent": "node-status-reporter"}))
 	serviceServer := services.NewServiceServer(vpp, k8sclient, log.WithFields(logrus.Fields{"component": "services"}))
 	prometheusServer := prometheus.NewPrometheusServer(vpp, log.WithFields(logrus.Fields{"component": "prometheus"}))
+	egressPolicyWatcher := watchers.NewEgressPolicyWatcher(k8sclient, log.WithFields(logrus.Fields{"subcomponent": "egress-policy-watcher"}))
+	podAnnotationWatcher := watchers.NewPodAnnotationWatcher(k8sclient, log.WithFields(logrus.Fields{"subcomponent": "pod-annotation-watcher"}))
+	egressGatewayServer := egress.NewEgressGatewayServer(vpp, log.WithFields(logrus.Fields{"component": "egress"}))
 	localSIDWatcher := watchers.NewLocalSIDWatcher(vpp, clientv3, log.WithFields(logrus.Fields{"subcomponent": "localsid-watcher"}))
 	felixServer, err := felix.NewFelixServer(vpp, log.WithFields(logrus.Fields{"component": "policy"}))
 	if err != nil {
 		log.Fatalf("Failed to create policy server %s", err)
 	}
-	err = felix.InstallFelixPlugin()
-	if err != nil {
-		log.Fatalf("could not install felix plugin: %s", err)
-	}
 	connectivityServer := connectivity.NewConnectivityServer(vpp, felixServer, clientv3, log.WithFields(logrus.Fields{"subcomponent": "connectivity"}))
 	cniServer := cni.NewCNIServer(vpp, felixServer, log.WithFields(logrus.Fields{"component": "cni"}))
+	cniServer.SetPodQosClassGetter(podAnnotationWatcher)
+	captureServer := capture.NewCaptureServer(vpp, cniServer, log.WithFields(logrus.Fields{"component": "capture"}))
 
 	/* Pubsub should now be registered */
 
@@ -168,7 +247,7 @@ func main() {
 	routingServer.SetBGPConf(bgpConf)
 	serviceServer.SetBGPConf(bgpConf)
 
-	watchDog := watchdog.NewWatchDog(log.WithFields(logrus.Fields{"component": "watchDog"}), &t)
+	watchDog := watchdog.NewWatchDog(log.WithFields(logrus.Fields{"component": "watchDog"}), t)
 	Go(felixServer.ServeFelix)
 	felixConfig := watchDog.Wait(felixServer.FelixConfigChan, "Waiting for FelixConfig to be provided by the calico pod")
 	ourBGPSpec := watchDog.Wait(felixServer.GotOurNodeBGPchan, "Waiting for bgp spec to be provided on node add")
@@ -207,44 +286,24 @@ func main() {
 
 	Go(routeWatcher.WatchRoutes)
 	Go(linkWatcher.WatchLinks)
+	Go(uplinkFailoverWatcher.WatchUplinkFailover)
 	Go(bgpConfigurationWatcher.WatchBGPConfiguration)
 	Go(prefixWatcher.WatchPrefix)
 	Go(peerWatcher.WatchBGPPeers)
 	Go(bgpFilterWatcher.WatchBGPFilters)
 	Go(connectivityServer.ServeConnectivity)
 	Go(routingServer.ServeRouting)
+	Go(nodeStatusReporter.ReportNodeStatus)
 	Go(serviceServer.ServeService)
 	Go(cniServer.ServeCNI)
 	Go(prometheusServer.ServePrometheus)
+	Go(egressPolicyWatcher.WatchEgressPolicies)
+	Go(podAnnotationWatcher.WatchPodAnnotations)
+	Go(egressGatewayServer.ServeEgressGateway)
+	Go(captureServer.ServeCapture)
 
 	// watch LocalSID if SRv6 is enabled
 	if *config.GetCalicoVppFeatureGates().SRv6Enabled {
 		Go(localSIDWatcher.WatchLocalSID)
 	}
-
-	log.Infof("Agent started")
-
-	interruptSignalChannel := make(chan os.Signal, 2)
-	signal.Notify(interruptSignalChannel, os.Interrupt, syscall.SIGTERM)
-
-	usr1SignalChannel := make(chan os.Signal, 2)
-	signal.Notify(usr1SignalChannel, syscall.SIGUSR1)
-
-	select {
-	case <-usr1SignalChannel:
-		/* vpp-manager pokes us with USR1 if VPP terminates */
-		log.Warnf("Vpp stopped, exiting...")
-		t.Kill(errors.Errorf("Caught signal USR1"))
-	case <-interruptSignalChannel:
-		log.Infof("SIG received, exiting")
-		t.Kill(errors.Errorf("Caught INT signal"))
-	case <-t.Dying():
-		log.Errorf("tomb Dying %s", t.Err())
-	}
-	go func() {
-		time.Sleep(*config.CalicoVppGracefulShutdownTimeout)
-		panic("Graceful shutdown took too long")
-	}()
-	e := t.Wait()
-	log.Infof("Tomb exited with %v", e)
 }
@@ -0,0 +1,105 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/capture"
+	captureproto "github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/capture/proto"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+func main() {
+	var pod, output, socket, proto string
+	var srcPort, dstPort, maxPackets, snapLen uint
+	var duration time.Duration
+	request := &captureproto.CaptureRequest{}
+	filter := &captureproto.CaptureFilter{}
+	flag.StringVar(&pod, "pod", "", "Pod to capture on, as namespace/name")
+	flag.StringVar(&request.InterfaceName, "interface", "", "Interface in the pod, defaults to the main network one")
+	flag.StringVar(&request.InterfaceType, "type", "", "VPP interface of the pod interface (TunTap, Memif, VhostUser, Sriov), defaults to the one carrying the pod traffic")
+	flag.BoolVar(&request.Rx, "rx", false, "Capture packets received by VPP from the pod")
+	flag.BoolVar(&request.Tx, "tx", false, "Capture packets sent by VPP to the pod")
+	flag.BoolVar(&request.Drop, "drop", false, "Capture dropped packets")
+	flag.DurationVar(&duration, "duration", capture.DefaultDuration, "Capture duration")
+	flag.StringVar(&output, "w", "-", "Pcap file to write, - for stdout")
+	flag.StringVar(&socket, "s", config.CaptureServerSocket, "Agent capture socket")
+	flag.BoolVar(&filter.Ip6, "6", false, "Filter on IPv6 packets, implied by IPv6 addresses")
+	flag.StringVar(&proto, "proto", "", "Filter on the protocol (tcp, udp, icmp...)")
+	flag.StringVar(&filter.SrcIp, "src", "", "Filter on the source address")
+	flag.StringVar(&filter.DstIp, "dst", "", "Filter on the destination address")
+	flag.UintVar(&srcPort, "sport", 0, "Filter on the source port")
+	flag.UintVar(&dstPort, "dport", 0, "Filter on the destination port")
+	flag.UintVar(&maxPackets, "c", capture.DefaultMaxPackets, "Maximum number of packets")
+	flag.UintVar(&snapLen, "snaplen", capture.DefaultMaxBytesPerPacket, "Maximum bytes captured per packet")
+	flag.Parse()
+
+	namespace, name, found := strings.Cut(pod, "/")
+	if !found {
+		log.Fatalf("-pod should be namespace/name")
+	}
+	request.Namespace, request.Name = namespace, name
+	request.MaxPackets = uint32(maxPackets)
+	request.MaxBytesPerPacket = uint32(snapLen)
+	request.Duration = durationpb.New(duration)
+	if proto != "" {
+		p, err := types.UnformatProto(proto)
+		if err != nil {
+			log.Fatal(err)
+		}
+		filter.Protocol = uint32(p)
+	}
+	filter.SrcPort, filter.DstPort = uint32(srcPort), uint32(dstPort)
+	if filter.Ip6 || filter.Protocol != 0 || filter.SrcIp != "" || filter.DstIp != "" || srcPort != 0 || dstPort != 0 {
+		request.Filter = filter
+	}
+	_, err := capture.ParseCaptureRequest(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatalf("Cannot create %s: %v", output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	// On Ctrl-C the agent stops the capture and nothing is written
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	log.SetOutput(os.Stderr)
+	log.Infof("Capturing on %s for %s", pod, duration)
+	err = capture.Capture(ctx, socket, request, w)
+	if err != nil {
+		log.Fatalf("Capture failed: %v", err)
+	}
+}
@@ -17,7 +17,6 @@ package main
 
 import (
 	"flag"
-	"fmt"
 
 	log "github.com/sirupsen/logrus"
 
@@ -26,14 +25,20 @@ import (
 )
 
 func main() {
-	var fname string
-	cniServerStateFile := fmt.Sprintf("%s%d", config.CniServerStateFile, storage.CniServerStateFileVersion)
-	flag.StringVar(&fname, "f", cniServerStateFile, "Pod state path")
+	var fname, dir string
+	flag.StringVar(&dir, "d", config.CniServerStateDir, "Pod state directory")
+	flag.StringVar(&fname, "f", "", "Binary pod state file written by older versions")
 	flag.Parse()
 
-	st, err := storage.LoadCniServerState(fname)
+	var st []storage.LocalPodSpec
+	var err error
+	if fname != "" {
+		st, err = storage.LoadCniServerState(fname)
+	} else {
+		st, err = storage.NewJSONPodStore(dir).Load()
+	}
 	if err != nil {
-		log.Errorf("LoadCniServerState errored: %v", err)
+		log.Errorf("Loading pod state errored: %v", err)
 		return
 	}
 	for i, s := range st {
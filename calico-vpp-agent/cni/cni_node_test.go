@@ -139,7 +139,7 @@ var _ = Describe("Node-related functionality of CNI", func() {
 			felixConfig = &config.Config{}
 		}
 		connectivityServer.SetFelixConfig(felixConfig)
-		common.VppManagerInfo = &agentConf.VppManagerInfo{UplinkStatuses: map[string]agentConf.UplinkStatus{"eth0": {IsMain: true, SwIfIndex: 1}}}
+		common.SetVppManagerInfo(&agentConf.VppManagerInfo{UplinkStatuses: map[string]agentConf.UplinkStatus{"eth0": {IsMain: true, SwIfIndex: 1}}})
 	})
 
 	Describe("Addition of the node", func() {
@@ -827,7 +827,7 @@ var _ = Describe("Node-related functionality of CNI", func() {
 							"Dst": gs.PointTo(Equal(*tunnelEndLocalSid)),
 							"Paths": ContainElements(gs.MatchFields(gs.IgnoreExtras, gs.Fields{
 								"Gw":        Equal(net.ParseIP(GatewayIPv6)),
-								"SwIfIndex": Equal(common.VppManagerInfo.GetMainSwIfIndex()),
+								"SwIfIndex": Equal(common.GetVppManagerInfo().GetMainSwIfIndex()),
 							})),
 						}),
 					), "Can't find forwarding of SRv6 tunnel traffic out of node")
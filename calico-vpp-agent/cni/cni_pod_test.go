@@ -103,7 +103,7 @@ var _ = Describe("Pod-related functionality of CNI", func() {
 							},
 						},
 					}
-					common.VppManagerInfo = &config.VppManagerInfo{}
+					common.SetVppManagerInfo(&config.VppManagerInfo{})
 					os.Setenv("NODENAME", ThisNodeName)
 					os.Setenv("CALICOVPP_CONFIG_TEMPLATE", "sss")
 					config.GetCalicoVppInterfaces().DefaultPodIfSpec = &config.InterfaceSpec{}
@@ -118,6 +118,11 @@ var _ = Describe("Pod-related functionality of CNI", func() {
 					Expect(reply.Successful).To(BeTrue(),
 						fmt.Sprintf("Pod addition failed due to: %s", reply.ErrorMessage))
 
+					By("Checking the pod MAC address is a locally administered unicast address")
+					mac, err := net.ParseMAC(reply.ContainerMac)
+					Expect(err).ToNot(HaveOccurred(), "Invalid pod MAC address")
+					Expect(mac[0]&0x03).To(Equal(byte(0x02)), "Pod MAC address is not locally administered unicast")
+
 					By("Checking existence (and IP address) of interface tunnel at added pod's end")
 					interfaceDetails, err := exec.Command("docker", "exec", PodMockContainerName,
 						"ip", "address", "show", "dev", interfaceName).Output()
@@ -149,6 +154,70 @@ var _ = Describe("Pod-related functionality of CNI", func() {
 				})
 			})
 
+			Context("With pod annotations", func() {
+				It("should configure the bandwidth, the policy routes, the multicast groups and the MAC address", func() {
+					const (
+						ipAddress     = "1.2.3.45"
+						interfaceName = "annotatedIf"
+						podMac        = "02:42:ac:11:00:02"
+						group         = "239.1.1.1/32"
+					)
+
+					By("Getting Pod mock container's PID")
+					containerPidOutput, err := exec.Command("docker", "inspect", "-f", "{{.State.Pid}}",
+						PodMockContainerName).Output()
+					Expect(err).Should(BeNil(), "Failed to get pod mock container's PID string")
+					containerPidStr := strings.ReplaceAll(string(containerPidOutput), "\n", "")
+
+					By("Adding pod using CNI server")
+					newPod := &cniproto.AddRequest{
+						InterfaceName: interfaceName,
+						Netns:         fmt.Sprintf("/proc/%s/ns/net", containerPidStr), // expecting mount of "/proc" from host
+						ContainerIps:  []*cniproto.IPConfig{{Address: ipAddress + "/24"}},
+						Workload: &cniproto.WorkloadIDs{
+							Annotations: map[string]string{
+								cni.IngressBandwidthAnnotation:                       "10M",
+								cni.EgressBandwidthAnnotation:                        "20M",
+								cni.VppAnnotationPrefix + cni.PolicyRoutesAnnotation: `[{"dst": "10.1.0.0/16", "gateway": "192.168.1.1"}]`,
+								cni.VppAnnotationPrefix + cni.MulticastAnnotation:    `["239.1.1.1"]`,
+								cni.CalicoAnnotationPrefix + cni.HwAddrAnnotation:    podMac,
+							},
+						},
+					}
+					common.SetVppManagerInfo(&config.VppManagerInfo{})
+					os.Setenv("NODENAME", ThisNodeName)
+					os.Setenv("CALICOVPP_CONFIG_TEMPLATE", "sss")
+					config.GetCalicoVppInterfaces().DefaultPodIfSpec = &config.InterfaceSpec{}
+					err = config.LoadConfigSilent(log)
+					if err != nil {
+						log.Error(err)
+					}
+					config.GetCalicoVppFeatureGates().IPSecEnabled = &config.False
+					config.GetCalicoVppFeatureGates().MulticastEnabled = &config.True
+					reply, err := cniServer.Add(context.Background(), newPod)
+					Expect(err).ToNot(HaveOccurred(), "Pod addition failed")
+					Expect(reply.Successful).To(BeTrue(),
+						fmt.Sprintf("Pod addition failed due to: %s", reply.ErrorMessage))
+
+					By("Checking the pod MAC address is the annotated one")
+					Expect(reply.ContainerMac).To(Equal(podMac))
+
+					ifSwIfIndex := testutils.AssertTunInterfaceExistence(vpp, newPod)
+
+					By("Checking the bandwidth policers are attached to the tunnel interface")
+					// Pod egress traffic is received by VPP, pod ingress traffic is transmitted
+					testutils.AssertInterfaceFeatures(ifSwIfIndex, "tunnel interface", vpp,
+						"policer-input", "policer-output")
+
+					By("Checking the ABF policy of the policy route is attached to the tunnel interface")
+					testutils.AssertInterfaceFeatures(ifSwIfIndex, "tunnel interface", vpp, "abf-input-ip4")
+
+					By("Checking the multicast group is forwarded to the pod")
+					testutils.AssertMRoute(vpp, common.PodVRFIndex, group, ifSwIfIndex)
+					testutils.AssertMRoute(vpp, common.DefaultVRFIndex, group, ifSwIfIndex)
+				})
+			})
+
 			Context("With additional memif interface configured", func() {
 				BeforeEach(func() {
 					config.GetCalicoVppFeatureGates().MemifEnabled = &config.True
@@ -187,7 +256,7 @@ var _ = Describe("Pod-related functionality of CNI", func() {
 							},
 						},
 					}
-					common.VppManagerInfo = &config.VppManagerInfo{}
+					common.SetVppManagerInfo(&config.VppManagerInfo{})
 					reply, err := cniServer.Add(context.Background(), newPod)
 					Expect(err).ToNot(HaveOccurred(), "Pod addition failed")
 					Expect(reply.Successful).To(BeTrue(),
@@ -321,6 +390,75 @@ var _ = Describe("Pod-related functionality of CNI", func() {
 
 			})
 
+			Context("With memif default interface configured", func() {
+				BeforeEach(func() {
+					config.GetCalicoVppFeatureGates().MemifEnabled = &config.True
+				})
+
+				It("should have the memif as the only interface to VPP, and a dummy interface in the pod", func() {
+					const (
+						ipAddress     = "1.2.3.45"
+						interfaceName = "newInterface"
+					)
+
+					By("Getting Pod mock container's PID")
+					containerPidOutput, err := exec.Command("docker", "inspect", "-f", "{{.State.Pid}}",
+						PodMockContainerName).Output()
+					Expect(err).Should(BeNil(), "Failed to get pod mock container's PID string")
+					containerPidStr := strings.ReplaceAll(string(containerPidOutput), "\n", "")
+
+					By("Adding pod using CNI server")
+					newPod := &cniproto.AddRequest{
+						InterfaceName: interfaceName,
+						Netns:         fmt.Sprintf("/proc/%s/ns/net", containerPidStr), // expecting mount of "/proc" from host
+						ContainerIps:  []*cniproto.IPConfig{{Address: ipAddress + "/24"}},
+						Workload: &cniproto.WorkloadIDs{
+							Annotations: map[string]string{
+								cni.VppAnnotationPrefix + cni.MemifAnnotation: "enable",
+							},
+						},
+					}
+					common.SetVppManagerInfo(&config.VppManagerInfo{})
+					reply, err := cniServer.Add(context.Background(), newPod)
+					Expect(err).ToNot(HaveOccurred(), "Pod addition failed")
+					Expect(reply.Successful).To(BeTrue(),
+						fmt.Sprintf("Pod addition failed due to: %s", reply.ErrorMessage))
+
+					By("Checking there is no tun interface at VPP's end")
+					tunSwIfIndex, err := vpp.SearchInterfaceWithTag(
+						testutils.InterfaceTagForLocalTunTunnel(newPod.InterfaceName, newPod.Netns))
+					Expect(err).ToNot(HaveOccurred(), "Failed to search tun interface at VPP's end")
+					Expect(tunSwIfIndex).To(Equal(vpplink.InvalidID), "tun interface created for memif default interface")
+
+					By("Checking the memif interface at VPP's end")
+					memifSwIfIndex, err := vpp.SearchInterfaceWithTag(
+						testutils.InterfaceTagForLocalMemifTunnel(newPod.InterfaceName, newPod.Netns))
+					Expect(err).ShouldNot(HaveOccurred(), "Failed to get memif interface at VPP's end")
+					Expect(memifSwIfIndex).ToNot(Equal(vpplink.InvalidID), "memif interface not found")
+					testutils.AssertTunnelInterfaceIPAddress(vpp, memifSwIfIndex, ipAddress)
+
+					testutils.RunInPod(newPod.Netns, func() {
+						By("Checking the dummy interface carries the pod address")
+						link, err := netlink.LinkByName(interfaceName)
+						Expect(err).ToNot(HaveOccurred(), "can't find dummy interface in pod")
+						Expect(link.Type()).To(Equal("dummy"))
+						addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(addrs).To(HaveLen(1))
+						Expect(addrs[0].IP.String()).To(Equal(ipAddress))
+					})
+
+					By("Checking there is no PBL client")
+					pblClientStr, err := vpp.RunCli("sh pbl client")
+					Expect(err).ToNot(HaveOccurred(), "failed to get PBL configuration")
+					Expect(strings.ToLower(pblClientStr)).ToNot(ContainSubstring("pbl-client"))
+
+					By("Checking RPF routes go to the memif")
+					RPFVRF := testutils.AssertRPFVRFExistence(vpp, interfaceName, newPod.Netns)
+					testutils.AssertRPFRoutes(vpp, RPFVRF, memifSwIfIndex, ipAddress)
+				})
+			})
+
 			Context("With MultiNet configuration (and multinet VRF and loopback already configured)", func() {
 				var (
 					networkDefinition *watchers.NetworkDefinition
@@ -397,7 +535,7 @@ var _ = Describe("Pod-related functionality of CNI", func() {
 							ContainerIps:  []*cniproto.IPConfig{{Address: ipAddress + "/24"}},
 							Workload:      &cniproto.WorkloadIDs{},
 						}
-						common.VppManagerInfo = &config.VppManagerInfo{}
+						common.SetVppManagerInfo(&config.VppManagerInfo{})
 						reply, err := cniServer.Add(context.Background(), newPodForPrimaryNetwork)
 						Expect(err).ToNot(HaveOccurred(), "Pod addition to primary network failed")
 						Expect(reply.Successful).To(BeTrue(),
@@ -640,7 +778,7 @@ var _ = Describe("Pod-related functionality of CNI", func() {
 							ContainerIps:  []*cniproto.IPConfig{{Address: ipAddress + "/24"}},
 							Workload:      &cniproto.WorkloadIDs{},
 						}
-						common.VppManagerInfo = &config.VppManagerInfo{}
+						common.SetVppManagerInfo(&config.VppManagerInfo{})
 						reply, err := cniServer.Add(context.Background(), newPodForPrimaryNetwork)
 						Expect(err).ToNot(HaveOccurred(), "Pod addition to primary network failed")
 						Expect(reply.Successful).To(BeTrue(),
@@ -17,6 +17,7 @@ package cni
 
 import (
 	"context"
+	"crypto/sha256"
 	gerrors "errors"
 	"fmt"
 	"net"
@@ -31,7 +32,10 @@ import (
 	"github.com/projectcalico/calico/felix/proto"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"gopkg.in/tomb.v2"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/podinterface"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
@@ -52,13 +56,16 @@ type Server struct {
 	grpcServer *grpc.Server
 
 	podInterfaceMap map[string]storage.LocalPodSpec
+	podStore        storage.PodStore
 	lock            sync.Mutex /* protects Add/DelVppInterace/RescanState */
 	cniEventChan    chan common.CalicoVppEvent
 
-	memifDriver    *podinterface.MemifPodInterfaceDriver
-	tuntapDriver   *podinterface.TunTapPodInterfaceDriver
-	vclDriver      *podinterface.VclPodInterfaceDriver
-	loopbackDriver *podinterface.LoopbackPodInterfaceDriver
+	memifDriver     *podinterface.MemifPodInterfaceDriver
+	tuntapDriver    *podinterface.TunTapPodInterfaceDriver
+	vhostUserDriver *podinterface.VhostUserPodInterfaceDriver
+	sriovDriver     *podinterface.SriovPodInterfaceDriver
+	vclDriver       *podinterface.VclPodInterfaceDriver
+	loopbackDriver  *podinterface.LoopbackPodInterfaceDriver
 
 	availableBuffers uint64
 
@@ -67,6 +74,20 @@ type Server struct {
 	networkDefinitions   sync.Map
 	cniMultinetEventChan chan common.CalicoVppEvent
 	nodeBGPSpec          *common.LocalNodeSpec
+
+	multicastGroups  map[string]*multicastGroup
+	multicastTunnels map[uint32]bool
+
+	egressPolicyState *watchers.EgressPolicyState
+	egressTunnels     map[string]*egressTunnel
+
+	podQosClassGetter PodQosClassGetter
+}
+
+// PodQosClassGetter returns the kubernetes QoS class of a pod, which is not
+// part of the CNI Add request
+type PodQosClassGetter interface {
+	GetPodQosClass(namespace string, name string) (string, error)
 }
 
 func swIfIdxToIfName(idx uint32) string {
@@ -94,6 +115,26 @@ func (s *Server) SetOurBGPSpec(nodeBGPSpec *common.LocalNodeSpec) {
 	s.nodeBGPSpec = nodeBGPSpec
 }
 
+func (s *Server) SetPodQosClassGetter(podQosClassGetter PodQosClassGetter) {
+	s.podQosClassGetter = podQosClassGetter
+}
+
+// getKubernetesQosClass returns the kubernetes QoS class of the pod being
+// added, when it is mapped to a QoS class. Otherwise, or if it cannot be
+// found, it returns "" and the class is applied later on by the pod
+// annotation watcher
+func (s *Server) getKubernetesQosClass(workload *cniproto.WorkloadIDs) string {
+	if s.podQosClassGetter == nil || len(config.GetCalicoVppQos().KubernetesClasses) == 0 {
+		return ""
+	}
+	kubernetesClass, err := s.podQosClassGetter.GetPodQosClass(workload.Namespace, workload.Pod)
+	if err != nil {
+		s.log.Warnf("Error getting kubernetes QoS class of pod %s/%s: %s", workload.Namespace, workload.Pod, err)
+		return ""
+	}
+	return kubernetesClass
+}
+
 func (s *Server) newLocalPodSpecFromAdd(request *cniproto.AddRequest) (*storage.LocalPodSpec, error) {
 	podSpec := storage.LocalPodSpec{
 		InterfaceName:     request.GetInterfaceName(),
@@ -109,6 +150,7 @@ func (s *Server) newLocalPodSpecFromAdd(request *cniproto.AddRequest) (*storage.
 		WorkloadID:     request.Workload.Namespace + "/" + request.Workload.Pod,
 		EndpointID:     request.Workload.Endpoint,
 		HostPorts:      make([]storage.HostPortBinding, 0),
+		PodLabels:      labels.Set(request.Workload.Labels).String(),
 
 		/* defaults */
 		IfSpec:       GetDefaultIfSpec(true /* isL3 */),
@@ -117,8 +159,13 @@ func (s *Server) newLocalPodSpecFromAdd(request *cniproto.AddRequest) (*storage.
 		V4VrfID: vpplink.InvalidID,
 		V6VrfID: vpplink.InvalidID,
 
-		MemifSwIfIndex:  vpplink.InvalidID,
-		TunTapSwIfIndex: vpplink.InvalidID,
+		MemifSwIfIndex:     vpplink.InvalidID,
+		TunTapSwIfIndex:    vpplink.InvalidID,
+		VhostUserSwIfIndex: vpplink.InvalidID,
+		SriovSwIfIndex:     vpplink.InvalidID,
+
+		IngressPolicerIndex: vpplink.InvalidID,
+		EgressPolicerIndex:  vpplink.InvalidID,
 
 		NetworkName: request.DataplaneOptions["network_name"],
 	}
@@ -197,6 +244,12 @@ func (s *Server) newLocalPodSpecFromAdd(request *cniproto.AddRequest) (*storage.
 					Mask: route.Mask,
 				})
 			}
+			if networkDefinition.SRIOV != nil && !podSpec.EnableMemif {
+				podSpec.EnableSriov = true
+				podSpec.SriovUplink = networkDefinition.SRIOV.Uplink
+				podSpec.DefaultIfType = storage.VppIfTypeSriov
+				podSpec.IfSpec = GetDefaultIfSpec(false)
+			}
 		}
 	}
 	for _, requestContainerIP := range request.GetContainerIps() {
@@ -214,15 +267,48 @@ func (s *Server) newLocalPodSpecFromAdd(request *cniproto.AddRequest) (*storage.
 		if err != nil {
 			return nil, errors.Wrapf(err, "Cannot parse pod Annotations")
 		}
+		if podSpec.QosClass == "" {
+			err = s.ParseQosClass(&podSpec, "" /* annotatedClass */, s.getKubernetesQosClass(workload))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Cannot set pod QoS class")
+			}
+		}
+	}
+	if podSpec.EnableVhostUser && !*config.GetCalicoVppFeatureGates().VhostUserEnabled {
+		return nil, fmt.Errorf("enable vhostUser in config for vhost-user interfaces")
+	}
+	if podSpec.NetworkName == "" && podSpec.DefaultIfType == storage.VppIfTypeMemif {
+		if !*config.GetCalicoVppFeatureGates().MemifEnabled {
+			return nil, fmt.Errorf("enable memif in config for memif default interfaces")
+		}
+		if podSpec.EnableVCL {
+			return nil, fmt.Errorf("vcl requires a tun, it cannot be used with a memif default interface")
+		}
 	}
 
 	if podSpec.DefaultIfType == storage.VppIfTypeUnknown {
 		podSpec.DefaultIfType = storage.VppIfTypeTunTap
 	}
+	if podSpec.ContainerMac == "" {
+		podSpec.ContainerMac = generateContainerMac(&podSpec).String()
+	}
 
 	return &podSpec, nil
 }
 
+// generateContainerMac derives a locally administered unicast MAC address
+// from the pod name, so that it stays the same when the pod is recreated
+func generateContainerMac(podSpec *storage.LocalPodSpec) net.HardwareAddr {
+	id := podSpec.WorkloadID + "/" + podSpec.InterfaceName
+	if podSpec.WorkloadID == "/" {
+		id = podSpec.Key()
+	}
+	h := sha256.Sum256([]byte(id))
+	mac := net.HardwareAddr(h[:6])
+	mac[0] = (mac[0] &^ 0x01) | 0x02
+	return mac
+}
+
 func NewLocalPodSpecFromDel(request *cniproto.DelRequest) *storage.LocalPodSpec {
 	return &storage.LocalPodSpec{
 		InterfaceName: request.GetInterfaceName(),
@@ -255,6 +341,9 @@ func (s *Server) Add(ctx context.Context, request *cniproto.AddRequest) (*cnipro
 	existingSpec, ok := s.podInterfaceMap[podSpec.Key()]
 	if ok {
 		s.log.Info("pod(add) found existing spec")
+		// bandwidth annotations may have changed since the last add
+		existingSpec.IngressBandwidth = podSpec.IngressBandwidth
+		existingSpec.EgressBandwidth = podSpec.EgressBandwidth
 		podSpec = &existingSpec
 	}
 
@@ -267,26 +356,30 @@ func (s *Server) Add(ctx context.Context, request *cniproto.AddRequest) (*cnipro
 		}, nil
 	}
 	if len(config.GetCalicoVppInitialConfig().RedirectToHostRules) != 0 && podSpec.NetworkName == "" {
-		err := s.AddRedirectToHostToInterface(podSpec.TunTapSwIfIndex)
+		err := s.AddRedirectToHostToInterface(podSpec.GetPrimarySwIfIndex())
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	s.podInterfaceMap[podSpec.Key()] = *podSpec
-	cniServerStateFile := fmt.Sprintf("%s%d", config.CniServerStateFile, storage.CniServerStateFileVersion)
-	err = storage.PersistCniServerState(s.podInterfaceMap, cniServerStateFile)
+	err = s.podStore.Put(podSpec)
 	if err != nil {
 		s.log.Errorf("CNI state persist errored %v", err)
 	}
+	if podSpec.EnableMemif {
+		s.balanceMemifQueues()
+	}
 	s.log.Infof("pod(add) Done spec=%s", podSpec.String())
-	// XXX: container MAC doesn't make sense with tun, we just pass back a constant one.
-	// How does calico / k8s use it?
-	// TODO: pass real mac for tap ?
+	containerMac := podSpec.ContainerMac
+	if containerMac == "" {
+		// Pods created by older versions did not record their MAC
+		containerMac = "02:00:00:00:00:00"
+	}
 	return &cniproto.AddReply{
 		Successful:        true,
 		HostInterfaceName: swIfIdxToIfName(swIfIndex),
-		ContainerMac:      "02:00:00:00:00:00",
+		ContainerMac:      containerMac,
 	}, nil
 }
 
@@ -296,6 +389,16 @@ func (s *Server) fetchNDataThreads() {
 	s.tuntapDriver.NDataThreads = nDataThreads
 }
 
+// balanceMemifQueues places the memif queues of all the pods on the
+// workers, it should be called with the lock held
+func (s *Server) balanceMemifQueues() {
+	podSpecs := make([]storage.LocalPodSpec, 0, len(s.podInterfaceMap))
+	for _, podSpec := range s.podInterfaceMap {
+		podSpecs = append(podSpecs, podSpec)
+	}
+	s.memifDriver.BalanceQueues(podSpecs)
+}
+
 func (s *Server) FetchBufferConfig() {
 	availableBuffers, _, _, err := s.vpp.GetBufferStats()
 	if err != nil {
@@ -316,14 +419,16 @@ func (s *Server) rescanState() {
 		}
 	}
 
-	cniServerStateFile := fmt.Sprintf("%s%d", config.CniServerStateFile, storage.CniServerStateFileVersion)
-	podSpecs, err := storage.LoadCniServerState(cniServerStateFile)
+	importedVersion, err := storage.ImportCniServerState(s.podStore, config.CniServerStateFile)
 	if err != nil {
-		s.log.Errorf("Error getting pods from file %s, removing cache", err)
-		err := os.Remove(cniServerStateFile)
-		if err != nil {
-			s.log.Errorf("Could not remove %s, %s", cniServerStateFile, err)
-		}
+		s.log.Errorf("Error importing pods state file version %d: %s", importedVersion, err)
+	} else if importedVersion != 0 {
+		s.log.Infof("Imported pods state file version %d", importedVersion)
+	}
+
+	podSpecs, err := s.podStore.Load()
+	if err != nil {
+		s.log.Errorf("Error loading pods state: %s", err)
 	}
 
 	s.log.Infof("RescanState: re-creating all interfaces")
@@ -342,13 +447,23 @@ func (s *Server) rescanState() {
 		default:
 			s.log.Errorf("Interface add failed %s : %v", podSpecCopy.String(), err)
 		}
+		if err == nil {
+			err = s.podStore.Put(&podSpecCopy)
+		} else {
+			err = s.podStore.Delete(podSpec.Key())
+		}
+		if err != nil {
+			s.log.Errorf("CNI state persist errored %v", err)
+		}
 		if len(config.GetCalicoVppInitialConfig().RedirectToHostRules) != 0 && podSpecCopy.NetworkName == "" {
-			err := s.AddRedirectToHostToInterface(podSpecCopy.TunTapSwIfIndex)
+			err := s.AddRedirectToHostToInterface(podSpecCopy.GetPrimarySwIfIndex())
 			if err != nil {
 				s.log.Error(err)
 			}
 		}
 	}
+	// The number of workers may have changed since the pods were added
+	s.balanceMemifQueues()
 }
 
 func (s *Server) DelRedirectToHostOnInterface(swIfIndex uint32) error {
@@ -396,10 +511,13 @@ func (s *Server) Del(ctx context.Context, request *cniproto.DelRequest) (*cnipro
 	}
 
 	delete(s.podInterfaceMap, initialSpec.Key())
-	err := storage.PersistCniServerState(s.podInterfaceMap, config.CniServerStateFile+fmt.Sprint(storage.CniServerStateFileVersion))
+	err := s.podStore.Delete(partialPodSpec.Key())
 	if err != nil {
 		s.log.Errorf("CNI state persist errored %v", err)
 	}
+	if initialSpec.EnableMemif {
+		s.balanceMemifQueues()
+	}
 
 	return &cniproto.DelReply{
 		Successful: true,
@@ -417,17 +535,29 @@ func NewCNIServer(vpp *vpplink.VppLink, felixServerIpam common.FelixServerIpam,
 
 		grpcServer:      grpc.NewServer(),
 		podInterfaceMap: make(map[string]storage.LocalPodSpec),
+		podStore:        storage.NewJSONPodStore(config.CniServerStateDir),
 		tuntapDriver:    podinterface.NewTunTapPodInterfaceDriver(vpp, log),
 		memifDriver:     podinterface.NewMemifPodInterfaceDriver(vpp, log),
+		vhostUserDriver: podinterface.NewVhostUserPodInterfaceDriver(vpp, log),
+		sriovDriver:     podinterface.NewSriovPodInterfaceDriver(vpp, log),
 		vclDriver:       podinterface.NewVclPodInterfaceDriver(vpp, log),
 		loopbackDriver:  podinterface.NewLoopbackPodInterfaceDriver(vpp, log),
 
 		cniMultinetEventChan: make(chan common.CalicoVppEvent, common.ChanSize),
+
+		multicastGroups:  make(map[string]*multicastGroup),
+		multicastTunnels: make(map[uint32]bool),
+
+		egressTunnels: make(map[string]*egressTunnel),
 	}
 	reg := common.RegisterHandler(server.cniEventChan, "CNI server events")
 	reg.ExpectEvents(
 		common.FelixConfChanged,
 		common.IpamConfChanged,
+		common.TunnelAdded,
+		common.TunnelDeleted,
+		common.EgressPoliciesChanged,
+		common.PodAnnotationsChanged,
 	)
 	regM := common.RegisterHandler(server.cniMultinetEventChan, "CNI server Multinet events")
 	regM.ExpectEvents(
@@ -445,6 +575,42 @@ forloop:
 			break forloop
 		case evt := <-s.cniEventChan:
 			switch evt.Type {
+			case common.TunnelAdded:
+				swIfIndex, ok := evt.New.(uint32)
+				if !ok {
+					s.log.Errorf("evt.New is not a uint32 %v", evt.New)
+					continue
+				}
+				s.lock.Lock()
+				s.onMulticastTunnelAddedOrDeleted(swIfIndex, true /* isAdd */)
+				s.lock.Unlock()
+			case common.TunnelDeleted:
+				swIfIndex, ok := evt.Old.(uint32)
+				if !ok {
+					s.log.Errorf("evt.Old is not a uint32 %v", evt.Old)
+					continue
+				}
+				s.lock.Lock()
+				s.onMulticastTunnelAddedOrDeleted(swIfIndex, false /* isAdd */)
+				s.lock.Unlock()
+			case common.EgressPoliciesChanged:
+				state, ok := evt.New.(*watchers.EgressPolicyState)
+				if !ok {
+					s.log.Errorf("evt.New is not a (*watchers.EgressPolicyState) %v", evt.New)
+					continue
+				}
+				s.lock.Lock()
+				s.onEgressPoliciesChanged(state)
+				s.lock.Unlock()
+			case common.PodAnnotationsChanged:
+				update, ok := evt.New.(*watchers.PodAnnotationsUpdate)
+				if !ok {
+					s.log.Errorf("evt.New is not a (*watchers.PodAnnotationsUpdate) %v", evt.New)
+					continue
+				}
+				s.lock.Lock()
+				s.onPodAnnotationsChanged(update)
+				s.lock.Unlock()
 			case common.FelixConfChanged:
 				if new, _ := evt.New.(*felixConfig.Config); new != nil {
 					s.lock.Lock()
@@ -474,8 +640,8 @@ forloop:
 					for _, containerIP := range podSpec.GetContainerIps() {
 						podSpec.NeedsSnat = podSpec.NeedsSnat || s.felixServerIpam.IPNetNeedsSNAT(containerIP)
 					}
-					if NeededSnat != podSpec.NeedsSnat {
-						for _, swIfIndex := range []uint32{podSpec.LoopbackSwIfIndex, podSpec.TunTapSwIfIndex, podSpec.MemifSwIfIndex} {
+					if NeededSnat != podSpec.NeedsSnat && podSpec.EgressIP == "" {
+						for _, swIfIndex := range []uint32{podSpec.LoopbackSwIfIndex, podSpec.TunTapSwIfIndex, podSpec.MemifSwIfIndex, podSpec.VhostUserSwIfIndex, podSpec.SriovSwIfIndex} {
 							if swIfIndex != vpplink.InvalidID {
 								s.log.Infof("Enable/Disable interface[%d] SNAT", swIfIndex)
 								for _, ipFamily := range vpplink.IPFamilies {
@@ -490,6 +656,7 @@ forloop:
 				}
 				s.lock.Lock()
 				s.tuntapDriver.FelixConfigChanged(nil /* felixConfig */, ipipEncapRefCountDelta, vxlanEncapRefCountDelta, s.podInterfaceMap)
+				s.updateEgressClusterPrefixes()
 				s.lock.Unlock()
 			}
 		}
@@ -498,7 +665,7 @@ forloop:
 }
 
 func (s *Server) getMainInterface() *config.UplinkStatus {
-	for _, i := range common.VppManagerInfo.UplinkStatuses {
+	for _, i := range common.GetVppManagerInfo().UplinkStatuses {
 		if i.IsMain {
 			return &i
 		}
@@ -555,6 +722,7 @@ func (s *Server) ServeCNI(t *tomb.Tomb) error {
 		return err
 	}
 	cniproto.RegisterCniDataplaneServer(s.grpcServer, s)
+	healthpb.RegisterHealthServer(s.grpcServer, grpchealth.NewServer())
 
 	if *config.GetCalicoVppFeatureGates().MultinetEnabled {
 		netsSynced := make(chan bool)
@@ -616,6 +784,19 @@ func (s *Server) ServeCNI(t *tomb.Tomb) error {
 	return nil
 }
 
+// GetPodInterfaces returns the specs of the interfaces of a pod
+func (s *Server) GetPodInterfaces(namespace, name string) []storage.LocalPodSpec {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	podSpecs := make([]storage.LocalPodSpec, 0)
+	for _, podSpec := range s.podInterfaceMap {
+		if podSpec.WorkloadID == namespace+"/"+name {
+			podSpecs = append(podSpecs, podSpec.Copy())
+		}
+	}
+	return podSpecs
+}
+
 // ForceAddingNetworkDefinition will add another NetworkDefinition to this CNI server.
 // The usage is mainly for testing purposes.
 func (s *Server) ForceAddingNetworkDefinition(networkDefinition *watchers.NetworkDefinition) {
@@ -108,7 +108,7 @@ func (s *Server) removeConflictingContainers(newAddresses []storage.LocalIP, net
 		s.log.Infof("Deleting conflicting podSpec=%s", podSpec.Key())
 		s.DelVppInterface(&podSpec)
 		delete(s.podInterfaceMap, podSpec.Key())
-		err := storage.PersistCniServerState(s.podInterfaceMap, config.CniServerStateFile+fmt.Sprint(storage.CniServerStateFileVersion))
+		err := s.podStore.Delete(podSpec.Key())
 		if err != nil {
 			s.log.Errorf("CNI state persist errored %v", err)
 		}
@@ -141,7 +141,13 @@ func (s *Server) AddVppInterface(podSpec *storage.LocalPodSpec, doHostSideConf b
 	 */
 	if s.findPodVRFs(podSpec) {
 		s.log.Infof("VRF already exists in VPP podSpec=%s", podSpec.Key())
-		return podSpec.TunTapSwIfIndex, nil
+		s.RestorePodPolicyRoutes(podSpec)
+		s.RestorePodEgressGateway(podSpec)
+		err = s.UpdatePodBandwidthLimits(podSpec)
+		if err != nil {
+			return vpplink.InvalidID, errors.Wrapf(err, "error updating bandwidth limits")
+		}
+		return podSpec.GetPrimarySwIfIndex(), nil
 	}
 
 	/**
@@ -174,7 +180,9 @@ func (s *Server) AddVppInterface(podSpec *storage.LocalPodSpec, doHostSideConf b
 		goto err
 	}
 
-	if podSpec.NetworkName == "" || !podSpec.EnableMemif { // The only case where tun is not created is when we create memif interface in non main network
+	// The tun is not created for memif only interfaces, and for vhost-user
+	// and sriov interfaces which replace it
+	if !podSpec.IsMemifOnly() && !podSpec.EnableVhostUser && !podSpec.EnableSriov {
 		s.log.Infof("pod(add) tuntap")
 		err = s.tuntapDriver.CreateInterface(podSpec, stack, doHostSideConf)
 		if err != nil {
@@ -190,6 +198,22 @@ func (s *Server) AddVppInterface(podSpec *storage.LocalPodSpec, doHostSideConf b
 		}
 	}
 
+	if podSpec.EnableVhostUser {
+		s.log.Infof("pod(add) vhost-user")
+		err = s.vhostUserDriver.CreateInterface(podSpec, stack)
+		if err != nil {
+			goto err
+		}
+	}
+
+	if podSpec.EnableSriov {
+		s.log.Infof("pod(add) sriov")
+		err = s.addPodSriovVf(podSpec, stack, doHostSideConf)
+		if err != nil {
+			goto err
+		}
+	}
+
 	if podSpec.EnableVCL && *config.GetCalicoVppFeatureGates().VCLEnabled {
 		s.log.Infof("pod(add) VCL socket")
 		err = s.vclDriver.CreateInterface(podSpec, stack)
@@ -238,6 +262,35 @@ func (s *Server) AddVppInterface(podSpec *storage.LocalPodSpec, doHostSideConf b
 		}
 	}
 
+	swIfIndex, _ = podSpec.GetParamsForIfType(podSpec.DefaultIfType)
+	if swIfIndex != types.InvalidID {
+		s.log.Infof("pod(add) bandwidth limits")
+		err = s.AddPodBandwidthLimits(podSpec, stack, swIfIndex)
+		if err != nil {
+			goto err
+		}
+	}
+
+	if podSpec.NetworkName == "" {
+		if swIfIndex != types.InvalidID {
+			s.log.Infof("pod(add) multicast groups")
+			err = s.AddPodMulticastGroups(podSpec, stack, swIfIndex)
+			if err != nil {
+				goto err
+			}
+			s.log.Infof("pod(add) policy routes")
+			err = s.AddPodPolicyRoutes(podSpec, stack, swIfIndex)
+			if err != nil {
+				goto err
+			}
+		}
+		s.log.Infof("pod(add) egress gateway")
+		err = s.AddPodEgressGateway(podSpec, stack)
+		if err != nil {
+			goto err
+		}
+	}
+
 	if podSpec.NetworkName != "" {
 		value, ok := s.networkDefinitions.Load(podSpec.NetworkName)
 		if !ok {
@@ -278,7 +331,7 @@ func (s *Server) AddVppInterface(podSpec *storage.LocalPodSpec, doHostSideConf b
 		s.log.Errorf("failed to activate rpf strict on interface : %s", err)
 		goto err
 	}
-	return podSpec.TunTapSwIfIndex, err
+	return podSpec.GetPrimarySwIfIndex(), err
 
 err:
 	s.log.Errorf("Error, try a cleanup %+v", err)
@@ -290,7 +343,7 @@ err:
 // CleanUpVPPNamespace deletes the devices in the network namespace.
 func (s *Server) DelVppInterface(podSpec *storage.LocalPodSpec) {
 	if len(config.GetCalicoVppInitialConfig().RedirectToHostRules) != 0 && podSpec.NetworkName == "" {
-		err := s.DelRedirectToHostOnInterface(podSpec.TunTapSwIfIndex)
+		err := s.DelRedirectToHostOnInterface(podSpec.GetPrimarySwIfIndex())
 		if err != nil {
 			s.log.Error(err)
 		}
@@ -352,6 +405,22 @@ func (s *Server) DelVppInterface(podSpec *storage.LocalPodSpec) {
 			s.UnroutePodInterface(podSpec, swIfIndex, pblswIfIndex != types.InvalidID)
 		}
 	}
+	if podSpec.NetworkName == "" {
+		swIfIndex, _ := podSpec.GetParamsForIfType(podSpec.DefaultIfType)
+		if swIfIndex != types.InvalidID {
+			s.log.Infof("pod(del) multicast groups")
+			s.DelPodMulticastGroups(podSpec, swIfIndex)
+		}
+		s.log.Infof("pod(del) policy routes")
+		s.DelPodPolicyRoutes(podSpec)
+		s.log.Infof("pod(del) egress gateway")
+		s.DelPodEgressGateway(podSpec)
+	}
+	swIfIndex, _ := podSpec.GetParamsForIfType(podSpec.DefaultIfType)
+	if swIfIndex != types.InvalidID {
+		s.log.Infof("pod(del) bandwidth limits")
+		s.DelPodBandwidthLimits(podSpec, swIfIndex)
+	}
 	pblswIfIndex, _ := podSpec.GetParamsForIfType(podSpec.PortFilteredIfType)
 	if pblswIfIndex != types.InvalidID {
 		s.log.Infof("pod(del) PBL routes to %d", pblswIfIndex)
@@ -371,6 +440,14 @@ func (s *Server) DelVppInterface(podSpec *storage.LocalPodSpec) {
 		s.log.Infof("pod(del) memif")
 		s.memifDriver.DeleteInterface(podSpec)
 	}
+	if podSpec.EnableVhostUser {
+		s.log.Infof("pod(del) vhost-user")
+		s.vhostUserDriver.DeleteInterface(podSpec)
+	}
+	if podSpec.EnableSriov {
+		s.log.Infof("pod(del) sriov")
+		s.sriovDriver.DeleteInterface(podSpec)
+	}
 	s.log.Infof("pod(del) tuntap")
 	s.tuntapDriver.DeleteInterface(podSpec)
 	s.log.Infof("pod(del) loopback")
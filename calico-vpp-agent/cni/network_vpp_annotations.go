@@ -0,0 +1,303 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/watchers"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+// parseAnnotatedSettings returns the settings derived from the annotations
+// of a running pod, as newLocalPodSpecFromAdd would compute them. Pods
+// without annotated QoS class get the one mapped from kubernetesQosClass.
+func (s *Server) parseAnnotatedSettings(podSpec *storage.LocalPodSpec, annotations map[string]string, kubernetesQosClass string) (*storage.LocalPodSpec, error) {
+	newSpec := storage.LocalPodSpec{
+		InterfaceName: podSpec.InterfaceName,
+		NetworkName:   podSpec.NetworkName,
+		IfPortConfigs: make([]storage.LocalIfPortConfigs, 0),
+		IfSpec:        GetDefaultIfSpec(true /* isL3 */),
+		PBLMemifSpec:  GetDefaultIfSpec(false /* isL3 */),
+	}
+	if newSpec.NetworkName != "" && isMemif(newSpec.InterfaceName) {
+		newSpec.EnableMemif = true
+		newSpec.DefaultIfType = storage.VppIfTypeMemif
+		newSpec.IfSpec = GetDefaultIfSpec(false)
+	}
+	if podSpec.EnableSriov {
+		newSpec.EnableSriov = true
+		newSpec.DefaultIfType = storage.VppIfTypeSriov
+		newSpec.IfSpec = GetDefaultIfSpec(false)
+	}
+	err := s.ParsePodAnnotations(&newSpec, annotations)
+	if err != nil {
+		return nil, err
+	}
+	if newSpec.DefaultIfType == storage.VppIfTypeUnknown {
+		newSpec.DefaultIfType = storage.VppIfTypeTunTap
+	}
+	if newSpec.QosClass == "" {
+		err = s.ParseQosClass(&newSpec, "" /* annotatedClass */, kubernetesQosClass)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &newSpec, nil
+}
+
+// interfaceSpecChanges lists the differences between two interface specs
+// that can only be applied by recreating the interface
+func interfaceSpecChanges(name string, old, new config.InterfaceSpec) []string {
+	changes := make([]string, 0)
+	if old.NumRxQueues != new.NumRxQueues || old.NumTxQueues != new.NumTxQueues {
+		changes = append(changes, name+" queue count")
+	}
+	if old.RxQueueSize != new.RxQueueSize || old.TxQueueSize != new.TxQueueSize {
+		changes = append(changes, name+" queue size")
+	}
+	if old.GetIsL3(false) != new.GetIsL3(false) {
+		changes = append(changes, name+" isl3")
+	}
+	return changes
+}
+
+// updatePodQueues applies the rx mode of the pod annotations to its
+// interfaces, and places their queues on the workers again
+func (s *Server) updatePodQueues(podSpec *storage.LocalPodSpec, newSpec *storage.LocalPodSpec) error {
+	if podSpec.TunTapSwIfIndex != vpplink.InvalidID {
+		err := s.tuntapDriver.UpdatePodInterfaceQueues(podSpec.TunTapSwIfIndex, newSpec.IfSpec)
+		if err != nil {
+			return errors.Wrapf(err, "error updating tun")
+		}
+	}
+	if podSpec.VhostUserSwIfIndex != vpplink.InvalidID {
+		err := s.vhostUserDriver.UpdatePodInterfaceQueues(podSpec.VhostUserSwIfIndex, newSpec.IfSpec)
+		if err != nil {
+			return errors.Wrapf(err, "error updating vhost-user")
+		}
+	}
+	if podSpec.MemifSwIfIndex != vpplink.InvalidID {
+		err := s.memifDriver.UpdatePodInterfaceQueues(podSpec.MemifSwIfIndex, newSpec.GetMemifSpec(), newSpec.MemifOptions)
+		if err != nil {
+			return errors.Wrapf(err, "error updating memif")
+		}
+	}
+	podSpec.IfSpec.RxMode = newSpec.IfSpec.RxMode
+	podSpec.PBLMemifSpec.RxMode = newSpec.PBLMemifSpec.RxMode
+	return nil
+}
+
+// diffSpoofingSources returns the prefixes of newSources missing from
+// oldSources, and the prefixes of oldSources missing from newSources
+func diffSpoofingSources(oldSources, newSources []cnet.IPNet) (added, removed []cnet.IPNet) {
+	oldKeys := make(map[string]bool)
+	for _, source := range oldSources {
+		oldKeys[source.String()] = true
+	}
+	newKeys := make(map[string]bool)
+	for _, source := range newSources {
+		newKeys[source.String()] = true
+		if !oldKeys[source.String()] {
+			added = append(added, source)
+		}
+	}
+	for _, source := range oldSources {
+		if !newKeys[source.String()] {
+			removed = append(removed, source)
+		}
+	}
+	return added, removed
+}
+
+// updatePodSpoofingPrefixes updates the routes allowing the pod to spoof
+// source addresses in its RPF VRFs. Prefixes both in the old and the new
+// annotations are left untouched.
+func (s *Server) updatePodSpoofingPrefixes(podSpec *storage.LocalPodSpec, allowedSpoofingPrefixes string) error {
+	// Multinet memif pods have no RPF VRF
+	if podSpec.NetworkName == "" || !podSpec.EnableMemif {
+		oldSources, err := s.parseSpoofingPrefixes(podSpec.AllowedSpoofingPrefixes)
+		if err != nil {
+			return err
+		}
+		newSources, err := s.parseSpoofingPrefixes(allowedSpoofingPrefixes)
+		if err != nil {
+			return err
+		}
+		added, removed := diffSpoofingSources(oldSources, newSources)
+		stack := s.vpp.NewCleanupStack()
+		err = s.addSpoofingSources(podSpec, stack, added)
+		if err != nil {
+			stack.Execute()
+			return err
+		}
+		s.delSpoofingSources(podSpec, removed)
+	}
+	podSpec.AllowedSpoofingPrefixes = allowedSpoofingPrefixes
+	return nil
+}
+
+// updatePodPblPorts replaces the port ranges sent to the port filtered
+// interface of the pod
+func (s *Server) updatePodPblPorts(podSpec *storage.LocalPodSpec, ifPortConfigs []storage.LocalIfPortConfigs) error {
+	swIfIndex, isL3 := podSpec.GetParamsForIfType(podSpec.PortFilteredIfType)
+	if swIfIndex == types.InvalidID {
+		podSpec.IfPortConfigs = ifPortConfigs
+		return nil
+	}
+	oldSpec := podSpec.Copy()
+	s.UnroutePblPortsPodInterface(podSpec, swIfIndex)
+	podSpec.IfPortConfigs = ifPortConfigs
+	stack := s.vpp.NewCleanupStack()
+	err := s.RoutePblPortsPodInterface(podSpec, stack, swIfIndex, isL3)
+	if err != nil {
+		stack.Execute()
+		*podSpec = oldSpec
+		// Restore the previous port ranges
+		if rerr := s.RoutePblPortsPodInterface(podSpec, s.vpp.NewCleanupStack(), swIfIndex, isL3); rerr != nil {
+			s.log.Errorf("Error restoring PBL client of %s: %s", podSpec.Key(), rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// updatePodSettings applies the settings of newSpec which can be changed on
+// running interfaces to podSpec, and returns the ones requiring a restart
+func (s *Server) updatePodSettings(podSpec *storage.LocalPodSpec, newSpec *storage.LocalPodSpec) (needRestart []string, err error) {
+	needRestart = append(interfaceSpecChanges("interface", podSpec.IfSpec, newSpec.IfSpec),
+		interfaceSpecChanges("memif", podSpec.PBLMemifSpec, newSpec.PBLMemifSpec)...)
+	if podSpec.EnableMemif != newSpec.EnableMemif || podSpec.PortFilteredIfType != newSpec.PortFilteredIfType {
+		needRestart = append(needRestart, "memif")
+	}
+	if podSpec.EnableVCL != newSpec.EnableVCL {
+		needRestart = append(needRestart, "vcl")
+	}
+	if podSpec.EnableVhostUser != newSpec.EnableVhostUser {
+		needRestart = append(needRestart, "vhost-user")
+	}
+	if podSpec.DefaultIfType != newSpec.DefaultIfType {
+		needRestart = append(needRestart, "default interface")
+	}
+	oldOptions, newOptions := podSpec.MemifOptions, newSpec.MemifOptions
+	if oldOptions.BufferSize != newOptions.BufferSize || oldOptions.Slave != newOptions.Slave || oldOptions.ZeroCopy != newOptions.ZeroCopy {
+		needRestart = append(needRestart, "memif options")
+	}
+	if podSpec.MulticastGroups != newSpec.MulticastGroups {
+		needRestart = append(needRestart, "multicast groups")
+	}
+	if podSpec.PolicyRoutes != newSpec.PolicyRoutes {
+		needRestart = append(needRestart, "policy routes")
+	}
+	if newSpec.ContainerMac != "" && podSpec.ContainerMac != newSpec.ContainerMac {
+		needRestart = append(needRestart, "mac address")
+	}
+
+	if podSpec.IfSpec.RxMode != newSpec.IfSpec.RxMode || podSpec.PBLMemifSpec.RxMode != newSpec.PBLMemifSpec.RxMode {
+		s.log.Infof("pod(upd) rx mode of %s", podSpec.Key())
+		err = s.updatePodQueues(podSpec, newSpec)
+		if err != nil {
+			return needRestart, errors.Wrapf(err, "error updating rx mode")
+		}
+	}
+	// Queues are placed again on the workers by onPodAnnotationsChanged
+	if !slices.Equal(oldOptions.RxWorkers, newOptions.RxWorkers) || !slices.Equal(oldOptions.TxWorkers, newOptions.TxWorkers) {
+		s.log.Infof("pod(upd) memif workers of %s", podSpec.Key())
+		podSpec.MemifOptions.RxWorkers = newOptions.RxWorkers
+		podSpec.MemifOptions.TxWorkers = newOptions.TxWorkers
+		if podSpec.MemifSwIfIndex != vpplink.InvalidID {
+			err = s.memifDriver.UpdatePodInterfaceQueues(podSpec.MemifSwIfIndex, podSpec.GetMemifSpec(), podSpec.MemifOptions)
+			if err != nil {
+				return needRestart, errors.Wrapf(err, "error updating memif workers")
+			}
+		}
+	}
+	if podSpec.AllowedSpoofingPrefixes != newSpec.AllowedSpoofingPrefixes {
+		s.log.Infof("pod(upd) spoofing prefixes of %s: %s", podSpec.Key(), newSpec.AllowedSpoofingPrefixes)
+		err = s.updatePodSpoofingPrefixes(podSpec, newSpec.AllowedSpoofingPrefixes)
+		if err != nil {
+			return needRestart, errors.Wrapf(err, "error updating spoofing prefixes")
+		}
+	}
+	// Port ranges can only change live if the memif is already there
+	if podSpec.EnableMemif && newSpec.EnableMemif && !reflect.DeepEqual(podSpec.IfPortConfigs, newSpec.IfPortConfigs) {
+		s.log.Infof("pod(upd) PBL ports of %s", podSpec.Key())
+		err = s.updatePodPblPorts(podSpec, newSpec.IfPortConfigs)
+		if err != nil {
+			return needRestart, errors.Wrapf(err, "error updating PBL ports")
+		}
+	}
+	if podSpec.IngressBandwidth != newSpec.IngressBandwidth || podSpec.EgressBandwidth != newSpec.EgressBandwidth ||
+		podSpec.QosClass != newSpec.QosClass || podSpec.Dscp != newSpec.Dscp {
+		s.log.Infof("pod(upd) bandwidth limits and QoS class of %s", podSpec.Key())
+		podSpec.IngressBandwidth = newSpec.IngressBandwidth
+		podSpec.EgressBandwidth = newSpec.EgressBandwidth
+		podSpec.QosClass = newSpec.QosClass
+		podSpec.Dscp = newSpec.Dscp
+		err = s.UpdatePodBandwidthLimits(podSpec)
+		if err != nil {
+			return needRestart, errors.Wrapf(err, "error updating bandwidth limits")
+		}
+	}
+	return needRestart, nil
+}
+
+// onPodAnnotationsChanged updates the interfaces of a pod after its
+// annotations changed, and reports the changes that need a pod restart
+func (s *Server) onPodAnnotationsChanged(update *watchers.PodAnnotationsUpdate) {
+	for key, podSpec := range s.podInterfaceMap {
+		if podSpec.WorkloadID != update.PodWorkloadID() {
+			continue
+		}
+		newSpec, err := s.parseAnnotatedSettings(&podSpec, update.Annotations, update.QosClass)
+		if err != nil {
+			s.log.Errorf("Cannot parse annotations of %s: %s", key, err)
+			continue
+		}
+		needRestart, err := s.updatePodSettings(&podSpec, newSpec)
+		if err != nil {
+			s.log.Errorf("Error updating %s: %s", key, err)
+		}
+		s.podInterfaceMap[key] = podSpec
+		err = s.podStore.Put(&podSpec)
+		if err != nil {
+			s.log.Errorf("CNI state persist errored %v", err)
+		}
+		if len(needRestart) != 0 {
+			s.log.Warnf("Changes to %s need a pod restart: %s", key, strings.Join(needRestart, ", "))
+			common.SendEvent(common.CalicoVppEvent{
+				Type: common.PodSettingsNeedRestart,
+				New: &watchers.PodSettingsReport{
+					Namespace: update.Namespace,
+					Name:      update.Name,
+					Message: fmt.Sprintf("Interface %s: changes to %s are only applied when the pod is recreated",
+						podSpec.InterfaceName, strings.Join(needRestart, ", ")),
+				},
+			})
+		}
+	}
+	s.balanceMemifQueues()
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	cniproto "github.com/projectcalico/calico/cni-plugin/pkg/dataplane/grpc/proto"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+func sourceStrings(sources []cnet.IPNet) []string {
+	out := make([]string, 0, len(sources))
+	for _, source := range sources {
+		out = append(out, source.String())
+	}
+	return out
+}
+
+func TestDiffSpoofingSources(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+
+	oldSources, err := s.parseSpoofingPrefixes(`["10.0.0.0/8", "192.168.1.0/24"]`)
+	g.Expect(err).ToNot(HaveOccurred())
+	newSources, err := s.parseSpoofingPrefixes(`["192.168.1.1/24", "172.16.0.1"]`)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	added, removed := diffSpoofingSources(oldSources, newSources)
+	g.Expect(sourceStrings(added)).To(Equal([]string{"172.16.0.1/32"}))
+	g.Expect(sourceStrings(removed)).To(Equal([]string{"10.0.0.0/8"}))
+
+	added, removed = diffSpoofingSources(nil, newSources)
+	g.Expect(sourceStrings(added)).To(Equal([]string{"192.168.1.0/24", "172.16.0.1/32"}))
+	g.Expect(removed).To(BeEmpty())
+
+	added, removed = diffSpoofingSources(oldSources, nil)
+	g.Expect(added).To(BeEmpty())
+	g.Expect(removed).To(HaveLen(2))
+
+	sources, err := s.parseSpoofingPrefixes("")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sources).To(BeEmpty())
+	_, err = s.parseSpoofingPrefixes(`["not-a-prefix"]`)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestParseAnnotatedSettings(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+	g.Expect(config.GetCalicoVppInterfaces().Validate()).To(Succeed())
+
+	podSpec := &storage.LocalPodSpec{InterfaceName: "eth0"}
+	newSpec, err := s.parseAnnotatedSettings(podSpec, map[string]string{
+		CalicoAnnotationPrefix + SpoofAnnotation:    `["10.0.0.0/8"]`,
+		VppAnnotationPrefix + IfSpecAnnotation:      `{"eth0": {"rx": 2, "tx": 2, "rxMode": "polling"}}`,
+		VppAnnotationPrefix + MulticastAnnotation:   `["239.1.1.1"]`,
+		IngressBandwidthAnnotation:                  "10M",
+		VppAnnotationPrefix + "UnrelatedAnnotation": "true",
+	}, "BestEffort")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(newSpec.InterfaceName).To(Equal("eth0"))
+	g.Expect(newSpec.DefaultIfType).To(Equal(storage.VppIfTypeTunTap))
+	g.Expect(newSpec.AllowedSpoofingPrefixes).To(Equal(`["10.0.0.0/8"]`))
+	g.Expect(newSpec.IfSpec.NumRxQueues).To(Equal(2))
+	g.Expect(newSpec.IfSpec.GetIsL3(false)).To(BeTrue())
+	g.Expect(newSpec.MulticastGroups).To(Equal(`["239.1.1.1"]`))
+	g.Expect(newSpec.IngressBandwidth).To(Equal(uint64(10000000)))
+	// No QoS class is configured
+	g.Expect(newSpec.QosClass).To(BeEmpty())
+
+	// Memif interfaces of other networks are L2 memifs
+	podSpec = &storage.LocalPodSpec{InterfaceName: "memif1", NetworkName: "net1"}
+	newSpec, err = s.parseAnnotatedSettings(podSpec, map[string]string{}, "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(newSpec.EnableMemif).To(BeTrue())
+	g.Expect(newSpec.DefaultIfType).To(Equal(storage.VppIfTypeMemif))
+	g.Expect(newSpec.IfSpec.GetIsL3(true)).To(BeFalse())
+}
+
+func TestUpdatePodSettings(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+	g.Expect(config.GetCalicoVppInterfaces().Validate()).To(Succeed())
+
+	// The pod has no address, so spoofing routes are not programmed
+	podSpec := &storage.LocalPodSpec{InterfaceName: "eth0", AllowedSpoofingPrefixes: `["10.0.0.0/8"]`}
+	newSpec, err := s.parseAnnotatedSettings(podSpec, map[string]string{
+		CalicoAnnotationPrefix + SpoofAnnotation: `["10.0.0.0/8"]`,
+	}, "")
+	g.Expect(err).ToNot(HaveOccurred())
+	podSpec.IfSpec = newSpec.IfSpec
+	podSpec.PBLMemifSpec = newSpec.PBLMemifSpec
+	podSpec.DefaultIfType = newSpec.DefaultIfType
+
+	needRestart, err := s.updatePodSettings(podSpec, newSpec)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(needRestart).To(BeEmpty())
+
+	newSpec, err = s.parseAnnotatedSettings(podSpec, map[string]string{
+		CalicoAnnotationPrefix + SpoofAnnotation:  `["10.0.0.0/8", "172.16.0.0/16"]`,
+		VppAnnotationPrefix + IfSpecAnnotation:    `{"eth0": {"rx": 4, "tx": 1, "rxqsz": 1024, "txqsz": 1024}}`,
+		VppAnnotationPrefix + MulticastAnnotation: `["239.1.1.1"]`,
+		VppAnnotationPrefix + VclAnnotation:       "enable",
+	}, "")
+	g.Expect(err).ToNot(HaveOccurred())
+	needRestart, err = s.updatePodSettings(podSpec, newSpec)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(needRestart).To(ConsistOf("interface queue count", "vcl", "multicast groups"))
+	g.Expect(podSpec.AllowedSpoofingPrefixes).To(Equal(`["10.0.0.0/8", "172.16.0.0/16"]`))
+	// Settings needing a restart are not applied
+	g.Expect(podSpec.MulticastGroups).To(BeEmpty())
+
+	// Invalid prefixes keep the previous ones
+	newSpec.AllowedSpoofingPrefixes = `["not-a-prefix"]`
+	_, err = s.updatePodSettings(podSpec, newSpec)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(podSpec.AllowedSpoofingPrefixes).To(Equal(`["10.0.0.0/8", "172.16.0.0/16"]`))
+}
+
+type testPodQosClassGetter map[string]string
+
+func (g testPodQosClassGetter) GetPodQosClass(namespace string, name string) (string, error) {
+	kubernetesClass, found := g[namespace+"/"+name]
+	if !found {
+		return "", errors.Errorf("pod %s/%s not found", namespace, name)
+	}
+	return kubernetesClass, nil
+}
+
+func TestAddResolvesKubernetesQosClass(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+	g.Expect(config.GetCalicoVppInterfaces().Validate()).To(Succeed())
+	qos := *config.CalicoVppQos
+	*config.CalicoVppQos = &config.CalicoVppQosConfigType{
+		Classes: map[string]config.QosClass{
+			"latency": {Dscp: 46},
+			"bulk":    {Dscp: 8},
+		},
+		KubernetesClasses: map[string]string{"Guaranteed": "latency"},
+	}
+	defer func() { *config.CalicoVppQos = qos }()
+	s.SetPodQosClassGetter(testPodQosClassGetter{
+		"ns1/guaranteed": "Guaranteed",
+		"ns1/besteffort": "BestEffort",
+	})
+
+	addRequest := func(pod string, annotations map[string]string) *cniproto.AddRequest {
+		return &cniproto.AddRequest{
+			InterfaceName: "eth0",
+			Workload:      &cniproto.WorkloadIDs{Namespace: "ns1", Pod: pod, Annotations: annotations},
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		request  *cniproto.AddRequest
+		qosClass string
+		dscp     uint8
+	}{
+		{"mapped kubernetes class", addRequest("guaranteed", nil), "latency", 46},
+		{"unmapped kubernetes class", addRequest("besteffort", nil), "", 0},
+		{"unknown pod", addRequest("unknown", nil), "", 0},
+		{"annotation takes precedence", addRequest("guaranteed", map[string]string{
+			VppAnnotationPrefix + QosClassAnnotation: "bulk",
+		}), "bulk", 8},
+	} {
+		podSpec, err := s.newLocalPodSpecFromAdd(tc.request)
+		g.Expect(err).ToNot(HaveOccurred(), tc.name)
+		g.Expect(podSpec.QosClass).To(Equal(tc.qosClass), tc.name)
+		g.Expect(podSpec.Dscp).To(Equal(tc.dscp), tc.name)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+const (
+	// MinPodBandwidth and MaxPodBandwidth bound the bandwidth annotations,
+	// VPP policers are configured in kbits/s on 32 bits
+	MinPodBandwidth = 1000
+	MaxPodBandwidth = 1000 * (1<<32 - 1)
+	// minPodPolicerBurst allows at least a jumbo frame in the bucket
+	minPodPolicerBurst = 9216
+	// maxPodPolicerBurst keeps the bucket of unlimited marking policers
+	// within what VPP accepts
+	maxPodPolicerBurst = 1<<31 - 1
+)
+
+// getPodPolicerBandwidth returns the bandwidth the policer of a pod should
+// enforce in a direction, and whether the pod needs one. Pods with a QoS
+// class always have an egress policer, marking their traffic.
+func getPodPolicerBandwidth(podSpec *storage.LocalPodSpec, isIngress bool) (direction string, bandwidth uint64, needed bool) {
+	if isIngress {
+		return "ingress", podSpec.IngressBandwidth, podSpec.IngressBandwidth != 0
+	}
+	if podSpec.EgressBandwidth == 0 && podSpec.QosClass != "" {
+		return "egress", MaxPodBandwidth, true
+	}
+	return "egress", podSpec.EgressBandwidth, podSpec.EgressBandwidth != 0
+}
+
+// newPodPolicer returns a policer enforcing bandwidth (in bits/s), with
+// a bucket holding 100ms worth of traffic. Egress policers also mark the
+// DSCP of the QoS class of the pod.
+func newPodPolicer(podSpec *storage.LocalPodSpec, direction string, bandwidth uint64) *types.Policer {
+	burst := bandwidth / 8 / 10
+	if burst < minPodPolicerBurst {
+		burst = minPodPolicerBurst
+	}
+	if burst > maxPodPolicerBurst {
+		burst = maxPodPolicerBurst
+	}
+	return &types.Policer{
+		Name:           podSpec.GetInterfaceTag(direction),
+		CommittedRate:  uint32(bandwidth / 1000),
+		CommittedBurst: burst,
+		MarkDscp:       direction == "egress" && podSpec.QosClass != "",
+		Dscp:           podSpec.Dscp,
+	}
+}
+
+// enableDisablePodPolicer binds the policer to the pod interface. Pod
+// ingress traffic is transmitted by VPP on the interface, pod egress
+// traffic is received on it.
+func (s *Server) enableDisablePodPolicer(policerIndex uint32, swIfIndex uint32, isIngress bool, enable bool) error {
+	if isIngress && enable {
+		return s.vpp.EnablePolicerOutput(policerIndex, swIfIndex)
+	} else if isIngress {
+		return s.vpp.DisablePolicerOutput(policerIndex, swIfIndex)
+	} else if enable {
+		return s.vpp.EnablePolicerInput(policerIndex, swIfIndex)
+	}
+	return s.vpp.DisablePolicerInput(policerIndex, swIfIndex)
+}
+
+func (s *Server) addPodPolicer(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, swIfIndex uint32, isIngress bool) (uint32, error) {
+	direction, bandwidth, _ := getPodPolicerBandwidth(podSpec, isIngress)
+	policer := newPodPolicer(podSpec, direction, bandwidth)
+	s.log.Infof("pod(add) %s policer %s", direction, policer.String())
+	err := s.vpp.AddPolicer(policer)
+	if err != nil {
+		return types.InvalidID, err
+	}
+	stack.Push(s.vpp.DelPolicer, policer.PolicerIndex)
+
+	err = s.enableDisablePodPolicer(policer.PolicerIndex, swIfIndex, isIngress, true /* enable */)
+	if err != nil {
+		return types.InvalidID, err
+	}
+	stack.Push(s.enableDisablePodPolicer, policer.PolicerIndex, swIfIndex, isIngress, false /* enable */)
+	return policer.PolicerIndex, nil
+}
+
+func (s *Server) delPodPolicer(policerIndex uint32, swIfIndex uint32, isIngress bool) {
+	err := s.enableDisablePodPolicer(policerIndex, swIfIndex, isIngress, false /* enable */)
+	if err != nil {
+		s.log.Errorf("error unbinding policer %d: %s", policerIndex, err)
+	}
+	err = s.vpp.DelPolicer(policerIndex)
+	if err != nil {
+		s.log.Errorf("error deleting policer %d: %s", policerIndex, err)
+	}
+}
+
+// AddPodBandwidthLimits polices the traffic of the pod interface according
+// to the kubernetes.io/ingress-bandwidth and egress-bandwidth annotations,
+// and marks its egress traffic with the DSCP of its QoS class
+func (s *Server) AddPodBandwidthLimits(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, swIfIndex uint32) (err error) {
+	podSpec.IngressPolicerIndex = types.InvalidID
+	podSpec.EgressPolicerIndex = types.InvalidID
+	if _, _, needed := getPodPolicerBandwidth(podSpec, true /* isIngress */); needed {
+		podSpec.IngressPolicerIndex, err = s.addPodPolicer(podSpec, stack, swIfIndex, true /* isIngress */)
+		if err != nil {
+			return errors.Wrapf(err, "error adding ingress policer")
+		}
+	}
+	if _, _, needed := getPodPolicerBandwidth(podSpec, false /* isIngress */); needed {
+		podSpec.EgressPolicerIndex, err = s.addPodPolicer(podSpec, stack, swIfIndex, false /* isIngress */)
+		if err != nil {
+			return errors.Wrapf(err, "error adding egress policer")
+		}
+	}
+	return nil
+}
+
+func (s *Server) updatePodPolicer(podSpec *storage.LocalPodSpec, policerIndex *uint32, swIfIndex uint32, isIngress bool) error {
+	direction, bandwidth, needed := getPodPolicerBandwidth(podSpec, isIngress)
+	switch {
+	case !needed && *policerIndex != types.InvalidID:
+		s.log.Infof("pod(upd) removing %s policer %d", direction, *policerIndex)
+		s.delPodPolicer(*policerIndex, swIfIndex, isIngress)
+		*policerIndex = types.InvalidID
+	case needed && *policerIndex != types.InvalidID:
+		policer := newPodPolicer(podSpec, direction, bandwidth)
+		policer.PolicerIndex = *policerIndex
+		s.log.Infof("pod(upd) %s policer %s", direction, policer.String())
+		return s.vpp.UpdatePolicer(policer)
+	case needed:
+		stack := s.vpp.NewCleanupStack()
+		index, err := s.addPodPolicer(podSpec, stack, swIfIndex, isIngress)
+		if err != nil {
+			stack.Execute()
+			return err
+		}
+		*policerIndex = index
+	}
+	return nil
+}
+
+// UpdatePodBandwidthLimits applies the current bandwidth limits and QoS
+// class of a pod whose interfaces already exist in VPP, creating, updating
+// or removing its policers as needed
+func (s *Server) UpdatePodBandwidthLimits(podSpec *storage.LocalPodSpec) error {
+	swIfIndex, _ := podSpec.GetParamsForIfType(podSpec.DefaultIfType)
+	if swIfIndex == types.InvalidID {
+		return nil
+	}
+	err := s.updatePodPolicer(podSpec, &podSpec.IngressPolicerIndex, swIfIndex, true /* isIngress */)
+	if err != nil {
+		return errors.Wrapf(err, "error updating ingress policer")
+	}
+	err = s.updatePodPolicer(podSpec, &podSpec.EgressPolicerIndex, swIfIndex, false /* isIngress */)
+	if err != nil {
+		return errors.Wrapf(err, "error updating egress policer")
+	}
+	common.SendEvent(common.CalicoVppEvent{
+		Type: common.PodBandwidthChanged,
+		New:  podSpec,
+	})
+	return nil
+}
+
+func (s *Server) DelPodBandwidthLimits(podSpec *storage.LocalPodSpec, swIfIndex uint32) {
+	if podSpec.IngressPolicerIndex != types.InvalidID {
+		s.log.Infof("pod(del) ingress policer %d", podSpec.IngressPolicerIndex)
+		s.delPodPolicer(podSpec.IngressPolicerIndex, swIfIndex, true /* isIngress */)
+		podSpec.IngressPolicerIndex = types.InvalidID
+	}
+	if podSpec.EgressPolicerIndex != types.InvalidID {
+		s.log.Infof("pod(del) egress policer %d", podSpec.EgressPolicerIndex)
+		s.delPodPolicer(podSpec.EgressPolicerIndex, swIfIndex, false /* isIngress */)
+		podSpec.EgressPolicerIndex = types.InvalidID
+	}
+}
@@ -0,0 +1,395 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	vpptypes "github.com/calico-vpp/vpplink/api/v0"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/watchers"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+// egressTunnel carries the traffic of the local pods selected by egress
+// policies with the same egressIP to the active gateway node. The pods VRF
+// default route points to a dedicated VRF, which sends cluster traffic
+// to the PodVRF as usual and everything else into the tunnel.
+type egressTunnel struct {
+	egressIP  net.IP
+	swIfIndex uint32
+	vrfID     uint32
+	prefixes  map[string]*net.IPNet
+	pods      map[string]bool
+}
+
+func (s *Server) egressGatewayEnabled() bool {
+	return *config.GetCalicoVppFeatureGates().EgressGatewayEnabled
+}
+
+func getEgressVrfTag(egressIP net.IP) string {
+	return fmt.Sprintf("egress-src-%s", egressIP)
+}
+
+// getPodEgressIP returns the egress IP the pod traffic should be tunneled
+// to, or ""
+func (s *Server) getPodEgressIP(podSpec *storage.LocalPodSpec) string {
+	if s.egressPolicyState == nil || podSpec.NetworkName != "" {
+		return ""
+	}
+	namespace, _, _ := strings.Cut(podSpec.WorkloadID, "/")
+	podLabels, err := labels.ConvertSelectorToLabelsMap(podSpec.PodLabels)
+	if err != nil {
+		s.log.Warnf("Error parsing labels of pod %s: %s", podSpec.WorkloadID, err)
+	}
+	policy := s.egressPolicyState.GetPodPolicy(namespace, podLabels)
+	if policy == nil {
+		return ""
+	}
+	// On the active gateway too, the pod traffic goes through a tunnel to
+	// the local egress IP, where it is source NATed. The other pods keep the
+	// node address
+	return policy.EgressIP.String()
+}
+
+// getEgressClusterPrefixes returns the destinations that should not be sent
+// to the egress gateway, i.e. the IP pools, the services and the nodes
+func (s *Server) getEgressClusterPrefixes() map[string]*net.IPNet {
+	prefixes := make(map[string]*net.IPNet)
+	for _, prefix := range append(s.felixServerIpam.GetIPPoolPrefixes(), *config.ServiceCIDRs...) {
+		if prefix.IP.To4() != nil {
+			prefixes[prefix.String()] = prefix
+		}
+	}
+	if s.egressPolicyState != nil {
+		for _, nodeIP := range s.egressPolicyState.NodeIPs {
+			prefix := common.ToMaxLenCIDR(nodeIP)
+			prefixes[prefix.String()] = prefix
+		}
+	}
+	return prefixes
+}
+
+func (s *Server) addDelEgressClusterPrefix(tunnel *egressTunnel, prefix *net.IPNet, isAdd bool) error {
+	route := &types.Route{
+		Dst:   prefix,
+		Table: tunnel.vrfID,
+		Paths: []types.RoutePath{{
+			Table:     common.PodVRFIndex,
+			SwIfIndex: types.InvalidID,
+		}},
+	}
+	if isAdd {
+		return s.vpp.RouteAdd(route)
+	}
+	return s.vpp.RouteDel(route)
+}
+
+func (s *Server) syncEgressClusterPrefixes(tunnel *egressTunnel) {
+	prefixes := s.getEgressClusterPrefixes()
+	for key, prefix := range tunnel.prefixes {
+		if _, found := prefixes[key]; found {
+			continue
+		}
+		err := s.addDelEgressClusterPrefix(tunnel, prefix, false /* isAdd */)
+		if err != nil {
+			s.log.Errorf("Error deleting egress route %s in VRF %d: %s", prefix, tunnel.vrfID, err)
+		}
+		delete(tunnel.prefixes, key)
+	}
+	for key, prefix := range prefixes {
+		if _, found := tunnel.prefixes[key]; found {
+			continue
+		}
+		err := s.addDelEgressClusterPrefix(tunnel, prefix, true /* isAdd */)
+		if err != nil {
+			s.log.Errorf("Error adding egress route %s in VRF %d: %s", prefix, tunnel.vrfID, err)
+			continue
+		}
+		tunnel.prefixes[key] = prefix
+	}
+}
+
+func (s *Server) updateEgressClusterPrefixes() {
+	for _, tunnel := range s.egressTunnels {
+		s.syncEgressClusterPrefixes(tunnel)
+	}
+}
+
+// findEgressTunnel looks for the VRF and the tunnel created for egressIP
+// by a previous instance of the agent
+func (s *Server) findEgressTunnel(tunnel *egressTunnel, nodeIP net.IP) error {
+	vrfs, err := s.vpp.ListVRFs()
+	if err != nil {
+		return errors.Wrap(err, "error listing VRFs")
+	}
+	for _, vrf := range vrfs {
+		if vrf.Name == getEgressVrfTag(tunnel.egressIP) && !vrf.IsIP6 {
+			tunnel.vrfID = vrf.VrfID
+		}
+	}
+	tunnels, err := s.vpp.ListIPIPTunnels()
+	if err != nil {
+		return errors.Wrap(err, "error listing IPIP tunnels")
+	}
+	for _, t := range tunnels {
+		if t.Src.Equal(nodeIP) && t.Dst.Equal(tunnel.egressIP) {
+			tunnel.swIfIndex = t.SwIfIndex
+		}
+	}
+	return nil
+}
+
+func (s *Server) getOrCreateEgressTunnel(egressIPStr string) (*egressTunnel, error) {
+	if tunnel, found := s.egressTunnels[egressIPStr]; found {
+		return tunnel, nil
+	}
+	egressIP := net.ParseIP(egressIPStr).To4()
+	if egressIP == nil {
+		return nil, errors.Errorf("invalid egress IP %s", egressIPStr)
+	}
+	if s.nodeBGPSpec == nil || s.nodeBGPSpec.IPv4Address == nil {
+		return nil, errors.Errorf("missing node IPv4 address")
+	}
+	nodeIP := s.nodeBGPSpec.IPv4Address.IP
+	tunnel := &egressTunnel{
+		egressIP:  egressIP,
+		swIfIndex: types.InvalidID,
+		vrfID:     types.InvalidID,
+		prefixes:  make(map[string]*net.IPNet),
+		pods:      make(map[string]bool),
+	}
+	err := s.findEgressTunnel(tunnel, nodeIP)
+	if err != nil {
+		return nil, err
+	}
+	stack := s.vpp.NewCleanupStack()
+	if tunnel.vrfID == types.InvalidID {
+		tunnel.vrfID, err = s.vpp.AllocateVRF(false /* isIP6 */, getEgressVrfTag(egressIP))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error allocating egress VRF for %s", egressIP)
+		}
+		stack.Push(s.vpp.DelVRF, tunnel.vrfID, false /* isIP6 */)
+	}
+	if tunnel.swIfIndex == types.InvalidID {
+		ipipTunnel := &vpptypes.IPIPTunnel{Src: nodeIP, Dst: egressIP}
+		s.log.Infof("egress(add) create IPIP tunnel=%s", ipipTunnel.String())
+		tunnel.swIfIndex, err = s.vpp.AddIPIPTunnelCopyDscp(ipipTunnel, config.GetCalicoVppQos().HasClasses())
+		if err != nil {
+			stack.Execute()
+			return nil, errors.Wrapf(err, "error adding egress tunnel to %s", egressIP)
+		}
+		stack.Push(s.vpp.DelIPIPTunnel, ipipTunnel)
+		err = s.vpp.InterfaceSetUnnumbered(tunnel.swIfIndex, common.GetVppManagerInfo().GetMainSwIfIndex())
+		if err != nil {
+			stack.Execute()
+			return nil, errors.Wrapf(err, "error setting egress tunnel unnumbered")
+		}
+		err = s.vpp.InterfaceAdminUp(tunnel.swIfIndex)
+		if err != nil {
+			stack.Execute()
+			return nil, errors.Wrapf(err, "error setting egress tunnel up")
+		}
+	}
+	err = s.vpp.RouteAdd(&types.Route{
+		Dst:   &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+		Table: tunnel.vrfID,
+		Paths: []types.RoutePath{{SwIfIndex: tunnel.swIfIndex}},
+	})
+	if err != nil {
+		stack.Execute()
+		return nil, errors.Wrapf(err, "error adding egress default route in VRF %d", tunnel.vrfID)
+	}
+	s.syncEgressClusterPrefixes(tunnel)
+	s.egressTunnels[egressIPStr] = tunnel
+	return tunnel, nil
+}
+
+func (s *Server) deleteEgressTunnel(egressIPStr string) {
+	tunnel, found := s.egressTunnels[egressIPStr]
+	if !found {
+		return
+	}
+	s.log.Infof("egress(del) tunnel to %s", egressIPStr)
+	for key, prefix := range tunnel.prefixes {
+		err := s.addDelEgressClusterPrefix(tunnel, prefix, false /* isAdd */)
+		if err != nil {
+			s.log.Errorf("Error deleting egress route %s in VRF %d: %s", prefix, tunnel.vrfID, err)
+		}
+		delete(tunnel.prefixes, key)
+	}
+	err := s.vpp.RouteDel(&types.Route{
+		Dst:   &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+		Table: tunnel.vrfID,
+		Paths: []types.RoutePath{{SwIfIndex: tunnel.swIfIndex}},
+	})
+	if err != nil {
+		s.log.Errorf("Error deleting egress default route in VRF %d: %s", tunnel.vrfID, err)
+	}
+	err = s.vpp.DelIPIPTunnel(&vpptypes.IPIPTunnel{SwIfIndex: tunnel.swIfIndex})
+	if err != nil {
+		s.log.Errorf("Error deleting egress tunnel %d: %s", tunnel.swIfIndex, err)
+	}
+	err = s.vpp.DelVRF(tunnel.vrfID, false /* isIP6 */)
+	if err != nil {
+		s.log.Errorf("Error deleting egress VRF %d: %s", tunnel.vrfID, err)
+	}
+	delete(s.egressTunnels, egressIPStr)
+}
+
+func (s *Server) enableDisablePodSnat4(podSpec *storage.LocalPodSpec, isEnable bool) error {
+	if !podSpec.NeedsSnat {
+		return nil
+	}
+	for _, swIfIndex := range []uint32{podSpec.LoopbackSwIfIndex, podSpec.TunTapSwIfIndex, podSpec.MemifSwIfIndex, podSpec.VhostUserSwIfIndex, podSpec.SriovSwIfIndex} {
+		if swIfIndex == vpplink.InvalidID {
+			continue
+		}
+		err := s.vpp.EnableDisableCnatSNAT(swIfIndex, false /* isIP6 */, isEnable)
+		if err != nil {
+			return errors.Wrapf(err, "error setting SNAT on if[%d]", swIfIndex)
+		}
+	}
+	return nil
+}
+
+func (s *Server) applyPodEgress(podSpec *storage.LocalPodSpec, egressIPStr string) error {
+	tunnel, err := s.getOrCreateEgressTunnel(egressIPStr)
+	if err != nil {
+		return err
+	}
+	s.log.Infof("pod(add) egress via %s for %s", egressIPStr, podSpec.Key())
+	err = s.vpp.DelDefaultRouteViaTable(podSpec.V4VrfID, common.PodVRFIndex, false /* isIP6 */)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting VRF %d default route", podSpec.V4VrfID)
+	}
+	err = s.vpp.AddDefaultRouteViaTable(podSpec.V4VrfID, tunnel.vrfID, false /* isIP6 */)
+	if err != nil {
+		return errors.Wrapf(err, "error adding VRF %d default route via egress VRF %d", podSpec.V4VrfID, tunnel.vrfID)
+	}
+	err = s.enableDisablePodSnat4(podSpec, false /* isEnable */)
+	if err != nil {
+		return err
+	}
+	tunnel.pods[podSpec.Key()] = true
+	podSpec.EgressIP = egressIPStr
+	return nil
+}
+
+func (s *Server) removePodEgress(podSpec *storage.LocalPodSpec) {
+	if podSpec.EgressIP == "" {
+		return
+	}
+	s.log.Infof("pod(del) egress via %s for %s", podSpec.EgressIP, podSpec.Key())
+	tunnel, found := s.egressTunnels[podSpec.EgressIP]
+	if found {
+		err := s.vpp.DelDefaultRouteViaTable(podSpec.V4VrfID, tunnel.vrfID, false /* isIP6 */)
+		if err != nil {
+			s.log.Errorf("Error deleting VRF %d default route via egress VRF: %s", podSpec.V4VrfID, err)
+		}
+	}
+	err := s.vpp.AddDefaultRouteViaTable(podSpec.V4VrfID, common.PodVRFIndex, false /* isIP6 */)
+	if err != nil {
+		s.log.Errorf("Error restoring VRF %d default route: %s", podSpec.V4VrfID, err)
+	}
+	err = s.enableDisablePodSnat4(podSpec, true /* isEnable */)
+	if err != nil {
+		s.log.Error(err)
+	}
+	if found {
+		delete(tunnel.pods, podSpec.Key())
+		if len(tunnel.pods) == 0 {
+			s.deleteEgressTunnel(podSpec.EgressIP)
+		}
+	}
+	podSpec.EgressIP = ""
+}
+
+// reconcilePodEgress makes the pod egress traffic use the egress IP of the
+// policy currently selecting it. It returns whether podSpec was modified
+func (s *Server) reconcilePodEgress(podSpec *storage.LocalPodSpec) (bool, error) {
+	egressIP := s.getPodEgressIP(podSpec)
+	if egressIP == podSpec.EgressIP {
+		return false, nil
+	}
+	s.removePodEgress(podSpec)
+	if egressIP == "" {
+		return true, nil
+	}
+	return true, s.applyPodEgress(podSpec, egressIP)
+}
+
+// AddPodEgressGateway sends the pod traffic leaving the cluster to its egress
+// gateway if an egress policy selects it
+func (s *Server) AddPodEgressGateway(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack) error {
+	podSpec.EgressIP = ""
+	if !s.egressGatewayEnabled() {
+		return nil
+	}
+	_, err := s.reconcilePodEgress(podSpec)
+	if err != nil {
+		return err
+	}
+	stack.Push(s.removePodEgress, podSpec)
+	return nil
+}
+
+// RestorePodEgressGateway reattaches a pod created by a previous instance
+// of the agent to its egress tunnel
+func (s *Server) RestorePodEgressGateway(podSpec *storage.LocalPodSpec) {
+	if podSpec.EgressIP == "" {
+		return
+	}
+	tunnel, err := s.getOrCreateEgressTunnel(podSpec.EgressIP)
+	if err != nil {
+		s.log.Errorf("Error restoring egress tunnel for %s: %s", podSpec.Key(), err)
+		return
+	}
+	tunnel.pods[podSpec.Key()] = true
+}
+
+func (s *Server) DelPodEgressGateway(podSpec *storage.LocalPodSpec) {
+	s.removePodEgress(podSpec)
+}
+
+func (s *Server) onEgressPoliciesChanged(state *watchers.EgressPolicyState) {
+	if !s.egressGatewayEnabled() {
+		return
+	}
+	s.egressPolicyState = state
+	s.updateEgressClusterPrefixes()
+	for key, podSpec := range s.podInterfaceMap {
+		podChanged, err := s.reconcilePodEgress(&podSpec)
+		if err != nil {
+			s.log.Errorf("Error updating egress of %s: %s", key, err)
+		}
+		if podChanged {
+			s.podInterfaceMap[key] = podSpec
+			err = s.podStore.Put(&podSpec)
+			if err != nil {
+				s.log.Errorf("CNI state persist errored %v", err)
+			}
+		}
+	}
+}
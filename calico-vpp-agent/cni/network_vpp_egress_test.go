@@ -0,0 +1,65 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/watchers"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+func TestGetPodEgressIPOnGateway(t *testing.T) {
+	g := NewWithT(t)
+	nodeName := *config.NodeName
+	*config.NodeName = "node-a"
+	defer func() { *config.NodeName = nodeName }()
+
+	s := newTestServer()
+	s.egressPolicyState = &watchers.EgressPolicyState{
+		Policies: map[string]*watchers.EgressPolicy{
+			"ns1": {
+				Namespace:    "ns1",
+				EgressIP:     net.ParseIP("192.168.0.200").To4(),
+				GatewayNodes: []string{"node-a", "node-b"},
+				PodSelector:  "app=checkout",
+			},
+		},
+		ReadyNodes: map[string]bool{"node-a": true, "node-b": true},
+	}
+
+	// The selected pods of the gateway node are tunneled to the local egress
+	// IP, to be source NATed there
+	g.Expect(s.getPodEgressIP(&storage.LocalPodSpec{
+		WorkloadID: "ns1/checkout",
+		PodLabels:  "app=checkout",
+	})).To(Equal("192.168.0.200"))
+
+	// The other pods of the gateway node are not tunneled and keep the node
+	// address
+	g.Expect(s.getPodEgressIP(&storage.LocalPodSpec{
+		WorkloadID: "ns1/web",
+		PodLabels:  "app=web",
+	})).To(BeEmpty())
+	g.Expect(s.getPodEgressIP(&storage.LocalPodSpec{
+		WorkloadID: "ns2/checkout",
+		PodLabels:  "app=checkout",
+	})).To(BeEmpty())
+}
@@ -0,0 +1,187 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/mfib_types"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+// multicastGroup tracks the local pod interfaces that joined a group.
+// Multicast sent by pods is looked up in the PodVRF (see CreatePodVRF)
+// and is replicated to members, to the uplink and to tunnels.
+// Multicast received on the uplink or in a tunnel is looked up in the
+// default VRF and is replicated to members only.
+type multicastGroup struct {
+	group   *net.IPNet
+	members map[uint32]bool
+}
+
+func (s *Server) multicastEnabled() bool {
+	return *config.GetCalicoVppFeatureGates().MulticastEnabled
+}
+
+// getMulticastEgressSwIfIndexes returns the interfaces that multicast
+// sent by local pods should be replicated to, besides local members
+func (s *Server) getMulticastEgressSwIfIndexes() []uint32 {
+	swIfIndexes := make([]uint32, 0)
+	if *config.GetCalicoVppMulticast().ForwardToUplink {
+		mainSwIfIndex := common.GetVppManagerInfo().GetMainSwIfIndex()
+		if mainSwIfIndex != vpplink.InvalidSwIfIndex {
+			swIfIndexes = append(swIfIndexes, mainSwIfIndex)
+		}
+	}
+	if *config.GetCalicoVppMulticast().ReplicateToTunnels {
+		for swIfIndex := range s.multicastTunnels {
+			swIfIndexes = append(swIfIndexes, swIfIndex)
+		}
+	}
+	return swIfIndexes
+}
+
+func (s *Server) addDelMulticastPath(group *net.IPNet, table uint32, swIfIndex uint32, isAdd bool) error {
+	route := &types.Route{
+		Dst:   group,
+		Table: table,
+		Paths: []types.RoutePath{{SwIfIndex: swIfIndex}},
+	}
+	if isAdd {
+		return s.vpp.MRouteAdd(route, mfib_types.MFIB_API_ENTRY_FLAG_ACCEPT_ALL_ITF)
+	}
+	return s.vpp.MRouteDel(route, mfib_types.MFIB_API_ENTRY_FLAG_ACCEPT_ALL_ITF)
+}
+
+func (s *Server) joinMulticastGroup(group *net.IPNet, swIfIndex uint32) (err error) {
+	mgroup, found := s.multicastGroups[group.String()]
+	if !found {
+		mgroup = &multicastGroup{group: group, members: make(map[uint32]bool)}
+		for _, egressSwIfIndex := range s.getMulticastEgressSwIfIndexes() {
+			s.log.Infof("pod(add) mroute %s [podVRF->if[%d]]", group, egressSwIfIndex)
+			err = s.addDelMulticastPath(group, common.PodVRFIndex, egressSwIfIndex, true /* isAdd */)
+			if err != nil {
+				return errors.Wrapf(err, "error adding mroute %s to if[%d]", group, egressSwIfIndex)
+			}
+		}
+		s.multicastGroups[group.String()] = mgroup
+	}
+	if mgroup.members[swIfIndex] {
+		return nil
+	}
+	for _, table := range []uint32{common.PodVRFIndex, common.DefaultVRFIndex} {
+		s.log.Infof("pod(add) mroute %s [VRF %d->if[%d]]", group, table, swIfIndex)
+		err = s.addDelMulticastPath(group, table, swIfIndex, true /* isAdd */)
+		if err != nil {
+			return errors.Wrapf(err, "error adding mroute %s in VRF %d to if[%d]", group, table, swIfIndex)
+		}
+	}
+	mgroup.members[swIfIndex] = true
+	return nil
+}
+
+func (s *Server) leaveMulticastGroup(group *net.IPNet, swIfIndex uint32) {
+	mgroup, found := s.multicastGroups[group.String()]
+	if !found || !mgroup.members[swIfIndex] {
+		return
+	}
+	for _, table := range []uint32{common.PodVRFIndex, common.DefaultVRFIndex} {
+		s.log.Infof("pod(del) mroute %s [VRF %d->if[%d]]", group, table, swIfIndex)
+		err := s.addDelMulticastPath(group, table, swIfIndex, false /* isAdd */)
+		if err != nil {
+			s.log.Errorf("error deleting mroute %s in VRF %d to if[%d]: %s", group, table, swIfIndex, err)
+		}
+	}
+	delete(mgroup.members, swIfIndex)
+	if len(mgroup.members) > 0 {
+		return
+	}
+	for _, egressSwIfIndex := range s.getMulticastEgressSwIfIndexes() {
+		s.log.Infof("pod(del) mroute %s [podVRF->if[%d]]", group, egressSwIfIndex)
+		err := s.addDelMulticastPath(group, common.PodVRFIndex, egressSwIfIndex, false /* isAdd */)
+		if err != nil {
+			s.log.Errorf("error deleting mroute %s to if[%d]: %s", group, egressSwIfIndex, err)
+		}
+	}
+	delete(s.multicastGroups, group.String())
+}
+
+// AddPodMulticastGroups subscribes the pod default interface to the
+// multicast groups listed in its annotations
+func (s *Server) AddPodMulticastGroups(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, swIfIndex uint32) error {
+	if podSpec.MulticastGroups == "" {
+		return nil
+	}
+	if !s.multicastEnabled() {
+		s.log.Warnf("pod(add) multicast groups requested but multicast is disabled, ignoring")
+		return nil
+	}
+	groups, err := s.ParseMulticastGroupsAnnotation(podSpec.MulticastGroups)
+	if err != nil {
+		return errors.Wrapf(err, "error parsing multicast groups")
+	}
+	for _, group := range groups {
+		err = s.joinMulticastGroup(group, swIfIndex)
+		if err != nil {
+			return err
+		}
+		stack.Push(s.leaveMulticastGroup, group, swIfIndex)
+	}
+	return nil
+}
+
+func (s *Server) DelPodMulticastGroups(podSpec *storage.LocalPodSpec, swIfIndex uint32) {
+	if podSpec.MulticastGroups == "" || !s.multicastEnabled() {
+		return
+	}
+	groups, err := s.ParseMulticastGroupsAnnotation(podSpec.MulticastGroups)
+	if err != nil {
+		s.log.WithError(err).Error("error parsing multicast groups")
+		return
+	}
+	for _, group := range groups {
+		s.leaveMulticastGroup(group, swIfIndex)
+	}
+}
+
+// onMulticastTunnelAddedOrDeleted replicates the multicast sent by pods in a
+// newly created tunnel, or stops doing so when the tunnel goes away
+func (s *Server) onMulticastTunnelAddedOrDeleted(swIfIndex uint32, isAdd bool) {
+	if !s.multicastEnabled() || !*config.GetCalicoVppMulticast().ReplicateToTunnels {
+		return
+	}
+	if s.multicastTunnels[swIfIndex] == isAdd {
+		return
+	}
+	for _, mgroup := range s.multicastGroups {
+		s.log.Infof("%s mroute %s [podVRF->tunnel if[%d]]", vpplink.IsAddToStr(isAdd), mgroup.group, swIfIndex)
+		err := s.addDelMulticastPath(mgroup.group, common.PodVRFIndex, swIfIndex, isAdd)
+		if err != nil {
+			s.log.Errorf("error updating mroute %s to tunnel if[%d]: %s", mgroup.group, swIfIndex, err)
+		}
+	}
+	if isAdd {
+		s.multicastTunnels[swIfIndex] = true
+	} else {
+		delete(s.multicastTunnels, swIfIndex)
+	}
+}
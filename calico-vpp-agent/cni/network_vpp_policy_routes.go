@@ -0,0 +1,156 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+// getPolicyRoutePath returns the path that traffic matching the policy
+// route should be forwarded to
+func (s *Server) getPolicyRoutePath(policyRoute *PolicyRoute) (*types.RoutePath, error) {
+	if policyRoute.Vrf != nil {
+		return &types.RoutePath{
+			Table:     *policyRoute.Vrf,
+			SwIfIndex: types.InvalidID,
+		}, nil
+	}
+	path := &types.RoutePath{
+		Gw:        policyRoute.Gateway,
+		SwIfIndex: types.InvalidID,
+	}
+	if policyRoute.Uplink != "" {
+		uplinkStatus, found := common.GetVppManagerInfo().UplinkStatuses[policyRoute.Uplink]
+		if !found {
+			return nil, errors.Errorf("uplink %s not found", policyRoute.Uplink)
+		}
+		path.SwIfIndex = uplinkStatus.SwIfIndex
+	}
+	return path, nil
+}
+
+func (s *Server) addPolicyRoute(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, policyRoute *PolicyRoute, swIfIndex uint32) error {
+	path, err := s.getPolicyRoutePath(policyRoute)
+	if err != nil {
+		return err
+	}
+	isIP6 := vpplink.IsIP6(policyRoute.Dst.IP)
+	anyPrefix := net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)}
+	if isIP6 {
+		anyPrefix = net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+	}
+	acl := &types.ACL{
+		Tag: podSpec.GetInterfaceTag("pbr"),
+		Rules: []types.ACLRule{{
+			Src:     anyPrefix,
+			Dst:     *policyRoute.Dst,
+			DstPort: policyRoute.Port,
+			Proto:   policyRoute.Proto,
+		}},
+	}
+	err = s.vpp.AddACL(acl)
+	if err != nil {
+		return errors.Wrapf(err, "error adding ACL for %s", policyRoute.Dst)
+	}
+	stack.Push(s.vpp.DelACL, acl.ACLIndex)
+
+	policy := &types.AbfPolicy{
+		ACLIndex: acl.ACLIndex,
+		Paths:    []types.RoutePath{*path},
+		IsIPv6:   isIP6,
+	}
+	err = s.vpp.AddAbfPolicy(policy)
+	if err != nil {
+		return errors.Wrapf(err, "error adding ABF policy for %s", policyRoute.Dst)
+	}
+	stack.Push(s.vpp.DelAbfPolicy, policy)
+
+	err = s.vpp.AttachAbfPolicy(policy.PolicyID, swIfIndex, isIP6)
+	if err != nil {
+		return errors.Wrapf(err, "error attaching ABF policy %d to if[%d]", policy.PolicyID, swIfIndex)
+	}
+	stack.Push(s.vpp.DetachAbfPolicy, policy.PolicyID, swIfIndex, isIP6)
+
+	podSpec.PolicyRouteIndexes = append(podSpec.PolicyRouteIndexes, storage.LocalPolicyRoute{
+		ACLIndex:    acl.ACLIndex,
+		AbfPolicyID: policy.PolicyID,
+		SwIfIndex:   swIfIndex,
+		IsIP6:       isIP6,
+	})
+	return nil
+}
+
+// AddPodPolicyRoutes steers the pod egress traffic matching the rules in
+// its PolicyRoutes annotation with ABF policies attached to swIfIndex
+func (s *Server) AddPodPolicyRoutes(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, swIfIndex uint32) error {
+	podSpec.PolicyRouteIndexes = make([]storage.LocalPolicyRoute, 0)
+	if podSpec.PolicyRoutes == "" {
+		return nil
+	}
+	policyRoutes, err := s.ParsePolicyRoutesAnnotation(podSpec.PolicyRoutes)
+	if err != nil {
+		return errors.Wrapf(err, "error parsing policy routes")
+	}
+	for _, policyRoute := range policyRoutes {
+		s.log.Infof("pod(add) policy route %s proto %s port %d", policyRoute.Dst, policyRoute.Proto.String(), policyRoute.Port)
+		err = s.addPolicyRoute(podSpec, stack, policyRoute, swIfIndex)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestorePodPolicyRoutes reserves the ABF policy IDs of a pod that was
+// created by a previous instance of the agent
+func (s *Server) RestorePodPolicyRoutes(podSpec *storage.LocalPodSpec) {
+	for _, policyRoute := range podSpec.PolicyRouteIndexes {
+		err := s.vpp.ReserveAbfPolicyID(policyRoute.AbfPolicyID)
+		if err != nil {
+			s.log.Errorf("error reserving ABF policy %d: %s", policyRoute.AbfPolicyID, err)
+		}
+	}
+}
+
+func (s *Server) DelPodPolicyRoutes(podSpec *storage.LocalPodSpec) {
+	for _, policyRoute := range podSpec.PolicyRouteIndexes {
+		s.log.Infof("pod(del) policy route %s", policyRoute.String())
+		err := s.vpp.DetachAbfPolicy(policyRoute.AbfPolicyID, policyRoute.SwIfIndex, policyRoute.IsIP6)
+		if err != nil {
+			s.log.Errorf("error detaching ABF policy %d: %s", policyRoute.AbfPolicyID, err)
+		}
+		err = s.vpp.DelAbfPolicy(&types.AbfPolicy{
+			PolicyID: policyRoute.AbfPolicyID,
+			ACLIndex: policyRoute.ACLIndex,
+			IsIPv6:   policyRoute.IsIP6,
+		})
+		if err != nil {
+			s.log.Errorf("error deleting ABF policy %d: %s", policyRoute.AbfPolicyID, err)
+		}
+		err = s.vpp.DelACL(policyRoute.ACLIndex)
+		if err != nil {
+			s.log.Errorf("error deleting ACL %d: %s", policyRoute.ACLIndex, err)
+		}
+	}
+	podSpec.PolicyRouteIndexes = make([]storage.LocalPolicyRoute, 0)
+}
@@ -16,7 +16,10 @@
 package cni
 
 import (
+	"net"
+
 	"github.com/pkg/errors"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
 
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
@@ -25,6 +28,21 @@ import (
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
 )
 
+// getContainerNeighborMac returns the MAC address VPP sends to for the pod
+// on the L2 interface swIfIndex. The agent sets the MAC of the tun and of
+// the SR-IOV VF, whereas memif and vhost-user applications keep using
+// common.ContainerSideMacAddress unless a MAC is requested by annotation.
+func getContainerNeighborMac(podSpec *storage.LocalPodSpec, swIfIndex uint32) net.HardwareAddr {
+	mac := podSpec.GetContainerMac()
+	if mac == nil {
+		return common.ContainerSideMacAddress
+	}
+	if podSpec.ContainerMacAnnotated || swIfIndex == podSpec.TunTapSwIfIndex || swIfIndex == podSpec.SriovSwIfIndex {
+		return mac
+	}
+	return common.ContainerSideMacAddress
+}
+
 func (s *Server) RoutePodInterface(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, swIfIndex uint32, isL3 bool, inPodVrf bool) error {
 	for _, containerIP := range podSpec.GetContainerIps() {
 		var table uint32
@@ -65,7 +83,7 @@ func (s *Server) RoutePodInterface(podSpec *storage.LocalPodSpec, stack *vpplink
 			err = s.vpp.AddNeighbor(&types.Neighbor{
 				SwIfIndex:    swIfIndex,
 				IP:           containerIP.IP,
-				HardwareAddr: common.ContainerSideMacAddress,
+				HardwareAddr: getContainerNeighborMac(podSpec, swIfIndex),
 				Flags:        types.IPNeighborStatic,
 			})
 			if err != nil {
@@ -157,7 +175,7 @@ func (s *Server) RoutePblPortsPodInterface(podSpec *storage.LocalPodSpec, stack
 			err = s.vpp.AddNeighbor(&types.Neighbor{
 				SwIfIndex:    swIfIndex,
 				IP:           containerIP.IP,
-				HardwareAddr: common.ContainerSideMacAddress,
+				HardwareAddr: getContainerNeighborMac(podSpec, swIfIndex),
 				Flags:        types.IPNeighborStatic,
 			})
 			if err != nil {
@@ -249,36 +267,43 @@ func (s *Server) ActivateStrictRPF(podSpec *storage.LocalPodSpec, stack *vpplink
 		return errors.Wrapf(err, "failed to add routes for RPF VRF")
 	}
 	s.log.Infof("pod(add) set custom-vrf urpf")
-	err = s.vpp.SetCustomURPF(podSpec.TunTapSwIfIndex, podSpec.V4RPFVrfID)
+	err = s.vpp.SetCustomURPF(podSpec.GetPrimarySwIfIndex(), podSpec.V4RPFVrfID)
 	if err != nil {
 		return errors.Wrapf(err, "failed to set urpf strict on interface")
 	} else {
-		stack.Push(s.vpp.UnsetURPF, podSpec.TunTapSwIfIndex)
+		stack.Push(s.vpp.UnsetURPF, podSpec.GetPrimarySwIfIndex())
 	}
 	return nil
 }
 
+// getRPFPathsToPod returns the paths used to reach the pod in its RPF VRF
+func (s *Server) getRPFPathsToPod(podSpec *storage.LocalPodSpec, containerIP *net.IPNet) []types.RoutePath {
+	// Always there (except multinet memif)
+	pathsToPod := []types.RoutePath{{
+		SwIfIndex: podSpec.GetPrimarySwIfIndex(),
+		Gw:        containerIP.IP,
+	}}
+	// Add pbl memif case
+	if podSpec.MemifSwIfIndex != vpplink.InvalidSwIfIndex && !podSpec.IsMemifOnly() {
+		pathsToPod = append(pathsToPod, types.RoutePath{
+			SwIfIndex: podSpec.MemifSwIfIndex,
+			Gw:        containerIP.IP,
+		})
+	}
+	return pathsToPod
+}
+
 func (s *Server) AddRPFRoutes(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack) (err error) {
 	for _, containerIP := range podSpec.GetContainerIps() {
 		RPFvrfID := podSpec.GetRPFVrfID(vpplink.IPFamilyFromIPNet(containerIP))
-		// Always there (except multinet memif)
-		pathsToPod := []types.RoutePath{{
-			SwIfIndex: podSpec.TunTapSwIfIndex,
-			Gw:        containerIP.IP,
-		}}
-		// Add pbl memif case
 		if podSpec.MemifSwIfIndex != vpplink.InvalidSwIfIndex {
-			pathsToPod = append(pathsToPod, types.RoutePath{
-				SwIfIndex: podSpec.MemifSwIfIndex,
-				Gw:        containerIP.IP,
-			})
 			s.log.Infof("pod(add) add route to %+v in rpfvrf %+v via memif and tun", podSpec.GetContainerIps(), RPFvrfID)
 		} else {
 			s.log.Infof("pod(add) add route to %+v in rpfvrf %+v via tun", podSpec.GetContainerIps(), RPFvrfID)
 		}
 		route := &types.Route{
 			Dst:   containerIP,
-			Paths: pathsToPod,
+			Paths: s.getRPFPathsToPod(podSpec, containerIP),
 			Table: RPFvrfID,
 		}
 		err = s.vpp.RouteAdd(route)
@@ -287,81 +312,99 @@ func (s *Server) AddRPFRoutes(podSpec *storage.LocalPodSpec, stack *vpplink.Clea
 		} else {
 			stack.Push(s.vpp.RouteDel, route)
 		}
+	}
+	return s.AddSpoofingRoutes(podSpec, stack, podSpec.AllowedSpoofingPrefixes)
+}
 
-		// Add addresses allowed to be spooofed
-		if podSpec.AllowedSpoofingPrefixes != "" {
-			// Parse Annotation data
-			allowedSources, err := s.ParseSpoofAddressAnnotation(podSpec.AllowedSpoofingPrefixes)
-			if err != nil {
-				return errors.Wrapf(err, "error parsing allowSpoofing addresses")
+// parseSpoofingPrefixes parses the value of the spoofing annotation, which
+// may be empty
+func (s *Server) parseSpoofingPrefixes(allowedSpoofingPrefixes string) ([]cnet.IPNet, error) {
+	if allowedSpoofingPrefixes == "" {
+		return nil, nil
+	}
+	allowedSources, err := s.ParseSpoofAddressAnnotation(allowedSpoofingPrefixes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing allowSpoofing addresses")
+	}
+	return allowedSources, nil
+}
+
+// AddSpoofingRoutes adds the routes allowing the pod to send traffic with
+// the given source prefixes to its RPF VRFs
+func (s *Server) AddSpoofingRoutes(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, allowedSpoofingPrefixes string) (err error) {
+	allowedSources, err := s.parseSpoofingPrefixes(allowedSpoofingPrefixes)
+	if err != nil {
+		return err
+	}
+	return s.addSpoofingSources(podSpec, stack, allowedSources)
+}
+
+func (s *Server) addSpoofingSources(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, allowedSources []cnet.IPNet) (err error) {
+	for _, containerIP := range podSpec.GetContainerIps() {
+		RPFvrfID := podSpec.GetRPFVrfID(vpplink.IPFamilyFromIPNet(containerIP))
+		for _, allowedSource := range allowedSources {
+			s.log.Infof("pod(add) add route to %+v in rpfvrf %+v to allow spoofing", allowedSource.IPNet, RPFvrfID)
+			route := &types.Route{
+				Dst:   &allowedSource.IPNet,
+				Paths: s.getRPFPathsToPod(podSpec, containerIP),
+				Table: RPFvrfID,
 			}
-			for _, allowedSource := range allowedSources {
-				s.log.Infof("pod(add) add route to %+v in rpfvrf %+v to allow spoofing", allowedSource.IPNet, RPFvrfID)
-				route := &types.Route{
-					Dst:   &allowedSource.IPNet,
-					Paths: pathsToPod,
-					Table: RPFvrfID,
-				}
-				err = s.vpp.RouteAdd(route)
-				if err != nil {
-					return errors.Wrapf(err, "error adding RPFVRF %d proper route", RPFvrfID)
-				} else {
-					stack.Push(s.vpp.RouteDel, route)
-				}
+			err = s.vpp.RouteAdd(route)
+			if err != nil {
+				return errors.Wrapf(err, "error adding RPFVRF %d proper route", RPFvrfID)
+			} else {
+				stack.Push(s.vpp.RouteDel, route)
 			}
 		}
 	}
 	return nil
 }
 
+// DelSpoofingRoutes removes the routes added by AddSpoofingRoutes
+func (s *Server) DelSpoofingRoutes(podSpec *storage.LocalPodSpec, allowedSpoofingPrefixes string) {
+	allowedSources, err := s.parseSpoofingPrefixes(allowedSpoofingPrefixes)
+	if err != nil {
+		s.log.WithError(err).Error("error parsing allowSpoofing addresses")
+	}
+	s.delSpoofingSources(podSpec, allowedSources)
+}
+
+func (s *Server) delSpoofingSources(podSpec *storage.LocalPodSpec, allowedSources []cnet.IPNet) {
+	for _, containerIP := range podSpec.GetContainerIps() {
+		RPFvrfID := podSpec.GetRPFVrfID(vpplink.IPFamilyFromIPNet(containerIP))
+		for _, allowedSource := range allowedSources {
+			s.log.Infof("pod(del) del route to %+v in rpfvrf %+v used to allow spoofing", allowedSource.IPNet, RPFvrfID)
+			err := s.vpp.RouteDel(&types.Route{
+				Dst:   &allowedSource.IPNet,
+				Paths: s.getRPFPathsToPod(podSpec, containerIP),
+				Table: RPFvrfID,
+			})
+			if err != nil {
+				s.log.Errorf("error deleting VRF %d route: %s", RPFvrfID, err)
+			}
+		}
+	}
+}
+
 func (s *Server) DeactivateStrictRPF(podSpec *storage.LocalPodSpec) {
 	var err error
 	for _, containerIP := range podSpec.GetContainerIps() {
 		RPFvrfID := podSpec.GetRPFVrfID(vpplink.IPFamilyFromIPNet(containerIP))
-		// Always there (except multinet memif)
-		pathsToPod := []types.RoutePath{{
-			SwIfIndex: podSpec.TunTapSwIfIndex,
-			Gw:        containerIP.IP,
-		}}
-		// pbl memif case
 		if podSpec.MemifSwIfIndex != vpplink.InvalidSwIfIndex {
-			pathsToPod = append(pathsToPod, types.RoutePath{
-				SwIfIndex: podSpec.MemifSwIfIndex,
-				Gw:        containerIP.IP,
-			})
 			s.log.Infof("pod(del) del route to %+v in rpfvrf %+v via memif and tun", podSpec.GetContainerIps(), RPFvrfID)
 		} else {
 			s.log.Infof("pod(del) del route to %+v in rpfvrf %+v via tun", podSpec.GetContainerIps(), RPFvrfID)
 		}
 		err = s.vpp.RouteDel(&types.Route{
 			Dst:   containerIP,
-			Paths: pathsToPod,
+			Paths: s.getRPFPathsToPod(podSpec, containerIP),
 			Table: RPFvrfID,
 		})
 		if err != nil {
 			s.log.Errorf("error deleting RPFVRF %d route : %s", RPFvrfID, err)
 		}
-
-		// Delete addresses allowed to be spooofed
-		if podSpec.AllowedSpoofingPrefixes != "" {
-			// Parse Annotation data
-			allowedSources, err := s.ParseSpoofAddressAnnotation(podSpec.AllowedSpoofingPrefixes)
-			if err != nil {
-				s.log.WithError(err).Error("error parsing allowSpoofing addresses")
-			}
-			for _, allowedSource := range allowedSources {
-				s.log.Infof("pod(del) del route to %+v in rpfvrf %+v used to allow spoofing", allowedSource.IPNet, RPFvrfID)
-				err = s.vpp.RouteDel(&types.Route{
-					Dst:   &allowedSource.IPNet,
-					Paths: pathsToPod,
-					Table: RPFvrfID,
-				})
-				if err != nil {
-					s.log.Errorf("error deleting VRF %d route: %s", RPFvrfID, err)
-				}
-			}
-		}
 	}
+	s.DelSpoofingRoutes(podSpec, podSpec.AllowedSpoofingPrefixes)
 
 	for _, ipFamily := range vpplink.IPFamilies {
 		rpfvrfID := podSpec.GetRPFVrfID(ipFamily)
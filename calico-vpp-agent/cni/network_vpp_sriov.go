@@ -0,0 +1,76 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/watchers"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+)
+
+// allocateSriovVf returns a VF of the uplink not given to another pod.
+// VF 0 is used by VPP itself.
+func (s *Server) allocateSriovVf(uplink string, pciID string) (int, error) {
+	numVFs, err := utils.GetInterfaceNumVFs(pciID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot get num VFs of %s", uplink)
+	}
+	used := make(map[int]bool)
+	for _, podSpec := range s.podInterfaceMap {
+		if podSpec.EnableSriov && podSpec.SriovUplink == uplink {
+			used[podSpec.SriovVf] = true
+		}
+	}
+	for vf := 1; vf < numVFs; vf++ {
+		if !used[vf] {
+			return vf, nil
+		}
+	}
+	return 0, errors.Errorf("no VF left on %s (%d VFs), increase numVFs in the uplink configuration", uplink, numVFs)
+}
+
+// addPodSriovVf gives the pod a VF of the uplink of its network
+func (s *Server) addPodSriovVf(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, doHostSideConf bool) error {
+	value, ok := s.networkDefinitions.Load(podSpec.NetworkName)
+	if !ok {
+		return errors.Errorf("network %s does not exist", podSpec.NetworkName)
+	}
+	networkDefinition, ok := value.(*watchers.NetworkDefinition)
+	if !ok || networkDefinition == nil {
+		panic("networkDefinition not of type *watchers.NetworkDefinition")
+	}
+	if networkDefinition.SRIOV == nil {
+		return errors.Errorf("network %s has no sriov configuration", podSpec.NetworkName)
+	}
+	uplink, ok := common.GetVppManagerInfo().UplinkStatuses[podSpec.SriovUplink]
+	if !ok {
+		return errors.Errorf("sriov uplink %s not found", podSpec.SriovUplink)
+	}
+	if podSpec.SriovVf == 0 {
+		vf, err := s.allocateSriovVf(podSpec.SriovUplink, uplink.PciID)
+		if err != nil {
+			return err
+		}
+		podSpec.SriovVf = vf
+	}
+	sriov := networkDefinition.SRIOV
+	return s.sriovDriver.CreateInterface(podSpec, stack, &uplink, sriov.VLAN+podSpec.SriovVf-1,
+		sriov.GetSpoofCheck(), sriov.Trust, doHostSideConf)
+}
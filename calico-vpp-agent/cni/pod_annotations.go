@@ -18,13 +18,16 @@ package cni
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
 	"github.com/projectcalico/vpp-dataplane/v3/config"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
@@ -34,10 +37,19 @@ const (
 	CalicoAnnotationPrefix string = "cni.projectcalico.org/"
 	VppAnnotationPrefix    string = "cni.projectcalico.org/vpp"
 	MemifPortAnnotation    string = "ExtraMemifPorts"
+	MemifAnnotation        string = "Memif"
 	VclAnnotation          string = "Vcl"
 	SpoofAnnotation        string = "AllowedSourcePrefixes"
+	HwAddrAnnotation       string = "hwAddr"
 	IfSpecAnnotation       string = "InterfacesSpec"
 	IfSpecPBLAnnotation    string = "ExtraMemifSpec"
+	MulticastAnnotation    string = "MulticastGroups"
+	PolicyRoutesAnnotation string = "PolicyRoutes"
+	VhostUserAnnotation    string = "VhostUser"
+	QosClassAnnotation     string = "QosClass"
+
+	IngressBandwidthAnnotation string = "kubernetes.io/ingress-bandwidth"
+	EgressBandwidthAnnotation  string = "kubernetes.io/egress-bandwidth"
 )
 
 func (s *Server) ParsePortSpec(value string) (ifPortConfigs *storage.LocalIfPortConfigs, err error) {
@@ -98,6 +110,31 @@ func (s *Server) ParseDefaultIfType(podSpec *storage.LocalPodSpec, ifType storag
 	return nil
 }
 
+// ParseMemifOptions reads the memif only settings of a memif interface
+// spec. value is either the spec, or a map of specs by interface name.
+func (s *Server) ParseMemifOptions(podSpec *storage.LocalPodSpec, ifSpec *config.InterfaceSpec, value string) error {
+	var options config.MemifOptions
+	if podSpec.NetworkName == "" {
+		err := json.Unmarshal([]byte(value), &options)
+		if err != nil {
+			return err
+		}
+	} else {
+		var optionsByName map[string]config.MemifOptions
+		err := json.Unmarshal([]byte(value), &optionsByName)
+		if err != nil {
+			return err
+		}
+		options = optionsByName[podSpec.InterfaceName]
+	}
+	err := options.Validate(ifSpec)
+	if err != nil {
+		return errors.Wrapf(err, "invalid memif options")
+	}
+	podSpec.MemifOptions = options
+	return nil
+}
+
 func (s *Server) ParseEnableDisableAnnotation(value string) (bool, error) {
 	switch value {
 	case "enable":
@@ -127,6 +164,129 @@ func (s *Server) ParseSpoofAddressAnnotation(value string) ([]cnet.IPNet, error)
 	return allowedSources, nil
 }
 
+// ParseHwAddrAnnotation validates the MAC address requested for the pod
+func (s *Server) ParseHwAddrAnnotation(value string) (net.HardwareAddr, error) {
+	mac, err := net.ParseMAC(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(mac) != 6 {
+		return nil, errors.Errorf("%s is not an ethernet address", value)
+	}
+	if mac[0]&0x01 != 0 {
+		return nil, errors.Errorf("%s is a multicast address", value)
+	}
+	if mac.String() == "00:00:00:00:00:00" {
+		return nil, errors.Errorf("%s is not a valid address", value)
+	}
+	return mac, nil
+}
+
+func (s *Server) ParseMulticastGroupsAnnotation(value string) ([]*net.IPNet, error) {
+	var requestedGroups []string
+	err := json.Unmarshal([]byte(value), &requestedGroups)
+	if err != nil {
+		return nil, errors.Errorf("failed to parse '%s' as JSON: %s", value, err)
+	}
+	groups := make([]*net.IPNet, 0, len(requestedGroups))
+	for _, groupStr := range requestedGroups {
+		group := net.ParseIP(groupStr)
+		if group == nil || !group.IsMulticast() {
+			return nil, errors.Errorf("%s is not a multicast address", groupStr)
+		}
+		groups = append(groups, common.FullyQualified(group))
+	}
+	return groups, nil
+}
+
+// PolicyRouteSpec is one entry of the PolicyRoutes annotation. Pod egress
+// traffic matching Dst (and Proto/Port if set) is steered via Gateway,
+// via the uplink named Uplink, or looked up in the VRF Vrf
+type PolicyRouteSpec struct {
+	Dst     string  `json:"dst"`
+	Proto   string  `json:"proto,omitempty"`
+	Port    uint16  `json:"port,omitempty"`
+	Gateway string  `json:"gateway,omitempty"`
+	Uplink  string  `json:"uplink,omitempty"`
+	Vrf     *uint32 `json:"vrf,omitempty"`
+}
+
+type PolicyRoute struct {
+	Dst     *net.IPNet
+	Proto   types.IPProto
+	Port    uint16
+	Gateway net.IP
+	Uplink  string
+	Vrf     *uint32
+}
+
+func (s *Server) ParsePolicyRoutesAnnotation(value string) ([]*PolicyRoute, error) {
+	var specs []PolicyRouteSpec
+	err := json.Unmarshal([]byte(value), &specs)
+	if err != nil {
+		return nil, errors.Errorf("failed to parse '%s' as JSON: %s", value, err)
+	}
+	policyRoutes := make([]*PolicyRoute, 0, len(specs))
+	for idx, spec := range specs {
+		policyRoute := &PolicyRoute{Port: spec.Port, Uplink: spec.Uplink, Vrf: spec.Vrf}
+		_, policyRoute.Dst, err = net.ParseCIDR(spec.Dst)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing dst of rule[%d]", idx)
+		}
+		if spec.Proto != "" {
+			policyRoute.Proto, err = types.UnformatProto(spec.Proto)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing proto of rule[%d]", idx)
+			}
+		} else if spec.Port != 0 {
+			return nil, errors.Errorf("rule[%d] has a port but no proto", idx)
+		}
+		if spec.Gateway != "" {
+			policyRoute.Gateway = net.ParseIP(spec.Gateway)
+			if policyRoute.Gateway == nil {
+				return nil, errors.Errorf("rule[%d] invalid gateway %s", idx, spec.Gateway)
+			}
+			if vpplink.IsIP6(policyRoute.Gateway) != vpplink.IsIP6(policyRoute.Dst.IP) {
+				return nil, errors.Errorf("rule[%d] gateway and dst are not in the same family", idx)
+			}
+		}
+		if spec.Vrf != nil && (spec.Gateway != "" || spec.Uplink != "") {
+			return nil, errors.Errorf("rule[%d] vrf cannot be used with gateway or uplink", idx)
+		}
+		if spec.Vrf == nil && spec.Gateway == "" && spec.Uplink == "" {
+			return nil, errors.Errorf("rule[%d] needs one of gateway, uplink or vrf", idx)
+		}
+		policyRoutes = append(policyRoutes, policyRoute)
+	}
+	return policyRoutes, nil
+}
+
+// ParseBandwidthAnnotation parses a bandwidth quantity in bits/s,
+// as used by the kubernetes bandwidth plugin (e.g. 10M)
+func (s *Server) ParseBandwidthAnnotation(value string) (uint64, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse bandwidth %s", value)
+	}
+	bandwidth := quantity.Value()
+	if bandwidth < MinPodBandwidth || bandwidth > MaxPodBandwidth {
+		return 0, errors.Errorf("bandwidth %s should be between %d and %d bits/s", value, MinPodBandwidth, MaxPodBandwidth)
+	}
+	return uint64(bandwidth), nil
+}
+
+// ParseQosClass sets the QoS class of the pod, given by its annotation
+// or mapped from its kubernetes QoS class
+func (s *Server) ParseQosClass(podSpec *storage.LocalPodSpec, annotatedClass string, kubernetesClass string) error {
+	name, class, err := config.GetCalicoVppQos().GetPodClass(annotatedClass, kubernetesClass)
+	if err != nil {
+		return err
+	}
+	podSpec.QosClass = name
+	podSpec.Dscp = class.Dscp
+	return nil
+}
+
 func GetDefaultIfSpec(isL3 bool) config.InterfaceSpec {
 	return config.InterfaceSpec{
 		NumRxQueues: config.GetCalicoVppInterfaces().DefaultPodIfSpec.NumRxQueues,
@@ -142,6 +302,26 @@ func (s *Server) ParsePodAnnotations(podSpec *storage.LocalPodSpec, annotations
 		if key == CalicoAnnotationPrefix+SpoofAnnotation {
 			podSpec.AllowedSpoofingPrefixes = annotations[CalicoAnnotationPrefix+SpoofAnnotation]
 		}
+		// The requested MAC only applies to the interface of the main network
+		if key == CalicoAnnotationPrefix+HwAddrAnnotation && podSpec.NetworkName == "" {
+			mac, err := s.ParseHwAddrAnnotation(value)
+			if err != nil {
+				s.log.Warnf("Error parsing key %s %s", key, err)
+			} else {
+				podSpec.ContainerMac = mac.String()
+				podSpec.ContainerMacAnnotated = true
+			}
+		}
+		if key == IngressBandwidthAnnotation || key == EgressBandwidthAnnotation {
+			bandwidth, err := s.ParseBandwidthAnnotation(value)
+			if err != nil {
+				s.log.Warnf("Error parsing key %s %s", key, err)
+			} else if key == IngressBandwidthAnnotation {
+				podSpec.IngressBandwidth = bandwidth
+			} else {
+				podSpec.EgressBandwidth = bandwidth
+			}
+		}
 		if !strings.HasPrefix(key, VppAnnotationPrefix) {
 			continue
 		}
@@ -162,6 +342,12 @@ func (s *Server) ParsePodAnnotations(podSpec *storage.LocalPodSpec, annotations
 				podSpec.IfSpec = ethSpec
 				isL3 := podSpec.IfSpec.GetIsL3(isMemif(podSpec.InterfaceName))
 				podSpec.IfSpec.IsL3 = &isL3
+				if podSpec.NetworkName != "" && isMemif(podSpec.InterfaceName) {
+					err = s.ParseMemifOptions(podSpec, &podSpec.IfSpec, value)
+					if err != nil {
+						return err
+					}
+				}
 			}
 
 		case VppAnnotationPrefix + MemifPortAnnotation:
@@ -171,6 +357,17 @@ func (s *Server) ParsePodAnnotations(podSpec *storage.LocalPodSpec, annotations
 				return err
 			}
 			err = s.ParseDefaultIfType(podSpec, storage.VppIfTypeTunTap)
+		case VppAnnotationPrefix + MemifAnnotation:
+			// Only the main network interface has a tun to replace
+			if podSpec.NetworkName != "" {
+				continue
+			}
+			var enableMemif bool
+			enableMemif, err = s.ParseEnableDisableAnnotation(value)
+			if err == nil && enableMemif {
+				err = s.ParseDefaultIfType(podSpec, storage.VppIfTypeMemif)
+				podSpec.EnableMemif = podSpec.EnableMemif || err == nil
+			}
 		case VppAnnotationPrefix + IfSpecPBLAnnotation:
 			var ifSpec *config.InterfaceSpec
 			err := json.Unmarshal([]byte(value), &ifSpec)
@@ -185,8 +382,32 @@ func (s *Server) ParsePodAnnotations(podSpec *storage.LocalPodSpec, annotations
 			podSpec.PBLMemifSpec = *ifSpec
 			isL3 := podSpec.PBLMemifSpec.GetIsL3(true)
 			podSpec.PBLMemifSpec.IsL3 = &isL3
+			if podSpec.NetworkName == "" {
+				err = s.ParseMemifOptions(podSpec, &podSpec.PBLMemifSpec, value)
+				if err != nil {
+					return err
+				}
+			}
 		case VppAnnotationPrefix + VclAnnotation:
 			podSpec.EnableVCL, err = s.ParseEnableDisableAnnotation(value)
+		case VppAnnotationPrefix + VhostUserAnnotation:
+			podSpec.EnableVhostUser, err = s.ParseEnableDisableAnnotation(value)
+			if err == nil && podSpec.EnableVhostUser {
+				err = s.ParseDefaultIfType(podSpec, storage.VppIfTypeVhostUser)
+				podSpec.EnableVhostUser = err == nil
+			}
+		case VppAnnotationPrefix + QosClassAnnotation:
+			err = s.ParseQosClass(podSpec, value, "" /* kubernetesClass */)
+		case VppAnnotationPrefix + MulticastAnnotation:
+			_, err = s.ParseMulticastGroupsAnnotation(value)
+			if err == nil {
+				podSpec.MulticastGroups = value
+			}
+		case VppAnnotationPrefix + PolicyRoutesAnnotation:
+			_, err = s.ParsePolicyRoutesAnnotation(value)
+			if err == nil {
+				podSpec.PolicyRoutes = value
+			}
 		default:
 			continue
 		}
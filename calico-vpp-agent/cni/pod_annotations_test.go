@@ -0,0 +1,216 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cni
+
+// The annotation parsers do not need VPP, so they are tested outside of
+// the ginkgo CNI integration suite, which only runs with INTEGRATION_TEST
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+func newTestServer() *Server {
+	return &Server{log: logrus.NewEntry(logrus.New())}
+}
+
+// TestParsePodAnnotations checks the LocalPodSpec fields set by each
+// annotation. What they configure in VPP is checked by the CNI pod tests
+func TestParsePodAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		networkName string
+		annotations map[string]string
+		expected    storage.LocalPodSpec
+	}{{
+		name:        "multicast groups",
+		annotations: map[string]string{VppAnnotationPrefix + MulticastAnnotation: `["239.1.1.1"]`},
+		expected:    storage.LocalPodSpec{MulticastGroups: `["239.1.1.1"]`},
+	}, {
+		name:        "invalid multicast groups are ignored",
+		annotations: map[string]string{VppAnnotationPrefix + MulticastAnnotation: `["10.0.0.1"]`},
+	}, {
+		name:        "policy routes",
+		annotations: map[string]string{VppAnnotationPrefix + PolicyRoutesAnnotation: `[{"dst": "0.0.0.0/0", "uplink": "eth1"}]`},
+		expected:    storage.LocalPodSpec{PolicyRoutes: `[{"dst": "0.0.0.0/0", "uplink": "eth1"}]`},
+	}, {
+		name:        "invalid policy routes are ignored",
+		annotations: map[string]string{VppAnnotationPrefix + PolicyRoutesAnnotation: `[{"dst": "10.1.0.0/16"}]`},
+	}, {
+		name: "bandwidth",
+		annotations: map[string]string{
+			IngressBandwidthAnnotation: "10M",
+			EgressBandwidthAnnotation:  "20M",
+		},
+		expected: storage.LocalPodSpec{IngressBandwidth: 10000000, EgressBandwidth: 20000000},
+	}, {
+		name:        "invalid bandwidths are ignored",
+		annotations: map[string]string{IngressBandwidthAnnotation: "fast"},
+	}, {
+		name:        "MAC address",
+		annotations: map[string]string{CalicoAnnotationPrefix + HwAddrAnnotation: "02:42:ac:11:00:02"},
+		expected:    storage.LocalPodSpec{ContainerMac: "02:42:ac:11:00:02", ContainerMacAnnotated: true},
+	}, {
+		name:        "only the interface of the main network uses the MAC address",
+		networkName: "net1",
+		annotations: map[string]string{CalicoAnnotationPrefix + HwAddrAnnotation: "02:42:ac:11:00:02"},
+		expected:    storage.LocalPodSpec{NetworkName: "net1"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			podSpec := &storage.LocalPodSpec{NetworkName: tc.networkName}
+			g.Expect(newTestServer().ParsePodAnnotations(podSpec, tc.annotations)).To(Succeed())
+			g.Expect(*podSpec).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestParseMulticastGroupsAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+
+	groups, err := s.ParseMulticastGroupsAnnotation(`["239.1.1.1", "ff0e::1"]`)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(groups).To(HaveLen(2))
+	g.Expect(groups[0].String()).To(Equal("239.1.1.1/32"))
+	g.Expect(groups[1].String()).To(Equal("ff0e::1/128"))
+
+	for _, value := range []string{`["10.0.0.1"]`, `["not-an-ip"]`, `239.1.1.1`} {
+		_, err = s.ParseMulticastGroupsAnnotation(value)
+		g.Expect(err).To(HaveOccurred(), value)
+	}
+}
+
+func TestParsePolicyRoutesAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+
+	policyRoutes, err := s.ParsePolicyRoutesAnnotation(`[
+		{"dst": "10.1.0.0/16", "proto": "tcp", "port": 443, "gateway": "192.168.1.1"},
+		{"dst": "0.0.0.0/0", "uplink": "eth1"},
+		{"dst": "2001:db8::/32", "vrf": 10}
+	]`)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(policyRoutes).To(HaveLen(3))
+	g.Expect(policyRoutes[0].Dst.String()).To(Equal("10.1.0.0/16"))
+	g.Expect(policyRoutes[0].Proto).To(Equal(types.TCP))
+	g.Expect(policyRoutes[0].Port).To(Equal(uint16(443)))
+	g.Expect(policyRoutes[0].Gateway.String()).To(Equal("192.168.1.1"))
+	g.Expect(policyRoutes[1].Uplink).To(Equal("eth1"))
+	g.Expect(policyRoutes[1].Gateway).To(BeNil())
+	g.Expect(*policyRoutes[2].Vrf).To(Equal(uint32(10)))
+
+	for _, value := range []string{
+		`{"dst": "10.1.0.0/16", "uplink": "eth1"}`,
+		`[{"dst": "10.1.0.0", "uplink": "eth1"}]`,
+		`[{"dst": "10.1.0.0/16"}]`,
+		`[{"dst": "10.1.0.0/16", "port": 80, "uplink": "eth1"}]`,
+		`[{"dst": "10.1.0.0/16", "proto": "gre", "uplink": "eth1"}]`,
+		`[{"dst": "10.1.0.0/16", "gateway": "not-an-ip"}]`,
+		`[{"dst": "10.1.0.0/16", "gateway": "2001:db8::1"}]`,
+		`[{"dst": "10.1.0.0/16", "gateway": "192.168.1.1", "vrf": 10}]`,
+	} {
+		_, err = s.ParsePolicyRoutesAnnotation(value)
+		g.Expect(err).To(HaveOccurred(), value)
+	}
+}
+
+func TestParseBandwidthAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+
+	for value, expected := range map[string]uint64{
+		"10M":       10000000,
+		"1G":        1000000000,
+		"500k":      500000,
+		"100000000": 100000000,
+	} {
+		bandwidth, err := s.ParseBandwidthAnnotation(value)
+		g.Expect(err).ToNot(HaveOccurred(), value)
+		g.Expect(bandwidth).To(Equal(expected), value)
+	}
+
+	for _, value := range []string{"fast", "10Mbps", "-10M", "1", "10P"} {
+		_, err := s.ParseBandwidthAnnotation(value)
+		g.Expect(err).To(HaveOccurred(), value)
+	}
+}
+
+func TestParseHwAddrAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	s := newTestServer()
+
+	mac, err := s.ParseHwAddrAnnotation("02:42:AC:11:00:02")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mac.String()).To(Equal("02:42:ac:11:00:02"))
+
+	for _, value := range []string{
+		"not-a-mac",
+		"02:42:ac:11:00:02:00:01",
+		"01:00:5e:00:00:01",
+		"00:00:00:00:00:00",
+	} {
+		_, err = s.ParseHwAddrAnnotation(value)
+		g.Expect(err).To(HaveOccurred(), value)
+	}
+}
+
+func TestGenerateContainerMac(t *testing.T) {
+	g := NewWithT(t)
+
+	podSpec := &storage.LocalPodSpec{WorkloadID: "default/pod-1", InterfaceName: "eth0", NetnsName: "/var/run/netns/a"}
+	mac := generateContainerMac(podSpec)
+	g.Expect(mac).To(HaveLen(6))
+	// Locally administered unicast
+	g.Expect(mac[0] & 0x03).To(Equal(byte(0x02)))
+
+	// The MAC does not depend on the netns, so it survives pod recreation
+	recreated := &storage.LocalPodSpec{WorkloadID: "default/pod-1", InterfaceName: "eth0", NetnsName: "/var/run/netns/b"}
+	g.Expect(generateContainerMac(recreated)).To(Equal(mac))
+
+	other := &storage.LocalPodSpec{WorkloadID: "default/pod-1", InterfaceName: "net1"}
+	g.Expect(generateContainerMac(other)).ToNot(Equal(mac))
+	other = &storage.LocalPodSpec{WorkloadID: "default/pod-2", InterfaceName: "eth0"}
+	g.Expect(generateContainerMac(other)).ToNot(Equal(mac))
+}
+
+func TestGetContainerNeighborMac(t *testing.T) {
+	g := NewWithT(t)
+
+	podSpec := &storage.LocalPodSpec{
+		ContainerMac:       "02:42:ac:11:00:02",
+		TunTapSwIfIndex:    1,
+		MemifSwIfIndex:     2,
+		VhostUserSwIfIndex: types.InvalidID,
+		SriovSwIfIndex:     types.InvalidID,
+	}
+	g.Expect(getContainerNeighborMac(podSpec, 1).String()).To(Equal("02:42:ac:11:00:02"))
+	// Memif applications keep the fixed MAC
+	g.Expect(getContainerNeighborMac(podSpec, 2)).To(Equal(common.ContainerSideMacAddress))
+
+	podSpec.ContainerMacAnnotated = true
+	g.Expect(getContainerNeighborMac(podSpec, 2).String()).To(Equal("02:42:ac:11:00:02"))
+
+	// Pods created before the MAC was recorded
+	podSpec.ContainerMac = ""
+	g.Expect(getContainerNeighborMac(podSpec, 1)).To(Equal(common.ContainerSideMacAddress))
+}
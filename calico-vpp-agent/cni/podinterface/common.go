@@ -70,6 +70,21 @@ func (i *PodInterfaceDriverData) SpreadRxQueuesOnWorkers(swIfIndex uint32, numRx
 	}
 }
 
+// UpdatePodInterfaceQueues applies the rx mode of ifSpec to a running pod
+// interface, and places its queues on the workers again
+func (i *PodInterfaceDriverData) UpdatePodInterfaceQueues(swIfIndex uint32, ifSpec config.InterfaceSpec) (err error) {
+	err = i.vpp.SetInterfaceRxMode(swIfIndex, types.AllQueues, ifSpec.GetRxModeWithDefault(types.AdaptativeRxMode))
+	if err != nil {
+		return errors.Wrapf(err, "error SetInterfaceRxMode on pod if interface")
+	}
+	err = i.SpreadTxQueuesOnWorkers(swIfIndex, ifSpec.NumTxQueues)
+	if err != nil {
+		return errors.Wrapf(err, "error spreading tx queues on workers")
+	}
+	i.SpreadRxQueuesOnWorkers(swIfIndex, ifSpec.NumRxQueues)
+	return nil
+}
+
 func (i *PodInterfaceDriverData) UndoPodIfNatConfiguration(swIfIndex uint32) {
 	var err error
 	err = i.vpp.RemovePodInterface(swIfIndex)
@@ -17,6 +17,7 @@ package podinterface
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/pkg/errors"
@@ -31,6 +32,10 @@ import (
 
 type MemifPodInterfaceDriver struct {
 	PodInterfaceDriverData
+	// placementLock protects rxPlacement, also used by the event watchers
+	placementLock sync.Mutex
+	// rxPlacement is the worker of each rx queue of the memifs
+	rxPlacement map[uint32][]int
 }
 
 type dummy struct {
@@ -49,12 +54,13 @@ func NewMemifPodInterfaceDriver(vpp *vpplink.VppLink, log *logrus.Entry) *MemifP
 	i.vpp = vpp
 	i.log = log
 	i.Name = "memif"
+	i.rxPlacement = make(map[uint32][]int)
 	return i
 }
 
 func (i *MemifPodInterfaceDriver) CreateInterface(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, doHostSideConf bool) (err error) {
 	memifName := podSpec.InterfaceName
-	// if we are in main network (PBL case)
+	// if we are in main network (PBL or memif default interface case)
 	if podSpec.NetworkName == "" {
 		memifName = "vpp/memif-" + podSpec.InterfaceName
 	}
@@ -66,21 +72,22 @@ func (i *MemifPodInterfaceDriver) CreateInterface(podSpec *storage.LocalPodSpec,
 	}
 	podSpec.MemifSocketID = socketID
 
-	var usedIfSpec config.InterfaceSpec
-	if podSpec.NetworkName == "" { //PBL case
-		usedIfSpec = podSpec.PBLMemifSpec
-	} else {
-		usedIfSpec = podSpec.IfSpec
-	}
+	usedIfSpec := podSpec.GetMemifSpec()
+	options := podSpec.MemifOptions
 	// Create new memif
 	memif := &types.Memif{
 		Role:        types.MemifMaster,
 		Mode:        types.MemifModeEthernet,
 		NumRxQueues: usedIfSpec.NumRxQueues,
 		NumTxQueues: usedIfSpec.NumTxQueues,
-		QueueSize:   usedIfSpec.RxQueueSize,
+		RingSize:    options.GetRingSize(&usedIfSpec),
+		BufferSize:  options.BufferSize,
+		ZeroCopy:    options.ZeroCopy,
 		SocketID:    socketID,
 	}
+	if options.Slave {
+		memif.Role = types.MemifSlave
+	}
 	if *usedIfSpec.IsL3 {
 		memif.Mode = types.MemifModeIP
 	}
@@ -93,41 +100,38 @@ func (i *MemifPodInterfaceDriver) CreateInterface(podSpec *storage.LocalPodSpec,
 	}
 	podSpec.MemifSwIfIndex = memif.SwIfIndex
 
-	if *(*config.CalicoVppDebug).SpreadTxQueuesOnWorkers {
-		watcher, err := i.vpp.WatchInterfaceEvents(memif.SwIfIndex)
-		if err != nil {
-			return err
-		} else {
-			stack.Push(watcher.Stop)
-		}
-		go func() {
+	// Queues are only created when the peer connects, place them then
+	watcher, err := i.vpp.WatchInterfaceEvents(memif.SwIfIndex)
+	if err != nil {
+		return err
+	} else {
+		stack.Push(watcher.Stop)
+	}
+	spreadTx := *(*config.CalicoVppDebug).SpreadTxQueuesOnWorkers
+	txWorkers := append([]int(nil), options.TxWorkers...)
+	go func() {
+		i.log.WithFields(map[string]interface{}{
+			"swIfIndex": memif.SwIfIndex,
+		}).Infof("begin watching interface events for: %v", i.Name)
+
+		for event := range watcher.Events() {
 			i.log.WithFields(map[string]interface{}{
 				"swIfIndex": memif.SwIfIndex,
-			}).Infof("begin watching interface events for: %v", i.Name)
-
-			for event := range watcher.Events() {
-				i.log.WithFields(map[string]interface{}{
-					"swIfIndex": memif.SwIfIndex,
-				}).Infof("processing interface event for %v: %+v", i.Name, event)
-
-				switch event.Type {
-				case types.InterfaceEventLinkUp:
-					err = i.SpreadTxQueuesOnWorkers(memif.SwIfIndex, memif.NumTxQueues)
-					if err != nil {
-						i.log.Errorf("error spreading tx queues on workers: %v", err)
-					}
-					i.SpreadRxQueuesOnWorkers(memif.SwIfIndex, podSpec.IfSpec.NumRxQueues)
-				case types.InterfaceEventDeleted: // this might not be needed here, it could be handled internally in the watcher
-					watcher.Stop()
-				}
+			}).Infof("processing interface event for %v: %+v", i.Name, event)
+
+			switch event.Type {
+			case types.InterfaceEventLinkUp:
+				i.placeQueues(memif.SwIfIndex, memif.NumTxQueues, txWorkers, spreadTx)
+			case types.InterfaceEventDeleted: // this might not be needed here, it could be handled internally in the watcher
+				watcher.Stop()
 			}
+		}
 
-			i.log.WithFields(map[string]interface{}{
-				"swIfIndex": memif.SwIfIndex,
-			}).Infof("done watching interface events for: %v", i.Name)
+		i.log.WithFields(map[string]interface{}{
+			"swIfIndex": memif.SwIfIndex,
+		}).Infof("done watching interface events for: %v", i.Name)
 
-		}()
-	}
+	}()
 
 	err = i.vpp.SetInterfaceTag(memif.SwIfIndex, podSpec.GetInterfaceTag(i.Name))
 	if err != nil {
@@ -152,7 +156,7 @@ func (i *MemifPodInterfaceDriver) CreateInterface(podSpec *storage.LocalPodSpec,
 	}
 
 	if doHostSideConf {
-		if podSpec.NetworkName != "" {
+		if podSpec.IsMemifOnly() {
 			i.log.Infof("Creating host side dummy for memif interface %s", podSpec.InterfaceName)
 			err = i.createDummy(podSpec.NetnsName, podSpec.InterfaceName)
 			if err != nil {
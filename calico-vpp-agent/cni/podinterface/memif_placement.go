@@ -0,0 +1,172 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podinterface
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+/**
+ * Memif rx queues of all pods are placed together on the workers, so that
+ * busy pods do not end up sharing the same workers. Queues pinned with
+ * the rxWorkers memif option are placed first, the others then go to the
+ * least loaded workers, in swIfIndex order so that adding a memif does
+ * not move the queues of existing ones.
+ */
+
+// memifQueues are the rx queues of a memif to place
+type memifQueues struct {
+	swIfIndex   uint32
+	numRxQueues int
+	rxWorkers   []int
+}
+
+// computeRxPlacement returns the worker of each rx queue of the memifs
+func computeRxPlacement(memifs []memifQueues, nWorkers int) map[uint32][]int {
+	placement := make(map[uint32][]int)
+	if nWorkers <= 0 {
+		return placement
+	}
+	sort.Slice(memifs, func(i, j int) bool { return memifs[i].swIfIndex < memifs[j].swIfIndex })
+	load := make([]int, nWorkers)
+	for _, memif := range memifs {
+		workers := make([]int, memif.numRxQueues)
+		for queue := range workers {
+			workers[queue] = -1
+			if queue < len(memif.rxWorkers) && memif.rxWorkers[queue] < nWorkers {
+				workers[queue] = memif.rxWorkers[queue]
+				load[workers[queue]]++
+			}
+		}
+		placement[memif.swIfIndex] = workers
+	}
+	for _, memif := range memifs {
+		workers := placement[memif.swIfIndex]
+		for queue, worker := range workers {
+			if worker >= 0 {
+				continue
+			}
+			worker = 0
+			for w := range load {
+				if load[w] < load[worker] {
+					worker = w
+				}
+			}
+			workers[queue] = worker
+			load[worker]++
+		}
+	}
+	return placement
+}
+
+// BalanceQueues places the rx queues of the memifs of all the pods on the
+// workers. It is called when memifs are added or removed, and when the
+// number of workers changes.
+func (i *MemifPodInterfaceDriver) BalanceQueues(podSpecs []storage.LocalPodSpec) {
+	memifs := make([]memifQueues, 0)
+	for _, podSpec := range podSpecs {
+		if !podSpec.EnableMemif || podSpec.MemifSwIfIndex == vpplink.InvalidID {
+			continue
+		}
+		for queue, worker := range podSpec.MemifOptions.RxWorkers {
+			if worker >= i.NDataThreads {
+				i.log.Warnf("memif[%d] queue %d pinned on worker %d, but there are only %d workers", podSpec.MemifSwIfIndex, queue, worker, i.NDataThreads)
+			}
+		}
+		memifs = append(memifs, memifQueues{
+			swIfIndex:   podSpec.MemifSwIfIndex,
+			numRxQueues: podSpec.GetMemifSpec().NumRxQueues,
+			rxWorkers:   podSpec.MemifOptions.RxWorkers,
+		})
+	}
+	placement := computeRxPlacement(memifs, i.NDataThreads)
+
+	i.placementLock.Lock()
+	defer i.placementLock.Unlock()
+	for swIfIndex, workers := range placement {
+		if slices.Equal(i.rxPlacement[swIfIndex], workers) {
+			continue
+		}
+		i.applyRxPlacement(swIfIndex, workers)
+	}
+	i.rxPlacement = placement
+}
+
+// applyRxPlacement sets the workers of the rx queues of a memif. Queues only
+// exist once a peer is connected, so this is also done on link up.
+func (i *MemifPodInterfaceDriver) applyRxPlacement(swIfIndex uint32, workers []int) {
+	for queue, worker := range workers {
+		err := i.vpp.SetInterfaceRxPlacement(swIfIndex, queue, worker, false /* main */)
+		if err != nil {
+			i.log.Debugf("failed to set memif[%d] queue:%d worker:%d: %v", swIfIndex, queue, worker, err)
+		}
+	}
+}
+
+// placeTxQueues spreads the tx queues of a memif on the threads if enabled,
+// and applies the txWorkers memif option
+func (i *MemifPodInterfaceDriver) placeTxQueues(swIfIndex uint32, numTxQueues int, txWorkers []int, spread bool) error {
+	if spread {
+		err := i.SpreadTxQueuesOnWorkers(swIfIndex, numTxQueues)
+		if err != nil {
+			return err
+		}
+	}
+	for queue, worker := range txWorkers {
+		if worker >= i.NDataThreads {
+			continue
+		}
+		// Thread 0 is the main thread
+		err := i.vpp.SetInterfaceTxPlacement(swIfIndex, queue, worker+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeQueues places the queues of a memif, once its peer is connected
+func (i *MemifPodInterfaceDriver) placeQueues(swIfIndex uint32, numTxQueues int, txWorkers []int, spreadTx bool) {
+	err := i.placeTxQueues(swIfIndex, numTxQueues, txWorkers, spreadTx)
+	if err != nil {
+		i.log.Errorf("error placing tx queues on workers: %v", err)
+	}
+	i.placementLock.Lock()
+	defer i.placementLock.Unlock()
+	workers, ok := i.rxPlacement[swIfIndex]
+	if ok {
+		i.applyRxPlacement(swIfIndex, workers)
+	}
+}
+
+// UpdatePodInterfaceQueues applies the rx mode of ifSpec to a running memif,
+// and places its queues on the workers again
+func (i *MemifPodInterfaceDriver) UpdatePodInterfaceQueues(swIfIndex uint32, ifSpec config.InterfaceSpec, options config.MemifOptions) error {
+	err := i.vpp.SetInterfaceRxMode(swIfIndex, types.AllQueues, ifSpec.GetRxModeWithDefault(types.AdaptativeRxMode))
+	if err != nil {
+		return errors.Wrapf(err, "error SetInterfaceRxMode on pod if interface")
+	}
+	i.placeQueues(swIfIndex, ifSpec.NumTxQueues, options.TxWorkers, *config.GetCalicoVppDebug().SpreadTxQueuesOnWorkers)
+	return nil
+}
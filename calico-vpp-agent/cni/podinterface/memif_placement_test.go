@@ -0,0 +1,63 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podinterface
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPodInterface(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pod interface tests")
+}
+
+var _ = Describe("Memif queue placement", func() {
+	It("balances queues between the workers", func() {
+		placement := computeRxPlacement([]memifQueues{
+			{swIfIndex: 5, numRxQueues: 2},
+			{swIfIndex: 3, numRxQueues: 3},
+		}, 4)
+		Expect(placement).To(Equal(map[uint32][]int{
+			3: {0, 1, 2},
+			5: {3, 0},
+		}))
+	})
+
+	It("places pinned queues first", func() {
+		placement := computeRxPlacement([]memifQueues{
+			{swIfIndex: 3, numRxQueues: 2},
+			{swIfIndex: 5, numRxQueues: 2, rxWorkers: []int{0, 0}},
+		}, 2)
+		Expect(placement).To(Equal(map[uint32][]int{
+			3: {1, 1},
+			5: {0, 0},
+		}))
+	})
+
+	It("ignores pins on missing workers", func() {
+		placement := computeRxPlacement([]memifQueues{
+			{swIfIndex: 3, numRxQueues: 1, rxWorkers: []int{7}},
+		}, 2)
+		Expect(placement).To(Equal(map[uint32][]int{3: {0}}))
+	})
+
+	It("places nothing without workers", func() {
+		Expect(computeRxPlacement([]memifQueues{{swIfIndex: 3, numRxQueues: 1}}, 0)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,272 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podinterface
+
+import (
+	"fmt"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+)
+
+// SriovPodInterfaceDriver moves a VF of an avf uplink in the pod netns.
+// The PF tags the VF traffic with a VLAN of its own, which VPP receives
+// on a sub-interface of the uplink, so that pod traffic is still routed
+// and policed by VPP.
+type SriovPodInterfaceDriver struct {
+	PodInterfaceDriverData
+}
+
+func NewSriovPodInterfaceDriver(vpp *vpplink.VppLink, log *logrus.Entry) *SriovPodInterfaceDriver {
+	i := &SriovPodInterfaceDriver{}
+	i.vpp = vpp
+	i.log = log
+	i.Name = "sriov"
+	return i
+}
+
+// configureVF sets the VLAN, MAC and spoof checking of a VF on its PF,
+// which lives in the VPP netns
+func (i *SriovPodInterfaceDriver) configureVF(pfName string, vf int, vlan int, mac []byte, spoofCheck bool, trust bool) error {
+	return ns.WithNetNSPath(utils.GetnetnsPath(config.VppNetnsName), func(ns.NetNS) error {
+		pf, err := netlink.LinkByName(pfName)
+		if err != nil {
+			return errors.Wrapf(err, "cannot find PF %s", pfName)
+		}
+		err = netlink.LinkSetVfVlan(pf, vf, vlan)
+		if err != nil {
+			return errors.Wrapf(err, "cannot set VF %d vlan %d on %s", vf, vlan, pfName)
+		}
+		if mac != nil {
+			err = netlink.LinkSetVfHardwareAddr(pf, vf, mac)
+			if err != nil {
+				return errors.Wrapf(err, "cannot set VF %d hwaddr on %s", vf, pfName)
+			}
+		}
+		err = utils.SetVFSpoofTrust(pfName, vf, spoofCheck, trust)
+		if err != nil {
+			return errors.Wrapf(err, "cannot set VF %d spoof/trust on %s", vf, pfName)
+		}
+		return nil
+	})
+}
+
+func (i *SriovPodInterfaceDriver) CreateInterface(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack, uplink *config.UplinkStatus, vlan int, spoofCheck bool, trust bool, doHostSideConf bool) (err error) {
+	if podSpec.SriovVf == 0 {
+		return errors.Errorf("no VF allocated for %s", podSpec.Key())
+	}
+	vfPCI, err := utils.GetInterfaceNthVFPciID(uplink.PciID, podSpec.SriovVf)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find VF %d of %s", podSpec.SriovVf, podSpec.SriovUplink)
+	}
+
+	err = i.configureVF(podSpec.SriovUplink, podSpec.SriovVf, vlan, podSpec.GetContainerMac(), spoofCheck, trust)
+	if err != nil {
+		return err
+	}
+
+	if doHostSideConf {
+		driverName, err := utils.GetDriverNameFromPci(vfPCI)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get VF %s driver", vfPCI)
+		}
+		if driverName != config.DriverIAVF {
+			err = utils.BindVFtoDriver(vfPCI, config.DriverIAVF)
+			if err != nil {
+				return errors.Wrapf(err, "cannot bind VF %s to %s", vfPCI, config.DriverIAVF)
+			}
+		}
+		err = i.moveVFToPod(podSpec, vfPCI, vpplink.DefaultIntTo(podSpec.Mtu, uplink.Mtu))
+		if err != nil {
+			return err
+		}
+	}
+
+	swIfIndex, err := i.vpp.CreateVlanSubif(uplink.SwIfIndex, uint32(vlan))
+	if err != nil {
+		return errors.Wrapf(err, "error creating vlan %d sub-interface", vlan)
+	} else {
+		stack.Push(i.vpp.DeleteSubif, swIfIndex)
+	}
+	podSpec.SriovSwIfIndex = swIfIndex
+	i.log.Infof("pod(add) sriov VF %s vlan %d swIfIndex=%d", vfPCI, vlan, swIfIndex)
+
+	err = i.vpp.SetInterfaceTag(swIfIndex, podSpec.GetInterfaceTag(i.Name))
+	if err != nil {
+		return errors.Wrapf(err, "error tagging sriov sub-interface")
+	}
+
+	err = i.DoPodIfNatConfiguration(podSpec, stack, swIfIndex)
+	if err != nil {
+		return err
+	}
+
+	// Sub-interfaces have no queues and share the uplink promisc mode,
+	// so only part of DoPodInterfaceConfiguration applies
+	for _, ipFamily := range vpplink.IPFamilies {
+		err = i.vpp.SetInterfaceVRF(swIfIndex, podSpec.GetVrfID(ipFamily), ipFamily.IsIP6)
+		if err != nil {
+			return errors.Wrapf(err, "error setting vpp if[%d] in pod vrf", swIfIndex)
+		}
+	}
+	err = i.vpp.InterfaceAdminUp(swIfIndex)
+	if err != nil {
+		return errors.Wrapf(err, "error setting new pod if up")
+	}
+	err = i.vpp.InterfaceSetUnnumbered(swIfIndex, podSpec.LoopbackSwIfIndex)
+	if err != nil {
+		return errors.Wrapf(err, "error setting interface unnumbered")
+	}
+
+	// Answer the pod ARP requests for its gateway
+	vrfID := podSpec.GetVrfID(vpplink.IPFamilyV4)
+	err = i.vpp.EnableArpProxy(swIfIndex, vrfID)
+	if err != nil {
+		return errors.Wrapf(err, "error enabling ARP proxy on sriov sub-interface")
+	} else {
+		stack.Push(i.vpp.DisableArpProxy, swIfIndex, vrfID)
+	}
+
+	return nil
+}
+
+// moveVFToPod moves the netdev of a VF in the pod netns, and configures
+// it as the tun would be
+func (i *SriovPodInterfaceDriver) moveVFToPod(podSpec *storage.LocalPodSpec, vfPCI string, mtu int) error {
+	vfName, err := utils.GetInterfaceNameFromPci(vfPCI)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find VF %s netdev", vfPCI)
+	}
+	link, err := netlink.LinkByName(vfName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find VF %s", vfName)
+	}
+	podNS, err := ns.GetNS(podSpec.NetnsName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open netns %s", podSpec.NetnsName)
+	}
+	defer podNS.Close()
+	err = netlink.LinkSetNsFd(link, int(podNS.Fd()))
+	if err != nil {
+		return errors.Wrapf(err, "cannot move VF %s to %s", vfName, podSpec.NetnsName)
+	}
+
+	err = podNS.Do(func(ns.NetNS) error {
+		link, err := netlink.LinkByName(vfName)
+		if err != nil {
+			return errors.Wrapf(err, "cannot find VF %s in pod", vfName)
+		}
+		err = netlink.LinkSetName(link, podSpec.InterfaceName)
+		if err != nil {
+			return errors.Wrapf(err, "cannot rename VF %s to %s", vfName, podSpec.InterfaceName)
+		}
+		err = netlink.LinkSetMTU(link, mtu)
+		if err != nil {
+			return errors.Wrapf(err, "cannot set VF mtu %d", mtu)
+		}
+		err = netlink.LinkSetUp(link)
+		if err != nil {
+			return errors.Wrapf(err, "cannot set VF up")
+		}
+		for _, containerIP := range podSpec.GetContainerIps() {
+			i.log.Infof("pod(add) sriov address linux-ifIndex=%d address=%s", link.Attrs().Index, containerIP.String())
+			err = netlink.AddrAdd(link, &netlink.Addr{IPNet: containerIP})
+			if err != nil {
+				return errors.Wrapf(err, "failed to add IP addr to %s", podSpec.InterfaceName)
+			}
+		}
+		for _, route := range podSpec.GetRoutes() {
+			err = netlink.RouteAdd(&netlink.Route{
+				LinkIndex: link.Attrs().Index,
+				Scope:     netlink.SCOPE_UNIVERSE,
+				Dst:       route,
+			})
+			if err != nil {
+				i.log.Errorf("Error adding sriov route for %s: %s", route.String(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Error in linux NS config")
+	}
+	return nil
+}
+
+// moveVFFromPod gives the netdev of a VF back to the host netns, under a
+// name that cannot conflict with host interfaces
+func (i *SriovPodInterfaceDriver) moveVFFromPod(podSpec *storage.LocalPodSpec) error {
+	hostNS, err := ns.GetCurrentNS()
+	if err != nil {
+		return errors.Wrap(err, "cannot find our netns")
+	}
+	defer hostNS.Close()
+	return ns.WithNetNSPath(podSpec.NetnsName, func(ns.NetNS) error {
+		link, err := netlink.LinkByName(podSpec.InterfaceName)
+		if err != nil {
+			return errors.Wrapf(err, "cannot find VF %s in pod", podSpec.InterfaceName)
+		}
+		err = netlink.LinkSetDown(link)
+		if err != nil {
+			return errors.Wrapf(err, "cannot set VF down")
+		}
+		err = netlink.LinkSetName(link, fmt.Sprintf("sriov%d-%d", podSpec.SriovVf, link.Attrs().Index))
+		if err != nil {
+			return errors.Wrapf(err, "cannot rename VF %s", podSpec.InterfaceName)
+		}
+		err = netlink.LinkSetNsFd(link, int(hostNS.Fd()))
+		if err != nil {
+			return errors.Wrapf(err, "cannot move VF back to host netns")
+		}
+		return nil
+	})
+}
+
+func (i *SriovPodInterfaceDriver) DeleteInterface(podSpec *storage.LocalPodSpec) {
+	if !podSpec.EnableSriov || podSpec.SriovSwIfIndex == vpplink.InvalidID {
+		return
+	}
+
+	err := i.vpp.DisableArpProxy(podSpec.SriovSwIfIndex, podSpec.GetVrfID(vpplink.IPFamilyV4))
+	if err != nil {
+		i.log.Warnf("Error disabling ARP proxy on sriov[%d] %s", podSpec.SriovSwIfIndex, err)
+	}
+	i.UndoPodInterfaceConfiguration(podSpec.SriovSwIfIndex)
+	i.UndoPodIfNatConfiguration(podSpec.SriovSwIfIndex)
+
+	err = i.vpp.DeleteSubif(podSpec.SriovSwIfIndex)
+	if err != nil {
+		i.log.Warnf("Error deleting sriov[%d] %s", podSpec.SriovSwIfIndex, err)
+	}
+
+	err = i.moveVFFromPod(podSpec)
+	if err != nil {
+		i.log.Warnf("Error moving VF %d back from pod %s", podSpec.SriovVf, err)
+	}
+	// Stop forwarding the pod VLAN to the wire once the VF is released
+	err = i.configureVF(podSpec.SriovUplink, podSpec.SriovVf, 0 /* vlan */, nil, true /* spoof */, false /* trust */)
+	if err != nil {
+		i.log.Warnf("Error resetting VF %d %s", podSpec.SriovVf, err)
+	}
+	i.log.Infof("pod(del) sriov VF %d swIfIndex=%d", podSpec.SriovVf, podSpec.SriovSwIfIndex)
+}
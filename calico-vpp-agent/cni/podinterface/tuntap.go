@@ -63,8 +63,8 @@ func reduceMtuIf(podMtu *int, tunnelMtu int, tunnelEnabled bool) {
  */
 func (i *TunTapPodInterfaceDriver) computePodMtu(podSpecMtu int, fc *felixConfig.Config, ipipEnabled bool, vxlanEnabled bool) (podMtu int) {
 	hostMtu := vpplink.CalicoVppMaxMTu
-	if len(common.VppManagerInfo.UplinkStatuses) != 0 {
-		for _, v := range common.VppManagerInfo.UplinkStatuses {
+	if len(common.GetVppManagerInfo().UplinkStatuses) != 0 {
+		for _, v := range common.GetVppManagerInfo().UplinkStatuses {
 			if v.Mtu < hostMtu {
 				hostMtu = v.Mtu
 			}
@@ -108,6 +108,9 @@ func (i *TunTapPodInterfaceDriver) FelixConfigChanged(newFelixConfig *felixConfi
 	}
 	if i.felixConfig != nil {
 		for name, podSpec := range podSpecs {
+			if podSpec.EnableVhostUser || podSpec.EnableSriov {
+				continue
+			}
 			oldMtu := i.computePodMtu(podSpec.Mtu, i.felixConfig, i.ipipEncapRefCounts > 0, i.vxlanEncapRefCounts > 0)
 			newMtu := i.computePodMtu(podSpec.Mtu, i.felixConfig, i.ipipEncapRefCounts+ipipEncapRefCountDelta > 0, i.vxlanEncapRefCounts+vxlanEncapRefCountDelta > 0)
 			if oldMtu != newMtu {
@@ -151,6 +154,8 @@ func (i *TunTapPodInterfaceDriver) CreateInterface(podSpec *storage.LocalPodSpec
 
 	if *podSpec.IfSpec.IsL3 {
 		tun.Flags |= types.TapFlagTun
+	} else {
+		tun.HostMacAddress = podSpec.GetContainerMac()
 	}
 
 	if *config.GetCalicoVppDebug().GSOEnabled {
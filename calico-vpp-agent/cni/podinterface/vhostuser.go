@@ -0,0 +1,149 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podinterface
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+// VhostUserPodInterfaceDriver gives pods running VMs (KubeVirt) or DPDK
+// applications a vhost-user socket instead of a tun. VPP is the server
+// side of the socket, so that the guest can reconnect when VPP or the
+// agent restart.
+type VhostUserPodInterfaceDriver struct {
+	PodInterfaceDriverData
+}
+
+func NewVhostUserPodInterfaceDriver(vpp *vpplink.VppLink, log *logrus.Entry) *VhostUserPodInterfaceDriver {
+	i := &VhostUserPodInterfaceDriver{}
+	i.vpp = vpp
+	i.log = log
+	i.Name = "vhost"
+	return i
+}
+
+// prepareVhostUserSocket creates the directory of the socket, and removes
+// a stale socket left by a previous VPP
+func prepareVhostUserSocket(socket string) error {
+	err := os.MkdirAll(filepath.Dir(socket), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "error creating vhost-user socket directory for %s", socket)
+	}
+	err = os.Remove(socket)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error removing stale vhost-user socket %s", socket)
+	}
+	return nil
+}
+
+// removeVhostUserSocket removes the socket of an interface, and its
+// directory once the other interfaces of the pod removed theirs
+func removeVhostUserSocket(socket string) error {
+	err := os.Remove(socket)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error removing vhost-user socket %s", socket)
+	}
+	entries, err := os.ReadDir(filepath.Dir(socket))
+	if err != nil || len(entries) > 0 {
+		return nil
+	}
+	err = os.Remove(filepath.Dir(socket))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error removing vhost-user socket directory of %s", socket)
+	}
+	return nil
+}
+
+func (i *VhostUserPodInterfaceDriver) CreateInterface(podSpec *storage.LocalPodSpec, stack *vpplink.CleanupStack) (err error) {
+	socket := podSpec.GetVhostUserSocket()
+	err = prepareVhostUserSocket(socket)
+	if err != nil {
+		return err
+	}
+
+	vhostUser := &types.VhostUser{
+		SocketFilename: socket,
+		IsServer:       true,
+		EnableGSO:      *config.GetCalicoVppDebug().GSOEnabled,
+		Tag:            podSpec.GetInterfaceTag(i.Name),
+	}
+	err = i.vpp.CreateVhostUser(vhostUser)
+	if err != nil {
+		return errors.Wrapf(err, "Error creating vhost-user")
+	} else {
+		stack.Push(i.vpp.DeleteVhostUser, vhostUser.SwIfIndex)
+	}
+	podSpec.VhostUserSwIfIndex = vhostUser.SwIfIndex
+	i.log.Infof("pod(add) vhost-user %s", vhostUser.String())
+
+	err = i.DoPodIfNatConfiguration(podSpec, stack, vhostUser.SwIfIndex)
+	if err != nil {
+		return err
+	}
+
+	// The guest is an ethernet endpoint: configure the interface as L2,
+	// which also makes it promiscuous as the guest picks its own MACs
+	isL3 := false
+	ifSpec := podSpec.IfSpec
+	ifSpec.IsL3 = &isL3
+	err = i.DoPodInterfaceConfiguration(podSpec, stack, ifSpec, vhostUser.SwIfIndex)
+	if err != nil {
+		return err
+	}
+
+	// Answer the guest ARP requests for its gateway
+	vrfID := podSpec.GetVrfID(vpplink.IPFamilyV4)
+	err = i.vpp.EnableArpProxy(vhostUser.SwIfIndex, vrfID)
+	if err != nil {
+		return errors.Wrapf(err, "error enabling ARP proxy on vhost-user")
+	} else {
+		stack.Push(i.vpp.DisableArpProxy, vhostUser.SwIfIndex, vrfID)
+	}
+
+	return nil
+}
+
+func (i *VhostUserPodInterfaceDriver) DeleteInterface(podSpec *storage.LocalPodSpec) {
+	if !podSpec.EnableVhostUser || podSpec.VhostUserSwIfIndex == vpplink.InvalidID {
+		return
+	}
+
+	err := i.vpp.DisableArpProxy(podSpec.VhostUserSwIfIndex, podSpec.GetVrfID(vpplink.IPFamilyV4))
+	if err != nil {
+		i.log.Warnf("Error disabling ARP proxy on vhost-user[%d] %s", podSpec.VhostUserSwIfIndex, err)
+	}
+	i.UndoPodInterfaceConfiguration(podSpec.VhostUserSwIfIndex)
+	i.UndoPodIfNatConfiguration(podSpec.VhostUserSwIfIndex)
+
+	err = i.vpp.DeleteVhostUser(podSpec.VhostUserSwIfIndex)
+	if err != nil {
+		i.log.Warnf("Error deleting vhost-user[%d] %s", podSpec.VhostUserSwIfIndex, err)
+	}
+	err = removeVhostUserSocket(podSpec.GetVhostUserSocket())
+	if err != nil {
+		i.log.Warn(err)
+	}
+	i.log.Infof("pod(del) vhost-user swIfIndex=%d", podSpec.VhostUserSwIfIndex)
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podinterface
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/storage"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+var _ = Describe("Vhost-user sockets", func() {
+	var socketDir string
+
+	BeforeEach(func() {
+		var err error
+		socketDir, err = os.MkdirTemp("", "vhost-user")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(socketDir)).To(Succeed())
+	})
+
+	It("uses a per pod directory", func() {
+		podSpec := &storage.LocalPodSpec{WorkloadID: "ns1/vm1", InterfaceName: "eth0"}
+		Expect(podSpec.GetVhostUserSocket()).To(Equal(filepath.Join(config.VhostUserSocketDir, "ns1/vm1/eth0.sock")))
+	})
+
+	It("replaces stale sockets", func() {
+		socket := filepath.Join(socketDir, "ns1/vm1/eth0.sock")
+		Expect(prepareVhostUserSocket(socket)).To(Succeed())
+		Expect(filepath.Dir(socket)).To(BeADirectory())
+		Expect(os.WriteFile(socket, nil, 0644)).To(Succeed())
+		Expect(prepareVhostUserSocket(socket)).To(Succeed())
+		Expect(socket).ToNot(BeAnExistingFile())
+	})
+
+	It("only removes the socket of the deleted interface", func() {
+		socket0 := filepath.Join(socketDir, "ns1/vm1/eth0.sock")
+		socket1 := filepath.Join(socketDir, "ns1/vm1/net1.sock")
+		for _, socket := range []string{socket0, socket1} {
+			Expect(prepareVhostUserSocket(socket)).To(Succeed())
+			Expect(os.WriteFile(socket, nil, 0644)).To(Succeed())
+		}
+
+		Expect(removeVhostUserSocket(socket0)).To(Succeed())
+		Expect(socket0).ToNot(BeAnExistingFile())
+		Expect(socket1).To(BeAnExistingFile())
+
+		Expect(removeVhostUserSocket(socket1)).To(Succeed())
+		Expect(filepath.Dir(socket1)).ToNot(BeAnExistingFile())
+		// Removing twice is not an error
+		Expect(removeVhostUserSocket(socket1)).To(Succeed())
+	})
+})
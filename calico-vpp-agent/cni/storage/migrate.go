@@ -0,0 +1,328 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/lunixbochs/struc"
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+/**
+ * Before the PodStore, pods were persisted in a binary state file packed
+ * with struc, named config.CniServerStateFile followed by the version of
+ * its layout. These files are only read once, to import their pods in the
+ * PodStore. The layouts of the released state files are frozen below, each
+ * with a migrate() converting it to the next version. Files are converted
+ * one version at a time up to the last one, which converts to the current
+ * LocalPodSpec. To support an older release, freeze its layout here with a
+ * migrate() to the following version, and lower
+ * MinCniServerStateFileVersion.
+ */
+const (
+	MinCniServerStateFileVersion = 8
+	CniServerStateFileVersion    = 9
+)
+
+// localPodSpecV8 is the LocalPodSpec layout of version 8, which predates
+// the uRPF VRFs of the pods
+type localPodSpecV8 struct {
+	InterfaceNameSize int `struc:"int16,sizeof=InterfaceName"`
+	InterfaceName     string
+	NetnsNameSize     int `struc:"int16,sizeof=NetnsName"`
+	NetnsName         string
+	AllowIPForwarding bool
+	RoutesSize        int `struc:"int16,sizeof=Routes"`
+	Routes            []LocalIPNet
+	ContainerIpsSize  int `struc:"int16,sizeof=ContainerIps"`
+	ContainerIps      []LocalIP
+	Mtu               int
+
+	OrchestratorIDSize int `struc:"int16,sizeof=OrchestratorID"`
+	OrchestratorID     string
+	WorkloadIDSize     int `struc:"int16,sizeof=WorkloadID"`
+	WorkloadID         string
+	EndpointIDSize     int `struc:"int16,sizeof=EndpointID"`
+	EndpointID         string
+	HostPortsSize      int `struc:"int16,sizeof=HostPorts"`
+	HostPorts          []HostPortBinding
+
+	IfPortConfigsLen   int `struc:"int16,sizeof=IfPortConfigs"`
+	IfPortConfigs      []LocalIfPortConfigs
+	PortFilteredIfType VppInterfaceType
+	DefaultIfType      VppInterfaceType
+	EnableVCL          bool
+	EnableMemif        bool
+
+	IfSpec       config.InterfaceSpec
+	PBLMemifSpec config.InterfaceSpec
+
+	MemifSocketID     uint32
+	TunTapSwIfIndex   uint32
+	MemifSwIfIndex    uint32
+	LoopbackSwIfIndex uint32
+	PblIndex          uint32
+
+	V4VrfID   uint32
+	V6VrfID   uint32
+	NeedsSnat bool
+
+	NetworkNameSize int `struc:"int16,sizeof=NetworkName"`
+	NetworkName     string
+
+	AllowedSpoofingPrefixesSize int `struc:"int16,sizeof=AllowedSpoofingPrefixes"`
+	AllowedSpoofingPrefixes     string
+}
+
+// migrate converts the pod to version 9. Its uRPF VRFs are allocated when
+// its interfaces are re-created
+func (ps *localPodSpecV8) migrate() localPodSpecV9 {
+	return localPodSpecV9{
+		InterfaceName:     ps.InterfaceName,
+		NetnsName:         ps.NetnsName,
+		AllowIPForwarding: ps.AllowIPForwarding,
+		Routes:            ps.Routes,
+		ContainerIps:      ps.ContainerIps,
+		Mtu:               ps.Mtu,
+
+		OrchestratorID: ps.OrchestratorID,
+		WorkloadID:     ps.WorkloadID,
+		EndpointID:     ps.EndpointID,
+		HostPorts:      ps.HostPorts,
+
+		IfPortConfigs:      ps.IfPortConfigs,
+		PortFilteredIfType: ps.PortFilteredIfType,
+		DefaultIfType:      ps.DefaultIfType,
+		EnableVCL:          ps.EnableVCL,
+		EnableMemif:        ps.EnableMemif,
+
+		IfSpec:       ps.IfSpec,
+		PBLMemifSpec: ps.PBLMemifSpec,
+
+		MemifSocketID:     ps.MemifSocketID,
+		TunTapSwIfIndex:   ps.TunTapSwIfIndex,
+		MemifSwIfIndex:    ps.MemifSwIfIndex,
+		LoopbackSwIfIndex: ps.LoopbackSwIfIndex,
+		PblIndex:          ps.PblIndex,
+
+		V4VrfID:   ps.V4VrfID,
+		V6VrfID:   ps.V6VrfID,
+		NeedsSnat: ps.NeedsSnat,
+
+		NetworkName:             ps.NetworkName,
+		AllowedSpoofingPrefixes: ps.AllowedSpoofingPrefixes,
+
+		V4RPFVrfID: types.InvalidID,
+		V6RPFVrfID: types.InvalidID,
+	}
+}
+
+// localPodSpecV9 is the LocalPodSpec layout of CniServerStateFileVersion 9.
+// Pods imported from it have no labels, they only match egress policies
+// without podSelector until they are re-added.
+type localPodSpecV9 struct {
+	InterfaceNameSize int `struc:"int16,sizeof=InterfaceName"`
+	InterfaceName     string
+	NetnsNameSize     int `struc:"int16,sizeof=NetnsName"`
+	NetnsName         string
+	AllowIPForwarding bool
+	RoutesSize        int `struc:"int16,sizeof=Routes"`
+	Routes            []LocalIPNet
+	ContainerIpsSize  int `struc:"int16,sizeof=ContainerIps"`
+	ContainerIps      []LocalIP
+	Mtu               int
+
+	OrchestratorIDSize int `struc:"int16,sizeof=OrchestratorID"`
+	OrchestratorID     string
+	WorkloadIDSize     int `struc:"int16,sizeof=WorkloadID"`
+	WorkloadID         string
+	EndpointIDSize     int `struc:"int16,sizeof=EndpointID"`
+	EndpointID         string
+	HostPortsSize      int `struc:"int16,sizeof=HostPorts"`
+	HostPorts          []HostPortBinding
+
+	IfPortConfigsLen   int `struc:"int16,sizeof=IfPortConfigs"`
+	IfPortConfigs      []LocalIfPortConfigs
+	PortFilteredIfType VppInterfaceType
+	DefaultIfType      VppInterfaceType
+	EnableVCL          bool
+	EnableMemif        bool
+
+	IfSpec       config.InterfaceSpec
+	PBLMemifSpec config.InterfaceSpec
+
+	MemifSocketID     uint32
+	TunTapSwIfIndex   uint32
+	MemifSwIfIndex    uint32
+	LoopbackSwIfIndex uint32
+	PblIndex          uint32
+
+	V4VrfID   uint32
+	V6VrfID   uint32
+	NeedsSnat bool
+
+	NetworkNameSize int `struc:"int16,sizeof=NetworkName"`
+	NetworkName     string
+
+	AllowedSpoofingPrefixesSize int `struc:"int16,sizeof=AllowedSpoofingPrefixes"`
+	AllowedSpoofingPrefixes     string
+
+	V4RPFVrfID uint32
+	V6RPFVrfID uint32
+}
+
+func (ps *localPodSpecV9) migrate() LocalPodSpec {
+	return LocalPodSpec{
+		InterfaceName:     ps.InterfaceName,
+		NetnsName:         ps.NetnsName,
+		AllowIPForwarding: ps.AllowIPForwarding,
+		Routes:            ps.Routes,
+		ContainerIps:      ps.ContainerIps,
+		Mtu:               ps.Mtu,
+
+		OrchestratorID: ps.OrchestratorID,
+		WorkloadID:     ps.WorkloadID,
+		EndpointID:     ps.EndpointID,
+		HostPorts:      ps.HostPorts,
+
+		IfPortConfigs:      ps.IfPortConfigs,
+		PortFilteredIfType: ps.PortFilteredIfType,
+		DefaultIfType:      ps.DefaultIfType,
+		EnableVCL:          ps.EnableVCL,
+		EnableMemif:        ps.EnableMemif,
+
+		IfSpec:       ps.IfSpec,
+		PBLMemifSpec: ps.PBLMemifSpec,
+
+		MemifSocketID:     ps.MemifSocketID,
+		TunTapSwIfIndex:   ps.TunTapSwIfIndex,
+		MemifSwIfIndex:    ps.MemifSwIfIndex,
+		LoopbackSwIfIndex: ps.LoopbackSwIfIndex,
+		PblIndex:          ps.PblIndex,
+
+		VhostUserSwIfIndex: types.InvalidID,
+		SriovSwIfIndex:     types.InvalidID,
+
+		V4VrfID:   ps.V4VrfID,
+		V6VrfID:   ps.V6VrfID,
+		NeedsSnat: ps.NeedsSnat,
+
+		NetworkName:             ps.NetworkName,
+		AllowedSpoofingPrefixes: ps.AllowedSpoofingPrefixes,
+		V4RPFVrfID:              ps.V4RPFVrfID,
+		V6RPFVrfID:              ps.V6RPFVrfID,
+
+		PolicyRouteIndexes: make([]LocalPolicyRoute, 0),
+
+		IngressPolicerIndex: types.InvalidID,
+		EgressPolicerIndex:  types.InvalidID,
+	}
+}
+
+func unpackCniServerState[T any](data []byte) ([]T, error) {
+	var state struct {
+		Version    int `struc:"int32"`
+		SpecsCount int `struc:"int32,sizeof=Specs"`
+		Specs      []T
+	}
+	err := struc.Unpack(bytes.NewBuffer(data), &state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error unpacking")
+	}
+	return state.Specs, nil
+}
+
+// migrateCniServerState decodes a state file, and converts its content
+// to the current LocalPodSpec, one version at a time
+func migrateCniServerState(version int, data []byte) (specs []LocalPodSpec, err error) {
+	var v8 []localPodSpecV8
+	var v9 []localPodSpecV9
+	switch version {
+	case 8:
+		v8, err = unpackCniServerState[localPodSpecV8](data)
+	case 9:
+		v9, err = unpackCniServerState[localPodSpecV9](data)
+	default:
+		return nil, fmt.Errorf("unsupported save file version: %d", version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, ps := range v8 {
+		v9 = append(v9, ps.migrate())
+	}
+	specs = make([]LocalPodSpec, 0, len(v9))
+	for _, ps := range v9 {
+		specs = append(specs, ps.migrate())
+	}
+	return specs, nil
+}
+
+// LoadCniServerState reads a binary state file of any supported version
+func LoadCniServerState(fname string) ([]LocalPodSpec, error) {
+	var header struct {
+		Version int `struc:"int32"`
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil // No state to load
+		} else {
+			return nil, errors.Wrapf(err, "Error reading file %s", fname)
+		}
+	}
+	err = struc.Unpack(bytes.NewBuffer(data), &header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error unpacking")
+	}
+	return migrateCniServerState(header.Version, data)
+}
+
+// ImportCniServerState adds the pods of the binary state files to the
+// store, and removes the state files. A state file is named after prefix
+// followed by its version, every supported version is looked for. Files
+// are imported from the oldest, so that the pods of the most recent one
+// win. It returns the most recent version imported, or 0.
+func ImportCniServerState(store PodStore, prefix string) (importedVersion int, err error) {
+	for version := MinCniServerStateFileVersion; version <= CniServerStateFileVersion; version++ {
+		fname := fmt.Sprintf("%s%d", prefix, version)
+		if _, err := os.Stat(fname); err != nil {
+			continue
+		}
+		specs, err := LoadCniServerState(fname)
+		if err != nil {
+			return version, errors.Wrapf(err, "error loading %s", fname)
+		}
+		for _, podSpec := range specs {
+			err = store.Put(&podSpec)
+			if err != nil {
+				return version, errors.Wrapf(err, "error importing %s", podSpec.Key())
+			}
+		}
+		err = os.Remove(fname)
+		if err != nil {
+			return version, errors.Wrapf(err, "error removing %s", fname)
+		}
+		importedVersion = version
+	}
+	return importedVersion, nil
+}
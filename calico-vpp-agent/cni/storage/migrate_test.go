@@ -0,0 +1,158 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden JSON files in testdata/")
+
+func TestStorage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CNI storage tests")
+}
+
+// The state files in testdata are packed with the layout of their version,
+// as the release using it wrote them. They must not be regenerated
+var stateFiles = map[int]string{
+	8: filepath.Join("testdata", "cni_server_state_v8"),
+	9: filepath.Join("testdata", "cni_server_state_v9"),
+}
+
+func dumpSpecs(specs []LocalPodSpec) []byte {
+	out, err := json.MarshalIndent(specs, "", "  ")
+	Expect(err).ToNot(HaveOccurred())
+	return append(out, '\n')
+}
+
+var _ = Describe("CNI server state file", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "cni-storage")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("has a state file for every version", func() {
+		for version := MinCniServerStateFileVersion; version <= CniServerStateFileVersion; version++ {
+			Expect(stateFiles).To(HaveKey(version))
+		}
+	})
+
+	for version, fname := range stateFiles {
+		version, fname := version, fname
+		It(fmt.Sprintf("migrates version %d", version), func() {
+			golden := fname + ".golden"
+			specs, err := LoadCniServerState(fname)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(specs).To(HaveLen(1))
+			if *update {
+				Expect(os.WriteFile(golden, dumpSpecs(specs), 0644)).To(Succeed())
+			}
+			expected, err := os.ReadFile(golden)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(dumpSpecs(specs))).To(Equal(string(expected)))
+		})
+	}
+
+	writeStateFile := func(prefix string, version int, workloadID string) string {
+		specs, err := LoadCniServerState(stateFiles[version])
+		Expect(err).ToNot(HaveOccurred())
+		data, err := os.ReadFile(stateFiles[version])
+		Expect(err).ToNot(HaveOccurred())
+		// Rename the pod, which is the last string of WorkloadIDSize+WorkloadID
+		old := append(binary.BigEndian.AppendUint16(nil, uint16(len(specs[0].WorkloadID))), specs[0].WorkloadID...)
+		renamed := append(binary.BigEndian.AppendUint16(nil, uint16(len(workloadID))), workloadID...)
+		data = bytes.Replace(data, old, renamed, 1)
+		fname := fmt.Sprintf("%s%d", prefix, version)
+		Expect(os.WriteFile(fname, data, 0644)).To(Succeed())
+		return fname
+	}
+
+	for version := range stateFiles {
+		version := version
+		It(fmt.Sprintf("imports the state file version %d in the store", version), func() {
+			prefix := filepath.Join(tmpDir, "state")
+			fname := writeStateFile(prefix, version, "default/pod-1")
+			store := NewJSONPodStore(filepath.Join(tmpDir, "pods"))
+
+			importedVersion, err := ImportCniServerState(store, prefix)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(importedVersion).To(Equal(version))
+			Expect(fname).ToNot(BeAnExistingFile())
+
+			specs, err := store.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(specs).To(HaveLen(1))
+			Expect(specs[0].WorkloadID).To(Equal("default/pod-1"))
+			Expect(specs[0].IngressPolicerIndex).To(Equal(uint32(types.InvalidID)))
+			Expect(specs[0].VhostUserSwIfIndex).To(Equal(uint32(types.InvalidID)))
+
+			importedVersion, err = ImportCniServerState(store, prefix)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(importedVersion).To(Equal(0))
+		})
+	}
+
+	It("imports the state files of every version, the most recent winning", func() {
+		prefix := filepath.Join(tmpDir, "state")
+		for version := range stateFiles {
+			writeStateFile(prefix, version, fmt.Sprintf("default/pod-v%d", version))
+		}
+		store := NewJSONPodStore(filepath.Join(tmpDir, "pods"))
+
+		importedVersion, err := ImportCniServerState(store, prefix)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(importedVersion).To(Equal(CniServerStateFileVersion))
+		specs, err := store.Load()
+		Expect(err).ToNot(HaveOccurred())
+		// The files contain the same interface
+		Expect(specs).To(HaveLen(1))
+		Expect(specs[0].WorkloadID).To(Equal(fmt.Sprintf("default/pod-v%d", CniServerStateFileVersion)))
+		for version := range stateFiles {
+			Expect(fmt.Sprintf("%s%d", prefix, version)).ToNot(BeAnExistingFile())
+		}
+	})
+
+	It("rejects unsupported versions", func() {
+		data, err := os.ReadFile(stateFiles[CniServerStateFileVersion])
+		Expect(err).ToNot(HaveOccurred())
+		// The file starts with its version, as a big endian int32
+		binary.BigEndian.PutUint32(data, MinCniServerStateFileVersion-1)
+		fname := filepath.Join(tmpDir, "state")
+		Expect(os.WriteFile(fname, data, 0644)).To(Succeed())
+		_, err = LoadCniServerState(fname)
+		Expect(err).To(MatchError(ContainSubstring("unsupported save file version")))
+	})
+})
@@ -16,16 +16,13 @@
 package storage
 
 import (
-	"bytes"
 	"crypto/sha512"
 	"encoding/base64"
 	"fmt"
 	"net"
-	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/lunixbochs/struc"
 	"github.com/pkg/errors"
 
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
@@ -35,19 +32,18 @@ import (
 )
 
 const (
-	CniServerStateFileVersion = 9  // Used to ensure compatibility wen we reload data
-	MaxAPITagLen              = 63 /* No more than 64 characters in API tags */
-	VrfTagHashLen             = 8  /* how many hash charatecters (b64) of the name in tag prefix (useful when trucated) */
+	MaxAPITagLen  = 63 /* No more than 64 characters in API tags */
+	VrfTagHashLen = 8  /* how many hash charatecters (b64) of the name in tag prefix (useful when trucated) */
 )
 
-// XXX: Increment CniServerStateFileVersion when changing this struct
+// XXX: Also used by the binary state file layouts in migrate.go, do not change
 type LocalIPNet struct {
-	MaskSize int    `struc:"int8,sizeof=Mask"`
+	MaskSize int    `struc:"int8,sizeof=Mask" json:"-"`
 	IP       net.IP `struc:"[16]byte"`
 	Mask     net.IPMask
 }
 
-// XXX: Increment CniServerStateFileVersion when changing this struct
+// XXX: Also used by the binary state file layouts in migrate.go, do not change
 type LocalIP struct {
 	IP net.IP `struc:"[16]byte"`
 }
@@ -59,6 +55,8 @@ const (
 	VppIfTypeTunTap
 	VppIfTypeMemif
 	VppIfTypeVCL
+	VppIfTypeVhostUser
+	VppIfTypeSriov
 )
 
 func (ift VppInterfaceType) String() string {
@@ -71,11 +69,29 @@ func (ift VppInterfaceType) String() string {
 		return "Memif"
 	case VppIfTypeVCL:
 		return "VCL"
+	case VppIfTypeVhostUser:
+		return "VhostUser"
+	case VppIfTypeSriov:
+		return "Sriov"
 	default:
 		return "Unknown"
 	}
 }
 
+func (ift VppInterfaceType) MarshalText() ([]byte, error) {
+	return []byte(ift.String()), nil
+}
+
+func (ift *VppInterfaceType) UnmarshalText(text []byte) error {
+	for t := VppIfTypeUnknown; t <= VppIfTypeSriov; t++ {
+		if t.String() == string(text) {
+			*ift = t
+			return nil
+		}
+	}
+	return errors.Errorf("unknown interface type %s", text)
+}
+
 func (n *LocalIPNet) String() string {
 	ipnet := net.IPNet{
 		IP:   n.IP,
@@ -84,6 +100,26 @@ func (n *LocalIPNet) String() string {
 	return ipnet.String()
 }
 
+// MarshalText formats the prefix as a CIDR in the JSON pod store
+func (n LocalIPNet) MarshalText() ([]byte, error) {
+	ipnet := net.IPNet{
+		IP:   n.IP,
+		Mask: n.Mask,
+	}
+	return []byte(ipnet.String()), nil
+}
+
+func (n *LocalIPNet) UnmarshalText(text []byte) error {
+	_, ipnet, err := net.ParseCIDR(string(text))
+	if err != nil {
+		return err
+	}
+	n.IP = ipnet.IP
+	n.Mask = ipnet.Mask
+	n.UpdateSizes()
+	return nil
+}
+
 func (n *LocalIP) String() string {
 	return n.IP.String()
 }
@@ -92,16 +128,6 @@ func (n *LocalIPNet) UpdateSizes() {
 	n.MaskSize = len(n.Mask)
 }
 
-func (ps *LocalPodSpec) UpdateSizes() {
-	ps.RoutesSize = len(ps.Routes)
-	ps.ContainerIpsSize = len(ps.ContainerIps)
-	ps.InterfaceNameSize = len(ps.InterfaceName)
-	ps.NetnsNameSize = len(ps.NetnsName)
-	for _, n := range ps.Routes {
-		n.UpdateSizes()
-	}
-}
-
 func (ps *LocalPodSpec) Key() string {
 	return fmt.Sprintf("netns:%s,if:%s", ps.NetnsName, ps.InterfaceName)
 }
@@ -141,14 +167,30 @@ func (ps *LocalPodSpec) FullString() string {
 	s += fmt.Sprintf("DefaultIfType:      %s\n", ps.DefaultIfType.String())
 	s += fmt.Sprintf("EnableVCL:          %t\n", ps.EnableVCL)
 	s += fmt.Sprintf("EnableMemif:        %t\n", ps.EnableMemif)
+	s += fmt.Sprintf("EnableVhostUser:    %t\n", ps.EnableVhostUser)
+	s += fmt.Sprintf("EnableSriov:        %t\n", ps.EnableSriov)
 	s += fmt.Sprintf("IsL3:               %t\n", *ps.IfSpec.IsL3)
 	s += fmt.Sprintf("MemifSocketID:      %d\n", ps.MemifSocketID)
 	s += fmt.Sprintf("TunTapSwIfIndex:    %d\n", ps.TunTapSwIfIndex)
 	s += fmt.Sprintf("MemifSwIfIndex:     %d\n", ps.MemifSwIfIndex)
 	s += fmt.Sprintf("LoopbackSwIfIndex:  %d\n", ps.LoopbackSwIfIndex)
+	s += fmt.Sprintf("VhostUserSwIfIndex: %d\n", ps.VhostUserSwIfIndex)
+	s += fmt.Sprintf("SriovSwIfIndex:     %d\n", ps.SriovSwIfIndex)
+	s += fmt.Sprintf("SriovVf:            %s/%d\n", ps.SriovUplink, ps.SriovVf)
 	s += fmt.Sprintf("PblIndexes:         %d\n", ps.PblIndex)
 	s += fmt.Sprintf("V4VrfID:            %d\n", ps.V4VrfID)
 	s += fmt.Sprintf("V6VrfID:            %d\n", ps.V6VrfID)
+	s += fmt.Sprintf("MulticastGroups:    %s\n", ps.MulticastGroups)
+	s += fmt.Sprintf("PolicyRoutes:       %s\n", ps.PolicyRoutes)
+	s += fmt.Sprintf("PolicyRouteIndexes: %s\n", types.StrableListToString("", ps.PolicyRouteIndexes))
+	s += fmt.Sprintf("PodLabels:          %s\n", ps.PodLabels)
+	s += fmt.Sprintf("EgressIP:           %s\n", ps.EgressIP)
+	s += fmt.Sprintf("IngressBandwidth:   %d\n", ps.IngressBandwidth)
+	s += fmt.Sprintf("EgressBandwidth:    %d\n", ps.EgressBandwidth)
+	s += fmt.Sprintf("IngressPolicer:     %d\n", ps.IngressPolicerIndex)
+	s += fmt.Sprintf("EgressPolicer:      %d\n", ps.EgressPolicerIndex)
+	s += fmt.Sprintf("QosClass:           %s dscp=%d\n", ps.QosClass, ps.Dscp)
+	s += fmt.Sprintf("ContainerMac:       %s (annotated:%t)\n", ps.ContainerMac, ps.ContainerMacAnnotated)
 	return s
 }
 
@@ -161,11 +203,56 @@ func (ps *LocalPodSpec) GetParamsForIfType(ifType VppInterfaceType) (swIfIndex u
 			return types.InvalidID, true
 		}
 		return ps.MemifSwIfIndex, *ps.PBLMemifSpec.IsL3
+	case VppIfTypeVhostUser:
+		// vhost-user interfaces are ethernet, but the guest MAC is not
+		// known in advance, so neighbors are resolved as on L3 interfaces
+		return ps.VhostUserSwIfIndex, true
+	case VppIfTypeSriov:
+		// The VF MAC is set to the pod MAC
+		return ps.SriovSwIfIndex, false
 	default:
 		return types.InvalidID, true
 	}
 }
 
+// GetPrimarySwIfIndex returns the interface carrying the pod traffic
+// outside of port filtered traffic. Policies, RPF and redirections
+// are applied to it.
+func (ps *LocalPodSpec) GetPrimarySwIfIndex() uint32 {
+	if ps.IsMemifOnly() {
+		return ps.MemifSwIfIndex
+	}
+	if ps.EnableVhostUser {
+		return ps.VhostUserSwIfIndex
+	}
+	if ps.EnableSriov {
+		return ps.SriovSwIfIndex
+	}
+	return ps.TunTapSwIfIndex
+}
+
+// GetMemifSpec returns the spec of the memif of the pod, which is given
+// separately from the tun one in the main network
+func (ps *LocalPodSpec) GetMemifSpec() config.InterfaceSpec {
+	if ps.NetworkName == "" {
+		return ps.PBLMemifSpec
+	}
+	return ps.IfSpec
+}
+
+// IsMemifOnly tells whether the pod interface is a memif without a tun,
+// a linux dummy interface carrying its addresses in the pod instead. This
+// is the case in non main networks, and with a memif default interface.
+func (ps *LocalPodSpec) IsMemifOnly() bool {
+	return ps.EnableMemif && (ps.NetworkName != "" || ps.DefaultIfType == VppIfTypeMemif)
+}
+
+// GetVhostUserSocket returns the path of the vhost-user socket of the pod,
+// in a per pod directory that can be mounted in the pod
+func (ps *LocalPodSpec) GetVhostUserSocket() string {
+	return filepath.Join(config.VhostUserSocketDir, ps.WorkloadID, ps.InterfaceName+".sock")
+}
+
 func (ps *LocalPodSpec) GetBuffersNeeded() uint64 {
 	var buffersNeededForThisPod uint64
 	buffersNeededForThisPod += ps.IfSpec.GetBuffersNeeded()
@@ -175,7 +262,7 @@ func (ps *LocalPodSpec) GetBuffersNeeded() uint64 {
 	return buffersNeededForThisPod
 }
 
-// XXX: Increment CniServerStateFileVersion when changing this struct
+// XXX: Also used by the binary state file layouts in migrate.go, do not change
 type LocalIfPortConfigs struct {
 	Start uint16
 	End   uint16
@@ -186,41 +273,48 @@ func (pc *LocalIfPortConfigs) String() string {
 	return fmt.Sprintf("%s %d-%d", pc.Proto.String(), pc.Start, pc.End)
 }
 
-// XXX: Increment CniServerStateFileVersion when changing this struct
+// XXX: Also used by the binary state file layouts in migrate.go, do not change
+type LocalPolicyRoute struct {
+	ACLIndex    uint32
+	AbfPolicyID uint32
+	SwIfIndex   uint32
+	IsIP6       bool
+}
+
+func (pr *LocalPolicyRoute) String() string {
+	return fmt.Sprintf("acl=%d abf=%d if=%d v6=%t", pr.ACLIndex, pr.AbfPolicyID, pr.SwIfIndex, pr.IsIP6)
+}
+
+// LocalPodSpec is persisted as JSON in the PodStore. Fields added later
+// are zero when loading older records, see PodStateRecordVersion.
 type LocalPodSpec struct {
-	InterfaceNameSize int `struc:"int16,sizeof=InterfaceName"`
 	InterfaceName     string
-	NetnsNameSize     int `struc:"int16,sizeof=NetnsName"`
 	NetnsName         string
 	AllowIPForwarding bool
-	RoutesSize        int `struc:"int16,sizeof=Routes"`
 	Routes            []LocalIPNet
-	ContainerIpsSize  int `struc:"int16,sizeof=ContainerIps"`
 	ContainerIps      []LocalIP
 	Mtu               int
 
 	// Pod identifiers
-	OrchestratorIDSize int `struc:"int16,sizeof=OrchestratorID"`
-	OrchestratorID     string
-	WorkloadIDSize     int `struc:"int16,sizeof=WorkloadID"`
-	WorkloadID         string
-	EndpointIDSize     int `struc:"int16,sizeof=EndpointID"`
-	EndpointID         string
+	OrchestratorID string
+	WorkloadID     string
+	EndpointID     string
 	// HostPort
-	HostPortsSize int `struc:"int16,sizeof=HostPorts"`
-	HostPorts     []HostPortBinding
+	HostPorts []HostPortBinding
 
-	IfPortConfigsLen int `struc:"int16,sizeof=IfPortConfigs"`
-	IfPortConfigs    []LocalIfPortConfigs
+	IfPortConfigs []LocalIfPortConfigs
 	/* This interface type will traffic MATCHING the portConfigs */
 	PortFilteredIfType VppInterfaceType
 	/* This interface type will traffic not matching portConfigs */
-	DefaultIfType VppInterfaceType
-	EnableVCL     bool
-	EnableMemif   bool
+	DefaultIfType   VppInterfaceType
+	EnableVCL       bool
+	EnableMemif     bool
+	EnableVhostUser bool
+	EnableSriov     bool
 
 	IfSpec       config.InterfaceSpec
 	PBLMemifSpec config.InterfaceSpec
+	MemifOptions config.MemifOptions
 
 	/**
 	 * Below are VPP internal ids, mutable fields in AddVppInterface
@@ -235,6 +329,13 @@ type LocalPodSpec struct {
 	LoopbackSwIfIndex uint32
 	PblIndex          uint32
 
+	VhostUserSwIfIndex uint32
+
+	/* SR-IOV VF of the uplink given to the pod, VF 0 is used by VPP */
+	SriovUplink    string
+	SriovVf        int
+	SriovSwIfIndex uint32
+
 	/**
 	 * These fields are only a runtime cache, but we also store them
 	 * on the disk for debugging purposes.
@@ -244,15 +345,49 @@ type LocalPodSpec struct {
 	NeedsSnat bool
 
 	/* Multi net */
-	NetworkNameSize int `struc:"int16,sizeof=NetworkName"`
-	NetworkName     string
+	NetworkName string
 
 	/* rpf check */
-	AllowedSpoofingPrefixesSize int `struc:"int16,sizeof=AllowedSpoofingPrefixes"`
-	AllowedSpoofingPrefixes     string
+	AllowedSpoofingPrefixes string
 
 	V4RPFVrfID uint32
 	V6RPFVrfID uint32
+
+	/* multicast groups joined by the pod, JSON list */
+	MulticastGroups string
+
+	/* policy based routing rules, JSON list */
+	PolicyRoutes       string
+	PolicyRouteIndexes []LocalPolicyRoute
+
+	/* egress gateway, labels are used to match the policy podSelector */
+	PodLabels string
+	EgressIP  string
+
+	/* bandwidth limits in bits/s from the pod annotations, 0 when unlimited */
+	IngressBandwidth    uint64
+	EgressBandwidth     uint64
+	IngressPolicerIndex uint32
+	EgressPolicerIndex  uint32
+
+	/* QoS class of the pod, its egress traffic is marked with Dscp */
+	QosClass string
+	Dscp     uint8
+
+	/* MAC address of the pod side of the interface, empty for pods created before it was recorded */
+	ContainerMac string
+	/* ContainerMacAnnotated is true when ContainerMac was requested by annotation */
+	ContainerMacAnnotated bool
+}
+
+// GetContainerMac returns the MAC address of the pod side of the interface,
+// or nil if it is not known
+func (ps *LocalPodSpec) GetContainerMac() net.HardwareAddr {
+	mac, err := net.ParseMAC(ps.ContainerMac)
+	if err != nil {
+		return nil
+	}
+	return mac
 }
 
 func (ps *LocalPodSpec) Copy() LocalPodSpec {
@@ -262,12 +397,15 @@ func (ps *LocalPodSpec) Copy() LocalPodSpec {
 	newPs.ContainerIps = append(make([]LocalIP, 0), ps.ContainerIps...)
 	newPs.HostPorts = append(make([]HostPortBinding, 0), ps.HostPorts...)
 	newPs.IfPortConfigs = append(make([]LocalIfPortConfigs, 0), ps.IfPortConfigs...)
+	newPs.PolicyRouteIndexes = append(make([]LocalPolicyRoute, 0), ps.PolicyRouteIndexes...)
+	newPs.MemifOptions.RxWorkers = append([]int(nil), ps.MemifOptions.RxWorkers...)
+	newPs.MemifOptions.TxWorkers = append([]int(nil), ps.MemifOptions.TxWorkers...)
 
 	return newPs
 
 }
 
-// XXX: Increment CniServerStateFileVersion when changing this struct
+// XXX: Also used by the binary state file layouts in migrate.go, do not change
 type HostPortBinding struct {
 	HostPort      uint16
 	HostIP6       net.IP `struc:"[16]byte"`
@@ -375,59 +513,3 @@ func (ps *LocalPodSpec) SetRPFVrfID(id uint32, ipFamily vpplink.IPFamily) {
 		ps.V4RPFVrfID = id
 	}
 }
-
-type SavedState struct {
-	Version    int `struc:"int32"`
-	SpecsCount int `struc:"int32,sizeof=Specs"`
-	Specs      []LocalPodSpec
-}
-
-func PersistCniServerState(podInterfaceMap map[string]LocalPodSpec, fname string) (err error) {
-	var buf bytes.Buffer
-	tmpFile := fmt.Sprintf("%s~", fname)
-	state := &SavedState{
-		Version:    CniServerStateFileVersion,
-		SpecsCount: len(podInterfaceMap),
-		Specs:      make([]LocalPodSpec, 0, len(podInterfaceMap)),
-	}
-	for _, podSpec := range podInterfaceMap {
-		state.Specs = append(state.Specs, podSpec)
-	}
-	err = struc.Pack(&buf, state)
-	if err != nil {
-		return errors.Wrap(err, "Error encoding pod data")
-	}
-
-	err = os.WriteFile(tmpFile, buf.Bytes(), 0200)
-	if err != nil {
-		return errors.Wrapf(err, "Error writing file %s", tmpFile)
-	}
-	err = os.Rename(tmpFile, fname)
-	if err != nil {
-		return errors.Wrapf(err, "Error moving file %s", tmpFile)
-	}
-	return nil
-}
-
-func LoadCniServerState(fname string) ([]LocalPodSpec, error) {
-	var state SavedState
-	data, err := os.ReadFile(fname)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil // No state to load
-		} else {
-			return nil, errors.Wrapf(err, "Error reading file %s", fname)
-		}
-	}
-	buf := bytes.NewBuffer(data)
-	err = struc.Unpack(buf, &state)
-	if err != nil {
-		return nil, errors.Wrapf(err, "Error unpacking")
-	}
-	if state.Version != CniServerStateFileVersion {
-		// When adding new versions, we need to keep loading old versions or some pods
-		// will remain disconnected forever after an upgrade
-		return nil, fmt.Errorf("unsupported save file version: %d", state.Version)
-	}
-	return state.Specs, nil
-}
@@ -0,0 +1,178 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PodStateRecordVersion is the version of the records written by the
+// PodStore. Increment it when a LocalPodSpec field needs a non-zero
+// default in records written by older versions, and convert them in
+// podStateRecord.migrate()
+const PodStateRecordVersion = 1
+
+// PodStore persists the LocalPodSpec of the pods handled by the CNI server
+type PodStore interface {
+	// Load returns all the persisted pods
+	Load() ([]LocalPodSpec, error)
+	// Put adds or replaces the record of a pod
+	Put(podSpec *LocalPodSpec) error
+	// Delete removes the record of the pod with the given key
+	Delete(key string) error
+}
+
+// podStateRecord is the content of a file of the JSONPodStore
+type podStateRecord struct {
+	Version int           `json:"version"`
+	Key     string        `json:"key"`
+	Spec    *LocalPodSpec `json:"spec"`
+}
+
+func (r *podStateRecord) migrate() error {
+	if r.Version < 1 || r.Version > PodStateRecordVersion {
+		return errors.Errorf("unsupported record version %d", r.Version)
+	}
+	if r.Spec == nil {
+		return errors.Errorf("record has no spec")
+	}
+	return nil
+}
+
+// JSONPodStore keeps one JSON file per pod in a directory. Files are
+// replaced atomically, so that a crash leaves either the previous or
+// the new record of a pod.
+type JSONPodStore struct {
+	dir string
+}
+
+func NewJSONPodStore(dir string) *JSONPodStore {
+	return &JSONPodStore{dir: dir}
+}
+
+const (
+	jsonRecordSuffix = ".json"
+	jsonTmpPrefix    = ".tmp-"
+)
+
+func (st *JSONPodStore) recordPath(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return filepath.Join(st.dir, hex.EncodeToString(h[:8])+jsonRecordSuffix)
+}
+
+func (st *JSONPodStore) syncDir() error {
+	dir, err := os.Open(st.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+func (st *JSONPodStore) Put(podSpec *LocalPodSpec) (err error) {
+	data, err := json.MarshalIndent(&podStateRecord{
+		Version: PodStateRecordVersion,
+		Key:     podSpec.Key(),
+		Spec:    podSpec,
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "Error encoding pod %s", podSpec.Key())
+	}
+	err = os.MkdirAll(st.dir, 0700)
+	if err != nil {
+		return errors.Wrapf(err, "Error creating %s", st.dir)
+	}
+	tmpFile, err := os.CreateTemp(st.dir, jsonTmpPrefix)
+	if err != nil {
+		return errors.Wrapf(err, "Error creating file in %s", st.dir)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpFile.Name())
+		}
+	}()
+	_, err = tmpFile.Write(append(data, '\n'))
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Error writing file %s", tmpFile.Name())
+	}
+	err = os.Rename(tmpFile.Name(), st.recordPath(podSpec.Key()))
+	if err != nil {
+		return errors.Wrapf(err, "Error moving file %s", tmpFile.Name())
+	}
+	return st.syncDir()
+}
+
+func (st *JSONPodStore) Delete(key string) error {
+	err := os.Remove(st.recordPath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "Error removing record of %s", key)
+	}
+	return st.syncDir()
+}
+
+// Load returns the valid records of the store, and an error listing the
+// invalid ones. It does not modify the store, so that it can be used while
+// the agent is running.
+func (st *JSONPodStore) Load() ([]LocalPodSpec, error) {
+	entries, err := os.ReadDir(st.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil // No state to load
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "Error reading %s", st.dir)
+	}
+	specs := make([]LocalPodSpec, 0, len(entries))
+	invalid := make([]string, 0)
+	for _, entry := range entries {
+		fname := filepath.Join(st.dir, entry.Name())
+		// Skips the temporary files of Put
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), jsonRecordSuffix) {
+			continue
+		}
+		var record podStateRecord
+		data, err := os.ReadFile(fname)
+		if err == nil {
+			err = json.Unmarshal(data, &record)
+		}
+		if err == nil {
+			err = record.migrate()
+		}
+		if err != nil {
+			invalid = append(invalid, entry.Name()+": "+err.Error())
+			continue
+		}
+		specs = append(specs, *record.Spec)
+	}
+	if len(invalid) != 0 {
+		return specs, errors.Errorf("invalid records %s", strings.Join(invalid, ", "))
+	}
+	return specs, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// toJSON allows comparing specs regardless of the length of their IPs
+func toJSON(specs ...LocalPodSpec) []string {
+	out := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		data, err := json.Marshal(&spec)
+		Expect(err).ToNot(HaveOccurred())
+		out = append(out, string(data))
+	}
+	return out
+}
+
+// testPodSpec returns a pod using the fields added after the binary state file
+func testPodSpec() LocalPodSpec {
+	specs, err := LoadCniServerState(stateFiles[CniServerStateFileVersion])
+	Expect(err).ToNot(HaveOccurred())
+	Expect(specs).To(HaveLen(1))
+	pod := specs[0]
+	pod.MulticastGroups = `["239.1.1.1"]`
+	pod.PodLabels = "app=web"
+	pod.EgressIP = "172.16.0.10"
+	pod.IngressBandwidth = 10000000
+	pod.IngressPolicerIndex = 13
+	pod.EnableVhostUser = true
+	pod.VhostUserSwIfIndex = 15
+	return pod
+}
+
+var _ = Describe("JSON pod store", func() {
+	var (
+		tmpDir string
+		store  *JSONPodStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "cni-store")
+		Expect(err).ToNot(HaveOccurred())
+		store = NewJSONPodStore(filepath.Join(tmpDir, "pods"))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("loads nothing when empty", func() {
+		specs, err := store.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(specs).To(BeEmpty())
+	})
+
+	It("stores one record per pod", func() {
+		pod1 := testPodSpec()
+		pod2 := pod1.Copy()
+		pod2.InterfaceName = "eth1"
+
+		Expect(store.Put(&pod1)).To(Succeed())
+		Expect(store.Put(&pod2)).To(Succeed())
+		pod1.Mtu = 9000
+		Expect(store.Put(&pod1)).To(Succeed())
+
+		specs, err := store.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(toJSON(specs...)).To(ConsistOf(toJSON(pod1, pod2)))
+
+		Expect(store.Delete(pod2.Key())).To(Succeed())
+		Expect(store.Delete(pod2.Key())).To(Succeed())
+		specs, err = store.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(toJSON(specs...)).To(ConsistOf(toJSON(pod1)))
+	})
+
+	It("skips invalid and temporary files", func() {
+		pod := testPodSpec()
+		Expect(store.Put(&pod)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(store.dir, jsonTmpPrefix+"123"), []byte("{"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(store.dir, "broken.json"), []byte("{"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(store.dir, "future.json"), []byte(`{"version": 1000, "spec": {}}`), 0600)).To(Succeed())
+
+		specs, err := store.Load()
+		Expect(err).To(MatchError(And(ContainSubstring("broken.json"), ContainSubstring("future.json"))))
+		Expect(toJSON(specs...)).To(ConsistOf(toJSON(pod)))
+	})
+})
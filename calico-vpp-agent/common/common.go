@@ -23,6 +23,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -43,9 +44,21 @@ import (
 
 var (
 	ContainerSideMacAddress, _ = net.ParseMAC("02:00:00:00:00:01")
-	VppManagerInfo             *config.VppManagerInfo
+	// vppManagerInfo is the info of the VPP programmed by the agent. It is
+	// replaced when VPP restarts or the node addresses move to another uplink
+	vppManagerInfo atomic.Pointer[config.VppManagerInfo]
 )
 
+// GetVppManagerInfo returns the info of the VPP programmed by the agent.
+// The info is replaced as a whole on updates, so it should not be modified.
+func GetVppManagerInfo() *config.VppManagerInfo {
+	return vppManagerInfo.Load()
+}
+
+func SetVppManagerInfo(info *config.VppManagerInfo) {
+	vppManagerInfo.Store(info)
+}
+
 const (
 	DefaultVRFIndex = uint32(0)
 	PuntTableID     = uint32(1)
@@ -55,6 +68,7 @@ const (
 type FelixServerIpam interface {
 	IPNetNeedsSNAT(prefix *net.IPNet) bool
 	GetPrefixIPPool(prefix *net.IPNet) *proto.IPAMPool
+	GetIPPoolPrefixes() []*net.IPNet
 }
 
 type LocalNodeSpec struct {
@@ -106,23 +120,58 @@ func CreateVppLinkInRetryLoop(socket string, log *logrus.Entry, timeout time.Dur
 	return nil, errors.Errorf("Cannot connect to VPP after 10 tries")
 }
 
-func WaitForVppManager() (*config.VppManagerInfo, error) {
+// ReadVppManagerInfo reads the info file written by vpp-manager
+func ReadVppManagerInfo() (*config.VppManagerInfo, error) {
 	vppManagerInfo := &config.VppManagerInfo{}
+	dat, err := os.ReadFile(config.VppManagerInfoFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(dat, vppManagerInfo)
+	if err != nil {
+		return nil, errors.Errorf("cannot unmarshal vpp manager info file %s", err)
+	}
+	return vppManagerInfo, nil
+}
+
+func WaitForVppManager() (*config.VppManagerInfo, error) {
 	for i := 0; i < 20; i++ {
-		dat, err := os.ReadFile(config.VppManagerInfoFile)
+		vppManagerInfo, err := ReadVppManagerInfo()
 		if err == nil {
-			err2 := json.Unmarshal(dat, vppManagerInfo)
-			if err2 != nil {
-				return nil, errors.Errorf("cannot unmarshal vpp manager info file %s", err2)
-			} else if vppManagerInfo.Status == config.Ready {
+			if vppManagerInfo.Status == config.Ready {
 				return vppManagerInfo, nil
 			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
 		}
 		time.Sleep(1 * time.Second)
 	}
 	return nil, errors.Errorf("Vpp manager not ready after 20 tries")
 }
 
+// WaitForRestartedVpp waits for vpp-manager to report a VPP other than
+// the one with pid previousPid as ready. Errors reading the info file are
+// retried, as it is rewritten while VPP restarts
+func WaitForRestartedVpp(previousPid int, timeout time.Duration) (*config.VppManagerInfo, error) {
+	var lastErr error
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		vppManagerInfo, err := ReadVppManagerInfo()
+		if err == nil {
+			if vppManagerInfo.Status == config.Ready && vppManagerInfo.VppPid != previousPid {
+				return vppManagerInfo, nil
+			}
+		} else if !os.IsNotExist(err) {
+			lastErr = err
+		}
+		time.Sleep(1 * time.Second)
+	}
+	if lastErr != nil {
+		return nil, errors.Wrapf(lastErr, "VPP not restarted after %s", timeout)
+	}
+	return nil, errors.Errorf("VPP not restarted after %s", timeout)
+}
+
 func WritePidToFile() error {
 	pid := strconv.FormatInt(int64(os.Getpid()), 10)
 	return os.WriteFile(config.CalicoVppPidFile, []byte(pid+"\n"), 0400)
@@ -40,6 +40,10 @@ const (
 	PodAdded   CalicoVppEventType = "PodAdded"
 	PodDeleted CalicoVppEventType = "PodDeleted"
 
+	PodBandwidthChanged    CalicoVppEventType = "PodBandwidthChanged"
+	PodAnnotationsChanged  CalicoVppEventType = "PodAnnotationsChanged"
+	PodSettingsNeedRestart CalicoVppEventType = "PodSettingsNeedRestart"
+
 	LocalPodAddressAdded   CalicoVppEventType = "LocalPodAddressAdded"
 	LocalPodAddressDeleted CalicoVppEventType = "LocalPodAddressDeleted"
 
@@ -68,6 +72,8 @@ const (
 	IpamPoolRemove CalicoVppEventType = "IpamPoolRemove"
 
 	WireguardPublicKeyChanged CalicoVppEventType = "WireguardPublicKeyChanged"
+
+	EgressPoliciesChanged CalicoVppEventType = "EgressPoliciesChanged"
 )
 
 var (
@@ -23,6 +23,7 @@ import (
 	vpptypes "github.com/calico-vpp/vpplink/api/v0"
 
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
 )
@@ -109,12 +110,12 @@ func (p *IpipProvider) AddConnectivity(cn *common.NodeConnectivity) error {
 
 		p.log.Infof("connectivity(add) create IPIP tunnel=%s", tunnel.String())
 
-		swIfIndex, err := p.vpp.AddIPIPTunnel(tunnel)
+		swIfIndex, err := p.vpp.AddIPIPTunnelCopyDscp(tunnel, config.GetCalicoVppQos().HasClasses())
 		if err != nil {
 			return errors.Wrapf(err, "Error adding ipip tunnel %s", tunnel.String())
 		}
 
-		err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.VppManagerInfo.GetMainSwIfIndex())
+		err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.GetVppManagerInfo().GetMainSwIfIndex())
 		if err != nil {
 			p.errorCleanup(tunnel)
 			return errors.Wrapf(err, "Error setting ipip tunnel unnumbered")
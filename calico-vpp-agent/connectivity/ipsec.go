@@ -170,7 +170,7 @@ func (p *IpsecProvider) getIPSECTunnelSpecs(nodeIP4, destNodeAddr *net.IP) (tunn
 }
 
 func (p *IpsecProvider) createIPSECTunnel(tunnel *IpsecTunnel, psk string, stack *vpplink.CleanupStack) error {
-	swIfIndex, err := p.vpp.AddIPIPTunnel(tunnel.IPIPTunnel)
+	swIfIndex, err := p.vpp.AddIPIPTunnelCopyDscp(tunnel.IPIPTunnel, config.GetCalicoVppQos().HasClasses())
 	if err != nil {
 		return errors.Wrapf(err, "Error adding ipip tunnel %s", tunnel.String())
 	} else {
@@ -186,7 +186,7 @@ func (p *IpsecProvider) createIPSECTunnel(tunnel *IpsecTunnel, psk string, stack
 		Old:  swIfIndex,
 	})
 
-	err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.VppManagerInfo.GetMainSwIfIndex())
+	err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.GetVppManagerInfo().GetMainSwIfIndex())
 	if err != nil {
 		return errors.Wrapf(err, "Error setting ipip tunnel %s unnumbered", tunnel.String())
 	}
@@ -255,7 +255,7 @@ func (p *IpsecProvider) createIPSECTunnel(tunnel *IpsecTunnel, psk string, stack
 	// Compare addresses lexicographically to select an initiator
 	if tunnel.IsInitiator() {
 		p.log.Infof("connectivity(add) IKE Set responder=%s", tunnel.String())
-		err = p.vpp.SetIKEv2Responder(tunnel.Profile(), common.VppManagerInfo.GetMainSwIfIndex(), tunnel.Dst)
+		err = p.vpp.SetIKEv2Responder(tunnel.Profile(), common.GetVppManagerInfo().GetMainSwIfIndex(), tunnel.Dst)
 		if err != nil {
 			return errors.Wrapf(err, "error configuring IPsec tunnel %s", tunnel.String())
 		}
@@ -163,7 +163,7 @@ func (p *VXLanProvider) AddConnectivity(cn *common.NodeConnectivity) error {
 		tunnel.SwIfIndex = swIfIndex
 
 		if cn.Vni == 0 {
-			err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.VppManagerInfo.GetMainSwIfIndex())
+			err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.GetVppManagerInfo().GetMainSwIfIndex())
 			if err != nil {
 				// TODO : delete tunnel
 				return errors.Wrapf(err, "Error setting vxlan tunnel unnumbered")
@@ -223,7 +223,7 @@ func (p *VXLanProvider) AddConnectivity(cn *common.NodeConnectivity) error {
 
 			p.log.Infof("connectivity(add) set vxlan interface unnumbered")
 			var uplinkToUse uint32
-			for _, intf := range common.VppManagerInfo.UplinkStatuses {
+			for _, intf := range common.GetVppManagerInfo().UplinkStatuses {
 				if intf.PhysicalNetworkName == p.server.networks[cn.Vni].PhysicalNetworkName {
 					uplinkToUse = intf.SwIfIndex
 					break
@@ -205,7 +205,7 @@ func (p *WireguardProvider) createWireguardTunnels() error {
 			tunnel.PublicKey = createdTunnel.PublicKey
 			tunnel.PrivateKey = createdTunnel.PrivateKey
 
-			err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.VppManagerInfo.GetMainSwIfIndex())
+			err = p.vpp.InterfaceSetUnnumbered(swIfIndex, common.GetVppManagerInfo().GetMainSwIfIndex())
 			if err != nil {
 				p.errorCleanup(tunnel)
 				return errors.Wrapf(err, "Error setting wireguard tunnel unnumbered")
@@ -0,0 +1,402 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+	"net"
+
+	vpptypes "github.com/calico-vpp/vpplink/api/v0"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/watchers"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+// egressGateway is the configuration of an egress IP on the node
+// currently acting as its gateway. Every node of the cluster, this one
+// included, sends the traffic of the selected pods in an IPIP tunnel to the
+// egress IP. The tunnels end in a VRF dedicated to the egress IP, and are
+// NAT44 inside interfaces. Their traffic leaving through the uplink is
+// source NATed to the egress IP, the NAT44 address of their VRF. The cnat
+// SNAT address of the node is left alone, so the traffic of the other pods
+// keeps the node address.
+type egressGateway struct {
+	egressIP net.IP
+	// vrfID is the VRF the tunnels end in
+	vrfID uint32
+	// tunnels indexed by remote node IP
+	tunnels map[string]*vpptypes.IPIPTunnel
+	// hasAddress is true once the egress IP is configured on the uplink
+	hasAddress bool
+	// hasNatAddress is true once the egress IP is the NAT44 address of vrfID
+	hasNatAddress bool
+}
+
+type EgressGatewayServer struct {
+	log *logrus.Entry
+	vpp *vpplink.VppLink
+
+	egressEventChan chan common.CalicoVppEvent
+
+	// restored is true once the gateways configured by a previous
+	// instance of the agent were looked up in VPP
+	restored bool
+	// natEnabled is true while the NAT44 plugin is enabled, i.e. while
+	// the node is the gateway of an egress IP
+	natEnabled bool
+	gateways   map[string]*egressGateway
+}
+
+func NewEgressGatewayServer(vpp *vpplink.VppLink, log *logrus.Entry) *EgressGatewayServer {
+	server := &EgressGatewayServer{
+		log:             log,
+		vpp:             vpp,
+		egressEventChan: make(chan common.CalicoVppEvent, common.ChanSize),
+		gateways:        make(map[string]*egressGateway),
+	}
+	reg := common.RegisterHandler(server.egressEventChan, "egress gateway events")
+	reg.ExpectEvents(common.EgressPoliciesChanged)
+	return server
+}
+
+func getEgressGatewayVrfTag(egressIP net.IP) string {
+	return fmt.Sprintf("egress-gw-%s", egressIP)
+}
+
+func (s *EgressGatewayServer) getOrCreateGateway(egressIP net.IP) *egressGateway {
+	gw, found := s.gateways[egressIP.String()]
+	if !found {
+		gw = &egressGateway{
+			egressIP: egressIP,
+			vrfID:    types.InvalidID,
+			tunnels:  make(map[string]*vpptypes.IPIPTunnel),
+		}
+		s.gateways[egressIP.String()] = gw
+	}
+	return gw
+}
+
+// restoreGateways looks for the VRFs, the tunnels, the NAT44 addresses and
+// the egress IPs configured by a previous instance of the agent, i.e. the
+// VRFs tagged with, the IPIP tunnels starting from and the addresses equal
+// to the egress IP of a policy
+func (s *EgressGatewayServer) restoreGateways(state *watchers.EgressPolicyState) error {
+	egressIPs := make(map[string]net.IP)
+	for _, policy := range state.Policies {
+		egressIPs[getEgressGatewayVrfTag(policy.EgressIP)] = policy.EgressIP
+		egressIPs[policy.EgressIP.String()] = policy.EgressIP
+	}
+	vrfs, err := s.vpp.ListVRFs()
+	if err != nil {
+		return errors.Wrap(err, "error listing VRFs")
+	}
+	for _, vrf := range vrfs {
+		egressIP, found := egressIPs[vrf.Name]
+		if !found || vrf.IsIP6 {
+			continue
+		}
+		s.log.Infof("egress: found gateway VRF %d for %s", vrf.VrfID, egressIP)
+		s.getOrCreateGateway(egressIP).vrfID = vrf.VrfID
+		// The NAT44 plugin is enabled with the first gateway
+		s.natEnabled = true
+	}
+	tunnels, err := s.vpp.ListIPIPTunnels()
+	if err != nil {
+		return errors.Wrap(err, "error listing IPIP tunnels")
+	}
+	for _, tunnel := range tunnels {
+		egressIP, found := egressIPs[tunnel.Src.String()]
+		if !found {
+			continue
+		}
+		s.log.Infof("egress: found gateway tunnel %s", tunnel.String())
+		s.getOrCreateGateway(egressIP).tunnels[tunnel.Dst.String()] = tunnel
+	}
+	addresses, err := s.vpp.AddrList(common.GetVppManagerInfo().GetMainSwIfIndex(), false /* isv6 */)
+	if err != nil {
+		return errors.Wrap(err, "error listing uplink addresses")
+	}
+	for _, address := range addresses {
+		egressIP, found := egressIPs[address.IPNet.IP.String()]
+		if found && common.IsFullyQualified(&address.IPNet) {
+			s.log.Infof("egress: found egress IP %s on uplink", egressIP)
+			s.getOrCreateGateway(egressIP).hasAddress = true
+		}
+	}
+	if !s.natEnabled {
+		return nil
+	}
+	natAddresses, err := s.vpp.ListNat44Addresses()
+	if err != nil {
+		return errors.Wrap(err, "error listing NAT44 addresses")
+	}
+	for _, natAddress := range natAddresses {
+		gw, found := s.gateways[natAddress.Address.String()]
+		if found && gw.vrfID == natAddress.VrfID {
+			gw.hasNatAddress = true
+		}
+	}
+	return nil
+}
+
+// enableNat enables the NAT44 plugin with the uplink as outside interface.
+// Forwarding passes the uplink traffic that is not for an egress IP
+func (s *EgressGatewayServer) enableNat() error {
+	if s.natEnabled {
+		return nil
+	}
+	s.log.Infof("egress: enabling NAT44")
+	err := s.vpp.EnableNat44()
+	if err != nil {
+		return err
+	}
+	s.natEnabled = true
+	err = s.vpp.EnableNatForwarding()
+	if err != nil {
+		return err
+	}
+	return s.vpp.AddNat44OutsideInterface(common.GetVppManagerInfo().GetMainSwIfIndex())
+}
+
+func (s *EgressGatewayServer) disableNat() {
+	if !s.natEnabled {
+		return
+	}
+	s.log.Infof("egress: disabling NAT44")
+	err := s.vpp.DisableNat44()
+	if err != nil {
+		s.log.Errorf("Error disabling NAT44: %s", err)
+		return
+	}
+	s.natEnabled = false
+}
+
+func (s *EgressGatewayServer) addTunnel(gw *egressGateway, nodeIP net.IP) error {
+	tunnel := &vpptypes.IPIPTunnel{Src: gw.egressIP, Dst: nodeIP}
+	s.log.Infof("egress(add) create IPIP tunnel=%s", tunnel.String())
+	swIfIndex, err := s.vpp.AddIPIPTunnel(tunnel)
+	if err != nil {
+		return errors.Wrapf(err, "error adding egress tunnel %s", tunnel.String())
+	}
+	stack := s.vpp.NewCleanupStack()
+	stack.Push(s.vpp.DelIPIPTunnel, tunnel)
+	err = s.vpp.SetInterfaceVRF(swIfIndex, gw.vrfID, false /* isIP6 */)
+	if err != nil {
+		stack.Execute()
+		return errors.Wrapf(err, "error setting egress tunnel %d in VRF %d", swIfIndex, gw.vrfID)
+	}
+	// The tunnels only receive, the replies are routed to the pods as usual
+	err = s.vpp.EnableInterfaceIP4(swIfIndex)
+	if err != nil {
+		stack.Execute()
+		return errors.Wrapf(err, "error enabling IPv4 on egress tunnel %d", swIfIndex)
+	}
+	err = s.vpp.AddNat44InsideInterface(swIfIndex)
+	if err != nil {
+		stack.Execute()
+		return errors.Wrapf(err, "error enabling NAT44 on egress tunnel %d", swIfIndex)
+	}
+	err = s.vpp.InterfaceAdminUp(swIfIndex)
+	if err != nil {
+		stack.Execute()
+		return errors.Wrapf(err, "error setting egress tunnel %d up", swIfIndex)
+	}
+	gw.tunnels[nodeIP.String()] = tunnel
+	return nil
+}
+
+func (s *EgressGatewayServer) delTunnel(gw *egressGateway, key string) {
+	tunnel := gw.tunnels[key]
+	s.log.Infof("egress(del) IPIP tunnel=%s", tunnel.String())
+	err := s.vpp.DelNat44InsideInterface(tunnel.SwIfIndex)
+	if err != nil {
+		s.log.Errorf("Error disabling NAT44 on egress tunnel %d: %s", tunnel.SwIfIndex, err)
+	}
+	err = s.vpp.DelIPIPTunnel(tunnel)
+	if err != nil {
+		s.log.Errorf("Error deleting egress tunnel %d: %s", tunnel.SwIfIndex, err)
+	}
+	delete(gw.tunnels, key)
+}
+
+// syncTunnels makes the gateway terminate a tunnel from every node. The
+// local pods also reach the egress IP through a tunnel from the node
+// address, so that their traffic is source NATed like the remote one
+func (s *EgressGatewayServer) syncTunnels(gw *egressGateway, nodeIPs map[string]net.IP) {
+	wanted := make(map[string]net.IP)
+	for _, nodeIP := range nodeIPs {
+		wanted[nodeIP.String()] = nodeIP
+	}
+	for key := range gw.tunnels {
+		if _, found := wanted[key]; !found {
+			s.delTunnel(gw, key)
+		}
+	}
+	for key, nodeIP := range wanted {
+		if _, found := gw.tunnels[key]; found {
+			continue
+		}
+		err := s.addTunnel(gw, nodeIP)
+		if err != nil {
+			s.log.Errorf("Error adding egress tunnel to %s: %s", nodeIP, err)
+		}
+	}
+}
+
+func (s *EgressGatewayServer) activateGateway(gw *egressGateway) error {
+	err := s.enableNat()
+	if err != nil {
+		return errors.Wrap(err, "error enabling NAT44")
+	}
+	if gw.vrfID == types.InvalidID {
+		s.log.Infof("egress(add) gateway for %s", gw.egressIP)
+		vrfID, err := s.vpp.AllocateVRF(false /* isIP6 */, getEgressGatewayVrfTag(gw.egressIP))
+		if err != nil {
+			return errors.Wrapf(err, "error allocating egress gateway VRF for %s", gw.egressIP)
+		}
+		gw.vrfID = vrfID
+		// The traffic of the tunnels and the NATed replies are routed as usual
+		err = s.vpp.AddDefaultRouteViaTable(gw.vrfID, common.DefaultVRFIndex, false /* isIP6 */)
+		if err != nil {
+			return errors.Wrapf(err, "error adding VRF %d default route", gw.vrfID)
+		}
+	}
+	if !gw.hasNatAddress {
+		err = s.vpp.AddNat44AddressInVRF(gw.egressIP, gw.vrfID)
+		if err != nil {
+			return errors.Wrapf(err, "error adding NAT44 address %s", gw.egressIP)
+		}
+		gw.hasNatAddress = true
+	}
+	if !gw.hasAddress {
+		// The egress IP terminates the tunnels and answers ARP on the uplink
+		err = s.vpp.AddInterfaceAddress(common.GetVppManagerInfo().GetMainSwIfIndex(), common.ToMaxLenCIDR(gw.egressIP))
+		if err != nil {
+			return errors.Wrapf(err, "error adding egress IP %s to uplink", gw.egressIP)
+		}
+		gw.hasAddress = true
+	}
+	common.SendEvent(common.CalicoVppEvent{
+		Type: common.LocalPodAddressAdded,
+		New:  cni.NetworkPod{ContainerIP: common.ToMaxLenCIDR(gw.egressIP), NetworkVni: 0},
+	})
+	return nil
+}
+
+func (s *EgressGatewayServer) deactivateGateway(gw *egressGateway) {
+	s.log.Infof("egress(del) gateway for %s", gw.egressIP)
+	common.SendEvent(common.CalicoVppEvent{
+		Type: common.LocalPodAddressDeleted,
+		Old:  cni.NetworkPod{ContainerIP: common.ToMaxLenCIDR(gw.egressIP), NetworkVni: 0},
+	})
+	for key := range gw.tunnels {
+		s.delTunnel(gw, key)
+	}
+	if gw.hasNatAddress {
+		err := s.vpp.DelNat44AddressInVRF(gw.egressIP, gw.vrfID)
+		if err != nil {
+			s.log.Errorf("Error deleting NAT44 address %s: %s", gw.egressIP, err)
+		}
+	}
+	if gw.vrfID != types.InvalidID {
+		err := s.vpp.DelDefaultRouteViaTable(gw.vrfID, common.DefaultVRFIndex, false /* isIP6 */)
+		if err != nil {
+			s.log.Errorf("Error deleting VRF %d default route: %s", gw.vrfID, err)
+		}
+		err = s.vpp.DelVRF(gw.vrfID, false /* isIP6 */)
+		if err != nil {
+			s.log.Errorf("Error deleting egress gateway VRF %d: %s", gw.vrfID, err)
+		}
+	}
+	if gw.hasAddress {
+		err := s.vpp.DelInterfaceAddress(common.GetVppManagerInfo().GetMainSwIfIndex(), common.ToMaxLenCIDR(gw.egressIP))
+		if err != nil {
+			s.log.Errorf("Error removing egress IP %s from uplink: %s", gw.egressIP, err)
+		}
+	}
+}
+
+// handleEgressPolicies makes this node the gateway of the egress IPs
+// assigned to it, and stops being the gateway of the other ones
+func (s *EgressGatewayServer) handleEgressPolicies(state *watchers.EgressPolicyState) {
+	if !s.restored {
+		err := s.restoreGateways(state)
+		if err != nil {
+			s.log.Errorf("Error restoring egress gateways: %s", err)
+		}
+		s.restored = true
+	}
+	activeIPs := make(map[string]net.IP)
+	for _, policy := range state.Policies {
+		if state.GetActiveGateway(policy) == *config.NodeName {
+			activeIPs[policy.EgressIP.String()] = policy.EgressIP
+		}
+	}
+	for key, gw := range s.gateways {
+		if _, found := activeIPs[key]; !found {
+			s.deactivateGateway(gw)
+			delete(s.gateways, key)
+		}
+	}
+	for key, activeIP := range activeIPs {
+		gw := s.getOrCreateGateway(activeIP)
+		err := s.activateGateway(gw)
+		if err != nil {
+			s.log.Error(err)
+			s.deactivateGateway(gw)
+			delete(s.gateways, key)
+			continue
+		}
+		s.syncTunnels(gw, state.NodeIPs)
+	}
+	if len(s.gateways) == 0 {
+		s.disableNat()
+	}
+}
+
+// ServeEgressGateway configures the egress gateways on the node. The
+// configuration is kept in VPP when the agent stops, and restored by the
+// next instance of the agent
+func (s *EgressGatewayServer) ServeEgressGateway(t *tomb.Tomb) error {
+	if !*config.GetCalicoVppFeatureGates().EgressGatewayEnabled {
+		<-t.Dying()
+		return nil
+	}
+	for {
+		select {
+		case <-t.Dying():
+			s.log.Warn("Egress gateway server asked to stop")
+			return nil
+		case evt := <-s.egressEventChan:
+			switch evt.Type {
+			case common.EgressPoliciesChanged:
+				state, ok := evt.New.(*watchers.EgressPolicyState)
+				if !ok {
+					s.log.Errorf("evt.New is not a (*watchers.EgressPolicyState) %v", evt.New)
+					continue
+				}
+				s.handleEgressPolicies(state)
+			}
+		}
+	}
+}
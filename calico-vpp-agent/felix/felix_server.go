@@ -351,7 +351,7 @@ func (s *Server) handleFelixServerEvents(evt common.CalicoVppEvent) error {
 		if !ok {
 			return fmt.Errorf("evt.New is not a (*storage.LocalPodSpec) %v", evt.New)
 		}
-		swIfIndex := podSpec.TunTapSwIfIndex
+		swIfIndex := podSpec.GetPrimarySwIfIndex()
 		if swIfIndex == vpplink.InvalidID {
 			swIfIndex = podSpec.MemifSwIfIndex
 		}
@@ -460,11 +460,20 @@ func (s *Server) ServeFelix(t *tomb.Tomb) error {
 	if err != nil {
 		return errors.Wrap(err, "Error in createFailSafePolicies")
 	}
+	go func() {
+		// Unblock Accept when the agent stops while felix is disconnected
+		<-t.Dying()
+		listener.Close()
+	}()
 	for {
 		s.state = StateDisconnected
 		// Accept only one connection
 		conn, err := listener.Accept()
 		if err != nil {
+			if !t.Alive() {
+				s.log.Warn("Felix server exiting")
+				return nil
+			}
 			return errors.Wrap(err, "cannot accept felix client connection")
 		}
 		s.log.Infof("Accepted connection from felix")
@@ -1440,6 +1449,22 @@ func (s *Server) GetPrefixIPPool(prefix *net.IPNet) *proto.IPAMPool {
 	return nil
 }
 
+// GetIPPoolPrefixes returns the CIDRs of all the IP pools
+func (s *Server) GetIPPoolPrefixes() []*net.IPNet {
+	s.ippoolLock.RLock()
+	defer s.ippoolLock.RUnlock()
+	prefixes := make([]*net.IPNet, 0, len(s.ippoolmap))
+	for _, pool := range s.ippoolmap {
+		_, poolCIDR, err := net.ParseCIDR(pool.GetCidr())
+		if err != nil {
+			s.log.Warnf("Couldn't parse pool CIDR %s: %v", pool.GetCidr(), err)
+			continue
+		}
+		prefixes = append(prefixes, poolCIDR)
+	}
+	return prefixes
+}
+
 func (s *Server) IPNetNeedsSNAT(prefix *net.IPNet) bool {
 	pool := s.GetPrefixIPPool(prefix)
 	if pool == nil {
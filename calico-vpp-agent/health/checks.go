@@ -0,0 +1,212 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apipb "github.com/osrg/gobgp/v3/api"
+	bgpserver "github.com/osrg/gobgp/v3/pkg/server"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.fd.io/govpp/adapter/statsclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+)
+
+// GetReadyUplinks returns the uplinks from the vpp manager info file, once
+// vpp-manager reported them ready. Each call reads its own copy of the info,
+// as it is updated by other goroutines while the checks run.
+func GetReadyUplinks() (map[string]config.UplinkStatus, error) {
+	info, err := common.ReadVppManagerInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.Status != config.Ready {
+		return nil, errors.Errorf("vpp-manager is %s", info.Status)
+	}
+	return info.UplinkStatuses, nil
+}
+
+// VppChecker checks VPP over its own API and stats segment connections,
+// reconnecting them when VPP restarts
+type VppChecker struct {
+	log        *logrus.Entry
+	getUplinks func() (map[string]config.UplinkStatus, error)
+
+	apiLock sync.Mutex
+	vpp     *vpplink.VppLink
+
+	statsLock           sync.Mutex
+	sc                  *statsclient.StatsClient
+	lastHeartbeat       float64
+	lastHeartbeatChange time.Time
+}
+
+func NewVppChecker(getUplinks func() (map[string]config.UplinkStatus, error), log *logrus.Entry) *VppChecker {
+	return &VppChecker{
+		log:        log,
+		getUplinks: getUplinks,
+	}
+}
+
+// AddChecks adds the VPP API and stats segment liveness checks, and the
+// uplinks readiness check to a health server
+func (c *VppChecker) AddChecks(s *Server) {
+	s.AddLivenessCheck("vpp-api", c.CheckVppAPI)
+	s.AddLivenessCheck("stats-segment", c.CheckStatsSegment)
+	s.AddReadinessCheck("uplinks", c.CheckUplinks)
+}
+
+func (c *VppChecker) withVpp(f func(vpp *vpplink.VppLink) error) (err error) {
+	c.apiLock.Lock()
+	defer c.apiLock.Unlock()
+	if c.vpp == nil {
+		c.vpp, err = vpplink.NewVppLink(config.VppAPISocket, c.log.WithFields(logrus.Fields{"component": "vpp-api"}))
+		if err != nil {
+			c.vpp = nil
+			return errors.Wrap(err, "cannot connect to VPP")
+		}
+	}
+	err = f(c.vpp)
+	if err != nil {
+		c.vpp.Close()
+		c.vpp = nil
+	}
+	return err
+}
+
+// CheckVppAPI sends a control ping to VPP
+func (c *VppChecker) CheckVppAPI() error {
+	return c.withVpp(func(vpp *vpplink.VppLink) error {
+		return vpp.ControlPing()
+	})
+}
+
+// CheckStatsSegment fails when VPP did not update the stats
+// segment heartbeat for longer than StatsMaxAge
+func (c *VppChecker) CheckStatsSegment() error {
+	c.statsLock.Lock()
+	defer c.statsLock.Unlock()
+	if c.sc == nil {
+		sc := statsclient.NewStatsClient("")
+		err := sc.Connect()
+		if err != nil {
+			return errors.Wrap(err, "cannot connect to the stats segment")
+		}
+		c.sc = sc
+	}
+	heartbeat, err := vpplink.GetHeartbeat(c.sc)
+	if err != nil {
+		_ = c.sc.Disconnect()
+		c.sc = nil
+		return err
+	}
+	if heartbeat != c.lastHeartbeat || c.lastHeartbeatChange.IsZero() {
+		c.lastHeartbeat = heartbeat
+		c.lastHeartbeatChange = time.Now()
+		return nil
+	}
+	maxAge := *config.GetCalicoVppHealth().StatsMaxAge
+	if age := time.Since(c.lastHeartbeatChange); age > maxAge {
+		return errors.Errorf("stats segment not updated for %s", age.Round(time.Second))
+	}
+	return nil
+}
+
+// isCheckedUplink tells whether the link of an uplink must be up. With
+// uplink failover, only the uplink holding the node addresses has to be
+func isCheckedUplink(uplink config.UplinkStatus) bool {
+	return uplink.HasNodeAddresses || (!uplink.IsMain && !uplink.IsStandby)
+}
+
+// CheckUplinks fails when the link of an uplink is down in VPP
+func (c *VppChecker) CheckUplinks() error {
+	uplinks, err := c.getUplinks()
+	if err != nil {
+		return err
+	}
+	return c.withVpp(func(vpp *vpplink.VppLink) error {
+		down := make([]string, 0)
+		for name, uplink := range uplinks {
+			if !isCheckedUplink(uplink) {
+				continue
+			}
+			details, err := vpp.GetInterfaceDetails(uplink.SwIfIndex)
+			if err != nil {
+				return errors.Wrapf(err, "cannot get uplink %s", name)
+			} else if details == nil {
+				return errors.Errorf("uplink %s not found", name)
+			}
+			if !details.IsLinkUp {
+				down = append(down, name)
+			}
+		}
+		if len(down) > 0 {
+			sort.Strings(down)
+			return errors.Errorf("uplink link down: %s", strings.Join(down, ", "))
+		}
+		return nil
+	})
+}
+
+// GrpcCheck calls the grpc health service of the server listening on socket
+func GrpcCheck(socket string) CheckFunc {
+	return func() error {
+		conn, err := grpc.NewClient("unix://"+socket,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "cannot connect to %s", socket)
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), *config.GetCalicoVppHealth().CheckTimeout)
+		defer cancel()
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return errors.Wrapf(err, "health check on %s failed", socket)
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return errors.Errorf("%s is %s", socket, resp.Status)
+		}
+		return nil
+	}
+}
+
+// BGPCheck fails until the BGP server is started
+func BGPCheck(bgpServer *bgpserver.BgpServer) CheckFunc {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), *config.GetCalicoVppHealth().CheckTimeout)
+		defer cancel()
+		resp, err := bgpServer.GetBgp(ctx, &apipb.GetBgpRequest{})
+		if err != nil {
+			return errors.Wrap(err, "cannot get BGP server")
+		}
+		if resp.Global == nil || resp.Global.Asn == 0 {
+			return errors.Errorf("BGP server not started")
+		}
+		return nil
+	}
+}
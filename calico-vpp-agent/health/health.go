@@ -0,0 +1,298 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	prometheusExporter "github.com/orijtech/prometheus-go-metrics-exporter"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+// CheckFunc returns an error when the checked component is unhealthy
+type CheckFunc func() error
+
+type check struct {
+	name      string
+	liveness  bool
+	fn        CheckFunc
+	isRunning atomic.Bool
+	err       error
+	duration  time.Duration
+}
+
+// Server runs liveness and readiness checks periodically, and
+// serves their last results on /healthz and /readyz
+type Server struct {
+	log            *logrus.Entry
+	component      string
+	listenEndpoint string
+	conf           *config.CalicoVppHealthConfigType
+	checks         []*check
+	lock           sync.RWMutex
+	lastRun        time.Time
+}
+
+// ReadyFlag is a readiness check passing once Set is called
+type ReadyFlag struct {
+	ready atomic.Bool
+	name  string
+}
+
+func (f *ReadyFlag) Set() {
+	f.ready.Store(true)
+}
+
+func (f *ReadyFlag) Reset() {
+	f.ready.Store(false)
+}
+
+func (f *ReadyFlag) check() error {
+	if !f.ready.Load() {
+		return errors.Errorf("%s not ready yet", f.name)
+	}
+	return nil
+}
+
+func NewHealthServer(component string, listenEndpoint string, log *logrus.Entry) *Server {
+	return &Server{
+		log:            log,
+		component:      component,
+		listenEndpoint: listenEndpoint,
+		conf:           config.GetCalicoVppHealth(),
+	}
+}
+
+func (s *Server) addCheck(name string, liveness bool, fn CheckFunc) {
+	if !s.conf.IsCheckEnabled(name) {
+		s.log.Infof("Health check %s disabled", name)
+		return
+	}
+	s.checks = append(s.checks, &check{name: name, liveness: liveness, fn: fn})
+}
+
+// AddLivenessCheck adds a check failing both /healthz and /readyz
+func (s *Server) AddLivenessCheck(name string, fn CheckFunc) {
+	s.addCheck(name, true, fn)
+}
+
+// AddReadinessCheck adds a check failing /readyz only
+func (s *Server) AddReadinessCheck(name string, fn CheckFunc) {
+	s.addCheck(name, false, fn)
+}
+
+// AddReadyFlag adds a readiness check failing until the returned flag is set
+func (s *Server) AddReadyFlag(name string) *ReadyFlag {
+	flag := &ReadyFlag{name: name}
+	s.AddReadinessCheck(name, flag.check)
+	return flag
+}
+
+// runCheck runs a check with the configured timeout. A check still running
+// from a previous round is not started again, and fails
+func (s *Server) runCheck(c *check) (err error) {
+	if !c.isRunning.CompareAndSwap(false, true) {
+		return errors.Errorf("previous check still running")
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		defer c.isRunning.Store(false)
+		errChan <- c.fn()
+	}()
+	select {
+	case err = <-errChan:
+		return err
+	case <-time.After(*s.conf.CheckTimeout):
+		return errors.Errorf("timed out after %s", *s.conf.CheckTimeout)
+	}
+}
+
+func (s *Server) runChecks() {
+	var wg sync.WaitGroup
+	for _, c := range s.checks {
+		wg.Add(1)
+		go func(c *check) {
+			defer wg.Done()
+			start := time.Now()
+			err := s.runCheck(c)
+			duration := time.Since(start)
+			s.lock.Lock()
+			if err != nil && c.err == nil {
+				s.log.Warnf("Health check %s failed: %v", c.name, err)
+			} else if err == nil && c.err != nil {
+				s.log.Infof("Health check %s passed", c.name)
+			}
+			c.err = err
+			c.duration = duration
+			s.lock.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	s.lock.Lock()
+	s.lastRun = time.Now()
+	s.lock.Unlock()
+}
+
+// status returns whether the liveness (and readiness) checks pass,
+// with a line per check
+func (s *Server) status(readiness bool) (ok bool, lines []string) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	ok = true
+	if s.lastRun.IsZero() {
+		if readiness {
+			return false, []string{"[-]checks not run yet"}
+		}
+		return true, []string{"[+]checks not run yet"}
+	}
+	if age := time.Since(s.lastRun); age > 3**s.conf.CheckInterval {
+		return false, []string{fmt.Sprintf("[-]checks not run for %s", age.Round(time.Second))}
+	}
+	for _, c := range s.checks {
+		if !readiness && !c.liveness {
+			continue
+		}
+		if c.err != nil {
+			ok = false
+			lines = append(lines, fmt.Sprintf("[-]%s failed: %v", c.name, c.err))
+		} else {
+			lines = append(lines, fmt.Sprintf("[+]%s ok", c.name))
+		}
+	}
+	return ok, lines
+}
+
+func (s *Server) handler(readiness bool, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok, lines := s.status(readiness)
+		if ok {
+			lines = append(lines, name+" check passed")
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			lines = append(lines, name+" check failed")
+		}
+		_, _ = w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// exportMetrics exports the results of the checks as gauges
+func (s *Server) exportMetrics(pe *prometheusExporter.Exporter) error {
+	status := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        "health_check_status",
+			Description: "1 if the health check passes, 0 otherwise",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys: []*metricspb.LabelKey{
+				{Key: "component", Description: "Calico/VPP component running the check"},
+				{Key: "check", Description: "Name of the check"},
+				{Key: "probe", Description: "liveness or readiness"},
+			},
+		},
+	}
+	duration := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        "health_check_duration_seconds",
+			Unit:        "seconds",
+			Description: "duration of the last run of the health check",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys:   status.MetricDescriptor.LabelKeys,
+		},
+	}
+	s.lock.RLock()
+	for _, c := range s.checks {
+		probe := "readiness"
+		if c.liveness {
+			probe = "liveness"
+		}
+		labels := []*metricspb.LabelValue{{Value: s.component}, {Value: c.name}, {Value: probe}}
+		status.Timeseries = append(status.Timeseries, &metricspb.TimeSeries{
+			LabelValues: labels,
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_DoubleValue{DoubleValue: boolToFloat(c.err == nil)}}},
+		})
+		duration.Timeseries = append(duration.Timeseries, &metricspb.TimeSeries{
+			LabelValues: labels,
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_DoubleValue{DoubleValue: c.duration.Seconds()}}},
+		})
+	}
+	s.lock.RUnlock()
+	for _, metric := range []*metricspb.Metric{status, duration} {
+		// empty timeseries prevents exporter from updating
+		if len(metric.Timeseries) == 0 {
+			metric.Timeseries = []*metricspb.TimeSeries{{}}
+		}
+		err := pe.ExportMetric(context.Background(), nil, nil, metric)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) ServeHealth(t *tomb.Tomb) error {
+	if !*s.conf.Enabled {
+		return nil
+	}
+	pe, err := prometheusExporter.New(prometheusExporter.Options{})
+	if err != nil {
+		return errors.Wrap(err, "failed to create health metrics exporter")
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", s.handler(false /* readiness */, "liveness"))
+	mux.Handle("/readyz", s.handler(true /* readiness */, "readiness"))
+	mux.Handle("/metrics", pe)
+	server := &http.Server{Addr: s.listenEndpoint, Handler: mux}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			s.log.Errorf("Failed to serve health endpoints: %s", err)
+		}
+	}()
+	s.log.Infof("Serving health endpoints on %s", s.listenEndpoint)
+
+	ticker := time.NewTicker(*s.conf.CheckInterval)
+	defer ticker.Stop()
+	for {
+		s.runChecks()
+		err = s.exportMetrics(pe)
+		if err != nil {
+			s.log.Errorf("Error exporting health metrics: %v", err)
+		}
+		select {
+		case <-t.Dying():
+			s.log.Warn("Health server asked to stop")
+			return server.Close()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+func TestHealth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Health Suite")
+}
+
+func get(s *Server, path string) (int, string) {
+	readiness := path == "/readyz"
+	name := "liveness"
+	if readiness {
+		name = "readiness"
+	}
+	recorder := httptest.NewRecorder()
+	s.handler(readiness, name)(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder.Code, recorder.Body.String()
+}
+
+var _ = Describe("Health server", func() {
+	var s *Server
+
+	BeforeEach(func() {
+		timeout := 50 * time.Millisecond
+		conf := config.GetCalicoVppHealth()
+		conf.CheckTimeout = &timeout
+		conf.DisabledChecks = []string{"bgp"}
+		Expect(conf.Validate()).To(Succeed())
+		s = NewHealthServer("test", "", logrus.NewEntry(logrus.New()))
+	})
+
+	It("Separates liveness and readiness", func() {
+		s.AddLivenessCheck("vpp-api", func() error { return nil })
+		s.AddReadinessCheck("uplinks", func() error { return errors.New("uplink link down: eth0") })
+
+		code, _ := get(s, "/healthz")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get(s, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+		s.runChecks()
+		code, body := get(s, "/healthz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(Equal("[+]vpp-api ok\nliveness check passed\n"))
+		code, body = get(s, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(ContainSubstring("[-]uplinks failed: uplink link down: eth0"))
+	})
+
+	It("Times out wedged checks", func() {
+		block := make(chan struct{})
+		defer close(block)
+		s.AddLivenessCheck("vpp-api", func() error { <-block; return nil })
+
+		s.runChecks()
+		code, body := get(s, "/healthz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(ContainSubstring("timed out"))
+
+		s.runChecks()
+		_, body = get(s, "/healthz")
+		Expect(body).To(ContainSubstring("previous check still running"))
+	})
+
+	It("Fails when checks are stale", func() {
+		s.AddLivenessCheck("vpp-api", func() error { return nil })
+		s.runChecks()
+		s.lastRun = time.Now().Add(-time.Hour)
+		code, _ := get(s, "/healthz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("Skips disabled checks and waits for ready flags", func() {
+		s.AddReadinessCheck("bgp", func() error { return errors.New("BGP server not started") })
+		felixReady := s.AddReadyFlag("felix")
+		Expect(s.checks).To(HaveLen(1))
+
+		s.runChecks()
+		code, _ := get(s, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		felixReady.Set()
+		s.runChecks()
+		code, _ = get(s, "/readyz")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+})
+
+var _ = Describe("VPP checks", func() {
+	It("Only checks the active uplink with failover", func() {
+		Expect(isCheckedUplink(config.UplinkStatus{IsMain: true, HasNodeAddresses: true})).To(BeTrue())
+		Expect(isCheckedUplink(config.UplinkStatus{})).To(BeTrue())
+		// Failed over to a standby
+		Expect(isCheckedUplink(config.UplinkStatus{IsMain: true})).To(BeFalse())
+		Expect(isCheckedUplink(config.UplinkStatus{IsStandby: true, HasNodeAddresses: true})).To(BeTrue())
+		Expect(isCheckedUplink(config.UplinkStatus{IsStandby: true})).To(BeFalse())
+	})
+})
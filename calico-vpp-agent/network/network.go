@@ -66,6 +66,27 @@ type NetworkSpec struct {
 	VNI                 int    `json:"vni"`
 	Range               string `json:"range"`
 	PhysicalNetworkName string `json:"physicalNetworkName"`
+	// SRIOV gives the pods of this network a VF of an uplink instead
+	// of a tun or memif interface
+	SRIOV *SRIOVSpec `json:"sriov,omitempty"`
+}
+
+// SRIOVSpec describes the VFs given to the pods of a network
+type SRIOVSpec struct {
+	// Uplink is the name of the avf uplink whose VFs are used
+	Uplink string `json:"uplink"`
+	// VLAN is the VLAN of the first VF given to a pod, each VF gets
+	// its own VLAN so that pod traffic always goes through VPP
+	VLAN int `json:"vlan"`
+	// Trust allows the pod to change the VF MAC address
+	Trust bool `json:"trust,omitempty"`
+	// SpoofCheck drops packets sent from another MAC than the VF one,
+	// defaults to true
+	SpoofCheck *bool `json:"spoofCheck,omitempty"`
+}
+
+func (s *SRIOVSpec) GetSpoofCheck() bool {
+	return s.SpoofCheck == nil || *s.SpoofCheck
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -74,6 +95,15 @@ func (in *Network) DeepCopyInto(out *Network) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
+	if in.Spec.SRIOV != nil {
+		in, out := &in.Spec.SRIOV, &out.Spec.SRIOV
+		*out = new(SRIOVSpec)
+		**out = **in
+		if (*in).SpoofCheck != nil {
+			(*out).SpoofCheck = new(bool)
+			*(*out).SpoofCheck = *(*in).SpoofCheck
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Network.
@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
@@ -48,6 +49,16 @@ type Server struct {
 	lock                     sync.Mutex
 }
 
+// agentRecoveryTime is the time the agent took to reprogram
+// the last restarted VPP, in nanoseconds
+var agentRecoveryTime atomic.Int64
+
+// SetAgentRecoveryTime records the time between VPP stopping and
+// the agent having reprogrammed the restarted VPP
+func SetAgentRecoveryTime(d time.Duration) {
+	agentRecoveryTime.Store(int64(d))
+}
+
 func (s *Server) recordMetrics(t *tomb.Tomb) {
 	pe, err := prometheusExporter.New(prometheusExporter.Options{})
 	if err != nil {
@@ -55,15 +66,19 @@ func (s *Server) recordMetrics(t *tomb.Tomb) {
 	}
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", pe)
+	// The server is closed when the agent reprograms a restarted VPP,
+	// so that the next one can listen
+	server := &http.Server{
+		Addr:    config.GetCalicoVppInitialConfig().PrometheusListenEndpoint,
+		Handler: mux,
+	}
 	go func() {
-		err := http.ListenAndServe(
-			config.GetCalicoVppInitialConfig().PrometheusListenEndpoint,
-			mux,
-		)
-		if err != nil {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
 			s.log.Fatalf("Failed to serve metrics: %s", err)
 		}
 	}()
+	defer server.Close()
 	ticker := time.NewTicker(*config.GetCalicoVppInitialConfig().PrometheusRecordMetricInterval)
 	for ; t.Alive(); <-ticker.C {
 		ifNames, dumpStats, _ := vpplink.GetInterfaceStats(s.sc)
@@ -79,6 +94,22 @@ func (s *Server) recordMetrics(t *tomb.Tomb) {
 				}
 			}
 		}
+		err := s.exportPolicerDrops(pe)
+		if err != nil {
+			s.log.Errorf("exportPolicerDrops errored with %s", err)
+		}
+		err = s.exportQosClassCounters(pe)
+		if err != nil {
+			s.log.Errorf("exportQosClassCounters errored with %s", err)
+		}
+		err = s.exportVppRestarts(pe)
+		if err != nil {
+			s.log.Errorf("exportVppRestarts errored with %s", err)
+		}
+		err = s.exportUplinkStatus(pe)
+		if err != nil {
+			s.log.Errorf("exportUplinkStatus errored with %s", err)
+		}
 	}
 	ticker.Stop()
 }
@@ -184,6 +215,215 @@ func (s *Server) exportMetricsForStat(names []string, sta adapter.StatEntry, ifN
 	return nil
 }
 
+// exportPolicerDrops exports the packets dropped by the policers enforcing
+// the pod bandwidth annotations
+func (s *Server) exportPolicerDrops(pe *prometheusExporter.Exporter) error {
+	drops, err := vpplink.GetPolicerDropStats(s.sc)
+	if err != nil {
+		return err
+	}
+	for _, direction := range []string{"ingress", "egress"} {
+		name := direction + "_policer_drops"
+		metric := &metricspb.Metric{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name:        name,
+				Unit:        "packets",
+				Description: "number of packets dropped by the pod " + direction + " bandwidth limit",
+				LabelKeys: []*metricspb.LabelKey{
+					{Key: "worker", Description: "VPP worker index"},
+					{Key: "namespace", Description: "Kubernetes namespace of the pod"},
+					{Key: "podName", Description: "Name of the pod"},
+					{Key: "nameInPod", Description: "Name of interface in the pod"},
+				},
+			},
+			Timeseries: []*metricspb.TimeSeries{},
+		}
+		s.lock.Lock()
+		for _, pod := range s.podInterfacesByKey {
+			policerIndex := pod.EgressPolicerIndex
+			if direction == "ingress" {
+				policerIndex = pod.IngressPolicerIndex
+			}
+			if policerIndex == vpplink.InvalidID {
+				continue
+			}
+			// policer counters are summed over the workers
+			metric.Timeseries = append(metric.Timeseries, getTimeSeries(0, pod, float64(drops[policerIndex])))
+		}
+		s.lock.Unlock()
+		// empty timeseries prevents exporter from updating
+		if len(metric.Timeseries) == 0 {
+			metric.Timeseries = []*metricspb.TimeSeries{{}}
+		}
+		err := pe.ExportMetric(context.Background(), nil, nil, metric)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportQosClassCounters exports the traffic sent by the pods of each QoS
+// class, as counted by the egress policers marking it
+func (s *Server) exportQosClassCounters(pe *prometheusExporter.Exporter) error {
+	counters, err := vpplink.GetPolicerConformStats(s.sc)
+	if err != nil {
+		return err
+	}
+	classCounters := make(map[string]adapter.CombinedCounter)
+	s.lock.Lock()
+	for _, pod := range s.podInterfacesByKey {
+		if pod.QosClass == "" || pod.EgressPolicerIndex == vpplink.InvalidID {
+			continue
+		}
+		counter := classCounters[pod.QosClass]
+		counter[0] += counters[pod.EgressPolicerIndex].Packets()
+		counter[1] += counters[pod.EgressPolicerIndex].Bytes()
+		classCounters[pod.QosClass] = counter
+	}
+	s.lock.Unlock()
+	for k, unit := range units {
+		metric := &metricspb.Metric{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name:        "qos_class_tx_" + unit,
+				Unit:        unit,
+				Description: "total number of " + unit + " sent by the pods of the QoS class",
+				LabelKeys: []*metricspb.LabelKey{
+					{Key: "qosClass", Description: "QoS class of the pods"},
+				},
+			},
+			Timeseries: []*metricspb.TimeSeries{},
+		}
+		for class, counter := range classCounters {
+			metric.Timeseries = append(metric.Timeseries, &metricspb.TimeSeries{
+				LabelValues: []*metricspb.LabelValue{{Value: class}},
+				Points: []*metricspb.Point{
+					{
+						Value: &metricspb.Point_DoubleValue{
+							DoubleValue: float64(counter[k]),
+						},
+					},
+				},
+			})
+		}
+		// empty timeseries prevents exporter from updating
+		if len(metric.Timeseries) == 0 {
+			metric.Timeseries = []*metricspb.TimeSeries{{}}
+		}
+		err := pe.ExportMetric(context.Background(), nil, nil, metric)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportVppRestarts exports the number of VPP restarts, and the time
+// vpp-manager and the agent took to recover from the last one
+func (s *Server) exportVppRestarts(pe *prometheusExporter.Exporter) error {
+	restarts := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        "vpp_restarts_total",
+			Description: "number of times VPP was restarted after exiting unexpectedly",
+			Type:        metricspb.MetricDescriptor_CUMULATIVE_INT64,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			Points: []*metricspb.Point{{Value: &metricspb.Point_Int64Value{
+				Int64Value: int64(common.GetVppManagerInfo().VppRestarts),
+			}}},
+		}},
+	}
+	recovery := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        "vpp_restart_recovery_seconds",
+			Unit:        "seconds",
+			Description: "time to recover from the last VPP restart",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys: []*metricspb.LabelKey{
+				{Key: "component", Description: "vpp-manager for VPP being ready, agent for VPP being reprogrammed"},
+			},
+		},
+		Timeseries: []*metricspb.TimeSeries{},
+	}
+	for component, d := range map[string]time.Duration{
+		"vpp-manager": common.GetVppManagerInfo().LastVppRecovery,
+		"agent":       time.Duration(agentRecoveryTime.Load()),
+	} {
+		recovery.Timeseries = append(recovery.Timeseries, &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{{Value: component}},
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_DoubleValue{DoubleValue: d.Seconds()}}},
+		})
+	}
+	for _, metric := range []*metricspb.Metric{restarts, recovery} {
+		err := pe.ExportMetric(context.Background(), nil, nil, metric)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// exportUplinkStatus exports the link state of the uplinks, which one
+// holds the node addresses, and the number of failovers between them
+func (s *Server) exportUplinkStatus(pe *prometheusExporter.Exporter) error {
+	uplinkLabel := []*metricspb.LabelKey{{Key: "uplink", Description: "uplink interface name"}}
+	linkUp := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        "uplink_link_up",
+			Description: "whether the uplink link is up in VPP",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys:   uplinkLabel,
+		},
+		Timeseries: []*metricspb.TimeSeries{},
+	}
+	hasNodeAddresses := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        "uplink_has_node_addresses",
+			Description: "whether the uplink holds the node addresses",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys:   uplinkLabel,
+		},
+		Timeseries: []*metricspb.TimeSeries{},
+	}
+	for name, uplinkStatus := range common.GetVppManagerInfo().UplinkStatuses {
+		for metric, value := range map[*metricspb.Metric]bool{
+			linkUp:           uplinkStatus.IsUp,
+			hasNodeAddresses: uplinkStatus.HasNodeAddresses,
+		} {
+			metric.Timeseries = append(metric.Timeseries, &metricspb.TimeSeries{
+				LabelValues: []*metricspb.LabelValue{{Value: name}},
+				Points:      []*metricspb.Point{{Value: &metricspb.Point_DoubleValue{DoubleValue: boolToFloat(value)}}},
+			})
+		}
+	}
+	failovers := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        "uplink_failovers_total",
+			Description: "number of times the node addresses moved between uplinks",
+			Type:        metricspb.MetricDescriptor_CUMULATIVE_INT64,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			Points: []*metricspb.Point{{Value: &metricspb.Point_Int64Value{
+				Int64Value: int64(common.GetVppManagerInfo().UplinkFailovers),
+			}}},
+		}},
+	}
+	for _, metric := range []*metricspb.Metric{linkUp, hasNodeAddresses, failovers} {
+		err := pe.ExportMetric(context.Background(), nil, nil, metric)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func getTimeSeries(worker int, pod storage.LocalPodSpec, value float64) *metricspb.TimeSeries {
 	return &metricspb.TimeSeries{
 		LabelValues: []*metricspb.LabelValue{
@@ -212,7 +452,7 @@ func NewPrometheusServer(vpp *vpplink.VppLink, l *logrus.Entry) *Server {
 	}
 	if *config.GetCalicoVppFeatureGates().PrometheusEnabled {
 		reg := common.RegisterHandler(server.channel, "prometheus events")
-		reg.ExpectEvents(common.PodAdded, common.PodDeleted)
+		reg.ExpectEvents(common.PodAdded, common.PodDeleted, common.PodBandwidthChanged)
 	}
 	return server
 }
@@ -228,17 +468,17 @@ func (s *Server) ServePrometheus(t *tomb.Tomb) error {
 			/* Note: we will only receive events we ask for when registering the chan */
 			evt := <-s.channel
 			switch evt.Type {
-			case common.PodAdded:
+			case common.PodAdded, common.PodBandwidthChanged:
 				podSpec, ok := evt.New.(*storage.LocalPodSpec)
 				if !ok {
 					s.log.Errorf("evt.New is not a *storage.LocalPodSpec %v", evt.New)
 					continue
 				}
 				s.lock.Lock()
-				if podSpec.TunTapSwIfIndex == vpplink.InvalidSwIfIndex {
+				if podSpec.GetPrimarySwIfIndex() == vpplink.InvalidSwIfIndex {
 					s.podInterfacesBySwifIndex[podSpec.MemifSwIfIndex] = *podSpec
 				} else {
-					s.podInterfacesBySwifIndex[podSpec.TunTapSwIfIndex] = *podSpec
+					s.podInterfacesBySwifIndex[podSpec.GetPrimarySwIfIndex()] = *podSpec
 				}
 				s.podInterfacesByKey[podSpec.Key()] = *podSpec
 				s.lock.Unlock()
@@ -251,10 +491,10 @@ func (s *Server) ServePrometheus(t *tomb.Tomb) error {
 				}
 				initialPod := s.podInterfacesByKey[podSpec.Key()]
 				delete(s.podInterfacesByKey, initialPod.Key())
-				if podSpec.TunTapSwIfIndex == vpplink.InvalidSwIfIndex {
+				if podSpec.GetPrimarySwIfIndex() == vpplink.InvalidSwIfIndex {
 					delete(s.podInterfacesBySwifIndex, initialPod.MemifSwIfIndex)
 				} else {
-					delete(s.podInterfacesBySwifIndex, initialPod.TunTapSwIfIndex)
+					delete(s.podInterfacesBySwifIndex, initialPod.GetPrimarySwIfIndex())
 				}
 				s.lock.Unlock()
 			}
@@ -266,6 +506,10 @@ func (s *Server) ServePrometheus(t *tomb.Tomb) error {
 		return errors.Wrap(err, "could not connect statsclient")
 	}
 	s.recordMetrics(t)
+	err = s.sc.Disconnect()
+	if err != nil {
+		s.log.Warnf("Error disconnecting statsclient: %v", err)
+	}
 
 	s.log.Warn("Prometheus Server returned")
 
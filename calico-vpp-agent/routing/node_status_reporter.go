@@ -0,0 +1,486 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	bgpapi "github.com/osrg/gobgp/v3/api"
+	"github.com/pkg/errors"
+	calicov3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	calicov3cli "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+	"github.com/projectcalico/calico/libcalico-go/lib/watch"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"gopkg.in/tomb.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+const (
+	nodeStatusTickInterval = 1 * time.Second
+)
+
+type nodeStatusRequest struct {
+	name       string
+	classes    []calicov3.NodeStatusClassType
+	period     time.Duration
+	nextUpdate time.Time
+}
+
+// NodeStatusReporter publishes the GoBGP state of this node in the
+// CalicoNodeStatus objects that select it, so that `calicoctl node status`
+// works on VPP nodes.
+type NodeStatusReporter struct {
+	log                  *logrus.Entry
+	server               *Server
+	clientv3             calicov3cli.Interface
+	watcher              watch.Interface
+	currentWatchRevision string
+
+	requests map[string]*nodeStatusRequest
+	// peerStates tracks the last seen state of each peer, so that
+	// transitions are logged and their cause reported
+	peerStates map[string]*peerState
+}
+
+// peerState is what the reporter remembers of a BGP peer
+type peerState struct {
+	sessionState       bgpapi.PeerState_SessionState
+	notificationsRecvd uint64
+	notificationsSent  uint64
+	flops              uint32
+	lastError          *BGPPeerError
+}
+
+// BGPPeerError is the last error of a BGP peer, as reported in the
+// BGPPeerErrorsAnnotation
+type BGPPeerError struct {
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// updatePeerState records the current state of a peer, and returns the
+// error explaining its last transition if any. GoBGP does not keep the
+// error that brought a session down, so it is inferred from the
+// NOTIFICATION messages exchanged and the session flops
+func updatePeerState(previous *peerState, peer *bgpapi.Peer, now time.Time) *peerState {
+	state := &peerState{
+		sessionState:       peer.GetState().GetSessionState(),
+		notificationsRecvd: peer.GetState().GetMessages().GetReceived().GetNotification(),
+		notificationsSent:  peer.GetState().GetMessages().GetSent().GetNotification(),
+		flops:              peer.GetState().GetFlops(),
+	}
+	if previous == nil {
+		return state
+	}
+	state.lastError = previous.lastError
+	var reason string
+	switch {
+	case state.notificationsRecvd > previous.notificationsRecvd:
+		reason = "NOTIFICATION received from peer"
+	case state.notificationsSent > previous.notificationsSent:
+		reason = "NOTIFICATION sent to peer"
+	case state.flops > previous.flops:
+		reason = "session lost"
+	case previous.sessionState != bgpapi.PeerState_ESTABLISHED &&
+		(state.sessionState < previous.sessionState ||
+			(previous.sessionState == bgpapi.PeerState_CONNECT && state.sessionState == bgpapi.PeerState_ACTIVE)):
+		// The session went back in the FSM before being established
+		reason = "cannot open session"
+	}
+	if reason != "" {
+		state.lastError = &BGPPeerError{
+			Error: fmt.Sprintf("%s (%s to %s, flops=%d)", reason, previous.sessionState, state.sessionState, state.flops),
+			Time:  now,
+		}
+	}
+	return state
+}
+
+func NewNodeStatusReporter(server *Server, clientv3 calicov3cli.Interface, log *logrus.Entry) *NodeStatusReporter {
+	return &NodeStatusReporter{
+		log:        log,
+		server:     server,
+		clientv3:   clientv3,
+		requests:   make(map[string]*nodeStatusRequest),
+		peerStates: make(map[string]*peerState),
+	}
+}
+
+// ReportNodeStatus watches CalicoNodeStatus objects for this node and
+// updates their status at the requested interval
+func (r *NodeStatusReporter) ReportNodeStatus(t *tomb.Tomb) error {
+	r.log.Infof("CalicoNodeStatus reporter starts")
+	ticker := time.NewTicker(nodeStatusTickInterval)
+	defer ticker.Stop()
+	for t.Alive() {
+		r.currentWatchRevision = ""
+		err := r.resyncAndCreateWatcher()
+		if err != nil {
+			r.log.Error(err)
+			goto restart
+		}
+		for {
+			select {
+			case <-t.Dying():
+				r.log.Infof("CalicoNodeStatus reporter asked to stop")
+				r.cleanExistingWatcher()
+				return nil
+			case <-ticker.C:
+				r.updateDueStatuses()
+			case event, ok := <-r.watcher.ResultChan():
+				if !ok {
+					err := r.resyncAndCreateWatcher()
+					if err != nil {
+						r.log.Error(err)
+						goto restart
+					}
+					continue
+				}
+				switch event.Type {
+				case watch.EventType(api.WatchError):
+					r.log.Debug("CalicoNodeStatus watch returned, restarting...")
+					goto restart
+				case watch.EventType(api.WatchAdded), watch.EventType(api.WatchModified):
+					status, ok := event.Object.(*calicov3.CalicoNodeStatus)
+					if !ok || status == nil {
+						r.log.Errorf("CalicoNodeStatus watch returned an unexpected object %v", event.Object)
+						continue
+					}
+					r.addOrUpdateRequest(status)
+				case watch.EventType(api.WatchDeleted):
+					status, ok := event.Previous.(*calicov3.CalicoNodeStatus)
+					if !ok || status == nil {
+						r.log.Errorf("CalicoNodeStatus watch returned an unexpected object %v", event.Previous)
+						continue
+					}
+					delete(r.requests, status.Name)
+				}
+			}
+		}
+
+	restart:
+		r.log.Debug("restarting CalicoNodeStatus watcher...")
+		r.cleanExistingWatcher()
+		time.Sleep(2 * time.Second)
+	}
+	r.log.Warn("CalicoNodeStatus reporter stopped")
+	return nil
+}
+
+func (r *NodeStatusReporter) addOrUpdateRequest(status *calicov3.CalicoNodeStatus) {
+	if status.Spec.Node != *config.NodeName {
+		delete(r.requests, status.Name)
+		return
+	}
+	var period time.Duration
+	if status.Spec.UpdatePeriodSeconds != nil {
+		period = time.Duration(*status.Spec.UpdatePeriodSeconds) * time.Second
+	}
+	req, found := r.requests[status.Name]
+	if !found {
+		req = &nodeStatusRequest{name: status.Name}
+		r.requests[status.Name] = req
+	}
+	if !found || req.period != period {
+		/* report immediately when the request is new or its period changed */
+		req.nextUpdate = time.Now()
+	}
+	req.classes = status.Spec.Classes
+	req.period = period
+}
+
+func (r *NodeStatusReporter) updateDueStatuses() {
+	now := time.Now()
+	for _, req := range r.requests {
+		// A zero period means the status should not be updated
+		if req.period == 0 || now.Before(req.nextUpdate) {
+			continue
+		}
+		req.nextUpdate = now.Add(req.period)
+		err := r.updateStatus(req)
+		if err != nil {
+			r.log.WithError(err).Warnf("Failed to update CalicoNodeStatus %s", req.name)
+		}
+	}
+}
+
+func (r *NodeStatusReporter) updateStatus(req *nodeStatusRequest) error {
+	nodeStatus, err := r.clientv3.CalicoNodeStatus().Get(context.Background(), req.name, options.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cannot get CalicoNodeStatus %s", req.name)
+	}
+	status := calicov3.CalicoNodeStatusStatus{LastUpdated: metav1.Now()}
+	var peerErrors map[string]*BGPPeerError
+	for _, class := range req.classes {
+		switch class {
+		case calicov3.NodeStatusClassTypeAgent:
+			status.Agent, err = r.getAgentStatus()
+		case calicov3.NodeStatusClassTypeBGP:
+			status.BGP, peerErrors, err = r.getBGPStatus()
+		case calicov3.NodeStatusClassTypeRoutes:
+			status.Routes, err = r.getRouteStatus()
+		default:
+			r.log.Warnf("Unknown CalicoNodeStatus class %s", class)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot get %s status", class)
+		}
+	}
+	nodeStatus.Status = status
+	if peerErrors != nil {
+		err = setPeerErrorsAnnotation(&nodeStatus.ObjectMeta, peerErrors)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = r.clientv3.CalicoNodeStatus().Update(context.Background(), nodeStatus, options.SetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cannot update CalicoNodeStatus %s", req.name)
+	}
+	return nil
+}
+
+func (r *NodeStatusReporter) getAgentStatus() (calicov3.CalicoNodeAgentStatus, error) {
+	agentStatus := calicov3.CalicoNodeAgentStatus{}
+	nodeIP4, nodeIP6 := common.GetBGPSpecAddresses(r.server.getOurBGPSpec())
+	daemonStatus := calicov3.BGPDaemonStatus{State: calicov3.BGPDaemonStateNotReady}
+
+	startTime := r.server.bgpStartTime.Load()
+	if startTime != 0 {
+		bgp, err := r.server.BGPServer.GetBgp(context.Background(), &bgpapi.GetBgpRequest{})
+		if err != nil {
+			return agentStatus, errors.Wrap(err, "cannot get GoBGP global state")
+		}
+		daemonStatus.State = calicov3.BGPDaemonStateReady
+		daemonStatus.Version = "GoBGP"
+		daemonStatus.RouterID = bgp.GetGlobal().GetRouterId()
+		daemonStatus.LastBootTime = time.Unix(0, startTime).Format(time.RFC3339)
+	}
+	if nodeIP4 != nil {
+		agentStatus.BIRDV4 = daemonStatus
+	}
+	if nodeIP6 != nil {
+		agentStatus.BIRDV6 = daemonStatus
+	}
+	return agentStatus, nil
+}
+
+func getSessionState(state bgpapi.PeerState_SessionState) calicov3.BGPSessionState {
+	switch state {
+	case bgpapi.PeerState_CONNECT:
+		return calicov3.BGPSessionStateConnect
+	case bgpapi.PeerState_ACTIVE:
+		return calicov3.BGPSessionStateActive
+	case bgpapi.PeerState_OPENSENT:
+		return calicov3.BGPSessionStateOpenSent
+	case bgpapi.PeerState_OPENCONFIRM:
+		return calicov3.BGPSessionStateOpenConfirm
+	case bgpapi.PeerState_ESTABLISHED:
+		return calicov3.BGPSessionStateEstablished
+	default:
+		return calicov3.BGPSessionStateIdle
+	}
+}
+
+// setPeerErrorsAnnotation sets the last error of the peers that had one
+// in the annotations of a CalicoNodeStatus
+func setPeerErrorsAnnotation(meta *metav1.ObjectMeta, peerErrors map[string]*BGPPeerError) error {
+	if len(peerErrors) == 0 {
+		delete(meta.Annotations, config.BGPPeerErrorsAnnotation)
+		return nil
+	}
+	data, err := json.Marshal(peerErrors)
+	if err != nil {
+		return errors.Wrap(err, "cannot encode BGP peer errors")
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[config.BGPPeerErrorsAnnotation] = string(data)
+	return nil
+}
+
+// getBGPStatus returns the BGP status of the node, and the last error
+// of the peers that had one
+func (r *NodeStatusReporter) getBGPStatus() (calicov3.CalicoNodeBGPStatus, map[string]*BGPPeerError, error) {
+	bgpStatus := calicov3.CalicoNodeBGPStatus{}
+	peerErrors := make(map[string]*BGPPeerError)
+	if r.server.bgpStartTime.Load() == 0 {
+		r.peerStates = make(map[string]*peerState)
+		return bgpStatus, peerErrors, nil
+	}
+	peerStates := make(map[string]*peerState)
+	now := time.Now()
+	err := r.server.BGPServer.ListPeer(context.Background(), &bgpapi.ListPeerRequest{}, func(peer *bgpapi.Peer) {
+		state := peer.GetState().GetSessionState()
+		address := peer.GetConf().GetNeighborAddress()
+		nodePeer := calicov3.CalicoNodePeer{
+			PeerIP: address,
+			Type:   calicov3.BGPPeerType(peer.GetConf().GetDescription()),
+			State:  getSessionState(state),
+		}
+		since := peer.GetTimers().GetState().GetDowntime()
+		if state == bgpapi.PeerState_ESTABLISHED {
+			since = peer.GetTimers().GetState().GetUptime()
+		}
+		if since != nil && since.GetSeconds() != 0 {
+			nodePeer.Since = since.AsTime().Format(time.RFC3339)
+		}
+		previous := r.peerStates[address]
+		current := updatePeerState(previous, peer, now)
+		if previous != nil && previous.sessionState != state {
+			r.log.Infof("BGP peer %s went from %s to %s (flops=%d)", address, previous.sessionState, state, current.flops)
+		}
+		if current.lastError != nil {
+			peerErrors[address] = current.lastError
+		}
+		peerStates[address] = current
+
+		isEstablished := state == bgpapi.PeerState_ESTABLISHED
+		ip := net.ParseIP(address)
+		if ip != nil && ip.To4() == nil {
+			bgpStatus.PeersV6 = append(bgpStatus.PeersV6, nodePeer)
+			if isEstablished {
+				bgpStatus.NumberEstablishedV6++
+			} else {
+				bgpStatus.NumberNotEstablishedV6++
+			}
+		} else {
+			bgpStatus.PeersV4 = append(bgpStatus.PeersV4, nodePeer)
+			if isEstablished {
+				bgpStatus.NumberEstablishedV4++
+			} else {
+				bgpStatus.NumberNotEstablishedV4++
+			}
+		}
+	})
+	if err != nil {
+		return bgpStatus, nil, errors.Wrap(err, "cannot list BGP peers")
+	}
+	// Peers removed from GoBGP are forgotten
+	r.peerStates = peerStates
+	return bgpStatus, peerErrors, nil
+}
+
+func (r *NodeStatusReporter) getPeerTypes() (map[string]calicov3.BGPPeerType, error) {
+	peerTypes := make(map[string]calicov3.BGPPeerType)
+	err := r.server.BGPServer.ListPeer(context.Background(), &bgpapi.ListPeerRequest{}, func(peer *bgpapi.Peer) {
+		peerTypes[peer.GetConf().GetNeighborAddress()] = calicov3.BGPPeerType(peer.GetConf().GetDescription())
+	})
+	return peerTypes, err
+}
+
+func (r *NodeStatusReporter) listRoutes(family *bgpapi.Family, peerTypes map[string]calicov3.BGPPeerType) ([]calicov3.CalicoNodeRoute, error) {
+	routes := make([]calicov3.CalicoNodeRoute, 0)
+	err := r.server.BGPServer.ListPath(context.Background(), &bgpapi.ListPathRequest{
+		TableType: bgpapi.TableType_GLOBAL,
+		Family:    family,
+		SortType:  bgpapi.ListPathRequest_PREFIX,
+	}, func(destination *bgpapi.Destination) {
+		for _, path := range destination.GetPaths() {
+			if !path.GetBest() || path.GetIsWithdraw() {
+				continue
+			}
+			route := calicov3.CalicoNodeRoute{
+				Type:        calicov3.RouteTypeRIB,
+				Destination: destination.GetPrefix(),
+				Gateway:     r.server.getNexthop(path),
+			}
+			neighborIP := path.GetNeighborIp()
+			if neighborIP == "" || neighborIP == "<nil>" {
+				/* Locally originated path, advertised to our peers */
+				route.LearnedFrom.SourceType = calicov3.RouteSourceTypeDirect
+			} else {
+				route.LearnedFrom.SourceType = calicov3.RouteSourceTypeBGPPeer
+				route.LearnedFrom.PeerIP = neighborIP
+				if peerTypes[neighborIP] == calicov3.BGPPeerTypeNodeMesh {
+					route.LearnedFrom.SourceType = calicov3.RouteSourceTypeNodeMesh
+				}
+			}
+			routes = append(routes, route)
+		}
+	})
+	return routes, err
+}
+
+func (r *NodeStatusReporter) getRouteStatus() (calicov3.CalicoNodeBGPRouteStatus, error) {
+	routeStatus := calicov3.CalicoNodeBGPRouteStatus{}
+	if r.server.bgpStartTime.Load() == 0 {
+		return routeStatus, nil
+	}
+	peerTypes, err := r.getPeerTypes()
+	if err != nil {
+		return routeStatus, errors.Wrap(err, "cannot list BGP peers")
+	}
+	nodeIP4, nodeIP6 := common.GetBGPSpecAddresses(r.server.getOurBGPSpec())
+	if nodeIP4 != nil {
+		routeStatus.RoutesV4, err = r.listRoutes(&common.BgpFamilyUnicastIPv4, peerTypes)
+		if err != nil {
+			return routeStatus, errors.Wrap(err, "cannot list ipv4 routes")
+		}
+	}
+	if nodeIP6 != nil {
+		routeStatus.RoutesV6, err = r.listRoutes(&common.BgpFamilyUnicastIPv6, peerTypes)
+		if err != nil {
+			return routeStatus, errors.Wrap(err, "cannot list ipv6 routes")
+		}
+	}
+	return routeStatus, nil
+}
+
+func (r *NodeStatusReporter) resyncAndCreateWatcher() error {
+	if r.currentWatchRevision == "" {
+		r.log.Debugf("Reconciliating CalicoNodeStatuses...")
+		statuses, err := r.clientv3.CalicoNodeStatus().List(context.Background(), options.ListOptions{
+			ResourceVersion: r.currentWatchRevision,
+		})
+		if err != nil {
+			return errors.Wrap(err, "cannot list CalicoNodeStatuses")
+		}
+		r.requests = make(map[string]*nodeStatusRequest)
+		for i := range statuses.Items {
+			r.addOrUpdateRequest(&statuses.Items[i])
+		}
+		r.currentWatchRevision = statuses.ResourceVersion
+	}
+	r.cleanExistingWatcher()
+	watcher, err := r.clientv3.CalicoNodeStatus().Watch(
+		context.Background(),
+		options.ListOptions{ResourceVersion: r.currentWatchRevision},
+	)
+	if err != nil {
+		return err
+	}
+	r.watcher = watcher
+	return nil
+}
+
+func (r *NodeStatusReporter) cleanExistingWatcher() {
+	if r.watcher != nil {
+		r.watcher.Stop()
+		r.log.Debug("Stopped watcher")
+		r.watcher = nil
+	}
+}
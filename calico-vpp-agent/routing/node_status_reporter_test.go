@@ -0,0 +1,146 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	bgpapi "github.com/osrg/gobgp/v3/api"
+	bgpserver "github.com/osrg/gobgp/v3/pkg/server"
+	calicov3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+func TestRouting(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Routing Suite")
+}
+
+func testPeer(state bgpapi.PeerState_SessionState, notificationsRecvd, notificationsSent uint64, flops uint32) *bgpapi.Peer {
+	return &bgpapi.Peer{State: &bgpapi.PeerState{
+		SessionState: state,
+		Flops:        flops,
+		Messages: &bgpapi.Messages{
+			Received: &bgpapi.Message{Notification: notificationsRecvd},
+			Sent:     &bgpapi.Message{Notification: notificationsSent},
+		},
+	}}
+}
+
+var _ = Describe("Node status reporter", func() {
+	log := logrus.NewEntry(logrus.New())
+
+	It("Infers the last error of peers", func() {
+		now := time.Now()
+		state := updatePeerState(nil, testPeer(bgpapi.PeerState_IDLE, 0, 0, 0), now)
+		Expect(state.lastError).To(BeNil())
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_CONNECT, 0, 0, 0), now)
+		Expect(state.lastError).To(BeNil())
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_ACTIVE, 0, 0, 0), now)
+		Expect(state.lastError).ToNot(BeNil())
+		Expect(state.lastError.Error).To(HavePrefix("cannot open session"))
+
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_ESTABLISHED, 0, 0, 0), now)
+		Expect(state.lastError.Error).To(HavePrefix("cannot open session"))
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_ACTIVE, 1, 0, 1), now.Add(time.Second))
+		Expect(state.lastError.Error).To(HavePrefix("NOTIFICATION received from peer"))
+		Expect(state.lastError.Time).To(Equal(now.Add(time.Second)))
+
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_ESTABLISHED, 1, 0, 1), now)
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_IDLE, 1, 1, 2), now)
+		Expect(state.lastError.Error).To(HavePrefix("NOTIFICATION sent to peer"))
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_ESTABLISHED, 1, 1, 2), now)
+		state = updatePeerState(state, testPeer(bgpapi.PeerState_IDLE, 1, 1, 3), now)
+		Expect(state.lastError.Error).To(HavePrefix("session lost"))
+	})
+
+	It("Annotates the status with peer errors", func() {
+		meta := &metav1.ObjectMeta{}
+		Expect(setPeerErrorsAnnotation(meta, map[string]*BGPPeerError{
+			"192.0.2.1": {Error: "session lost"},
+		})).To(Succeed())
+		peerErrors := make(map[string]*BGPPeerError)
+		Expect(json.Unmarshal([]byte(meta.Annotations[config.BGPPeerErrorsAnnotation]), &peerErrors)).To(Succeed())
+		Expect(peerErrors["192.0.2.1"].Error).To(Equal("session lost"))
+
+		Expect(setPeerErrorsAnnotation(meta, map[string]*BGPPeerError{})).To(Succeed())
+		Expect(meta.Annotations).ToNot(HaveKey(config.BGPPeerErrorsAnnotation))
+	})
+
+	It("Tracks the requests for this node", func() {
+		*config.NodeName = "node1"
+		r := NewNodeStatusReporter(&Server{}, nil, log)
+		period := uint32(10)
+		status := &calicov3.CalicoNodeStatus{
+			ObjectMeta: metav1.ObjectMeta{Name: "status"},
+			Spec: calicov3.CalicoNodeStatusSpec{
+				Node:                "node1",
+				Classes:             []calicov3.NodeStatusClassType{calicov3.NodeStatusClassTypeBGP},
+				UpdatePeriodSeconds: &period,
+			},
+		}
+		r.addOrUpdateRequest(status)
+		Expect(r.requests).To(HaveKey("status"))
+		Expect(r.requests["status"].period).To(Equal(10 * time.Second))
+
+		status.Spec.Node = "node2"
+		r.addOrUpdateRequest(status)
+		Expect(r.requests).ToNot(HaveKey("status"))
+	})
+
+	It("Forgets removed peers", func() {
+		bgpServer := bgpserver.NewBgpServer()
+		go bgpServer.Serve()
+		defer bgpServer.Stop()
+		Expect(bgpServer.StartBgp(context.Background(), &bgpapi.StartBgpRequest{Global: &bgpapi.Global{
+			Asn:        65000,
+			RouterId:   "192.0.2.254",
+			ListenPort: -1,
+		}})).To(Succeed())
+		server := &Server{BGPServer: bgpServer, log: log}
+		server.bgpStartTime.Store(time.Now().UnixNano())
+		r := NewNodeStatusReporter(server, nil, log)
+
+		for _, address := range []string{"192.0.2.1", "2001:db8::1"} {
+			Expect(bgpServer.AddPeer(context.Background(), &bgpapi.AddPeerRequest{Peer: &bgpapi.Peer{
+				Conf: &bgpapi.PeerConf{NeighborAddress: address, PeerAsn: 65001},
+				// Do not try to connect to the peer
+				State: &bgpapi.PeerState{AdminState: bgpapi.PeerState_DOWN},
+			}})).To(Succeed())
+		}
+		status, _, err := r.getBGPStatus()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.PeersV4).To(HaveLen(1))
+		Expect(status.PeersV6).To(HaveLen(1))
+		Expect(status.NumberNotEstablishedV4).To(Equal(1))
+		Expect(r.peerStates).To(HaveLen(2))
+
+		Expect(bgpServer.DeletePeer(context.Background(), &bgpapi.DeletePeerRequest{Address: "192.0.2.1"})).To(Succeed())
+		status, _, err = r.getBGPStatus()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.PeersV4).To(BeEmpty())
+		Expect(r.peerStates).To(HaveLen(1))
+		Expect(r.peerStates).To(HaveKey("2001:db8::1"))
+	})
+})
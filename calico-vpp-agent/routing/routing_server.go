@@ -19,6 +19,9 @@ package routing
 import (
 	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	bgpapi "github.com/osrg/gobgp/v3/api"
 	bgpserver "github.com/osrg/gobgp/v3/pkg/server"
@@ -58,6 +61,13 @@ type Server struct {
 	routingServerEventChan chan common.CalicoVppEvent
 
 	nodeBGPSpec *common.LocalNodeSpec
+	// nodeBGPSpecLock protects nodeBGPSpec from readers outside
+	// the routing server, like the node status reporter
+	nodeBGPSpecLock sync.RWMutex
+
+	// bgpStartTime is the unix time (in ns) at which GoBGP was
+	// last started, zero when it is stopped
+	bgpStartTime atomic.Int64
 }
 
 func (s *Server) SetBGPConf(bgpConf *calicov3.BGPConfigurationSpec) {
@@ -72,9 +82,17 @@ func (s *Server) SetBGPConf(bgpConf *calicov3.BGPConfigurationSpec) {
 }
 
 func (s *Server) SetOurBGPSpec(nodeBGPSpec *common.LocalNodeSpec) {
+	s.nodeBGPSpecLock.Lock()
+	defer s.nodeBGPSpecLock.Unlock()
 	s.nodeBGPSpec = nodeBGPSpec
 }
 
+func (s *Server) getOurBGPSpec() *common.LocalNodeSpec {
+	s.nodeBGPSpecLock.RLock()
+	defer s.nodeBGPSpecLock.RUnlock()
+	return s.nodeBGPSpec
+}
+
 func NewRoutingServer(vpp *vpplink.VppLink, bgpServer *bgpserver.BgpServer, log *logrus.Entry) *Server {
 	server := Server{
 		log:             log,
@@ -123,6 +141,7 @@ func (s *Server) ServeRouting(t *tomb.Tomb) (err error) {
 		if err != nil {
 			return errors.Wrap(err, "failed to start BGP server")
 		}
+		s.bgpStartTime.Store(time.Now().UnixNano())
 
 		nodeIP4, nodeIP6 := common.GetBGPSpecAddresses(s.nodeBGPSpec)
 		if nodeIP4 != nil {
@@ -156,6 +175,7 @@ func (s *Server) ServeRouting(t *tomb.Tomb) (err error) {
 			return errors.Wrap(err, "also failed to clean up routes which we injected")
 		}
 
+		s.bgpStartTime.Store(0)
 		err = s.BGPServer.StopBgp(context.Background(), &bgpapi.StopBgpRequest{})
 		if err != nil {
 			s.log.Errorf("failed to stop BGP server: %s", err)
@@ -73,6 +73,17 @@ func (s *IpamCacheStub) IPNetNeedsSNAT(prefix *net.IPNet) bool {
 	return false
 }
 
+func (s *IpamCacheStub) GetIPPoolPrefixes() []*net.IPNet {
+	prefixes := make([]*net.IPNet, 0, len(s.ipPools))
+	for _, ipPool := range s.ipPools {
+		_, poolCIDR, err := net.ParseCIDR(ipPool.Pool.Cidr)
+		if err == nil {
+			prefixes = append(prefixes, poolCIDR)
+		}
+	}
+	return prefixes
+}
+
 func (s *IpamCacheStub) AddPrefixIPPool(prefix *net.IPNet, ipPool *proto.IPAMPoolUpdate) {
 	s.ipPools[prefix.String()] = ipPool
 }
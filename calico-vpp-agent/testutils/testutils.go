@@ -26,6 +26,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -194,6 +195,32 @@ func AssertRPFRoutes(vpp *vpplink.VppLink, vrfID uint32, swifindex uint32, ipAdd
 
 }
 
+// AssertInterfaceFeatures checks that the given feature arc nodes are enabled on the interface,
+// e.g. the policers or the ABF policies attached to it, as they have no binary API to dump them
+// per interface
+func AssertInterfaceFeatures(swIfIndex uint32, interfaceDescriptiveName string, vpp *vpplink.VppLink, features ...string) {
+	featuresStr, err := vpp.RunCli(fmt.Sprintf("sh interface %d features", swIfIndex))
+	Expect(err).ToNot(HaveOccurred(),
+		fmt.Sprintf("failed to get %s's configured features", interfaceDescriptiveName))
+	featuresStr = strings.ToLower(featuresStr)
+	for _, feature := range features {
+		Expect(featuresStr).To(ContainSubstring(feature), fmt.Sprintf("%s is not enabled on %s, "+
+			"configured features arcs are %s", feature, interfaceDescriptiveName, featuresStr))
+	}
+}
+
+// AssertMRoute checks that the multicast group is forwarded to the interface in the VRF
+func AssertMRoute(vpp *vpplink.VppLink, vrfID uint32, group string, swIfIndex uint32) {
+	details, err := vpp.GetInterfaceDetails(swIfIndex)
+	Expect(err).ToNot(HaveOccurred(), "Failed to get interface %d details", swIfIndex)
+	// No binary API wrapper to dump mroutes -> using VPE
+	mfibStr, err := vpp.RunCli(fmt.Sprintf("show ip mfib table %d %s", vrfID, group))
+	Expect(err).ToNot(HaveOccurred(), "Failed to get multicast routes of VRF %d", vrfID)
+	Expect(mfibStr).To(ContainSubstring(group), "No multicast route for %s in VRF %d", group, vrfID)
+	Expect(mfibStr).To(MatchRegexp(`%s:\s*Forward`, regexp.QuoteMeta(details.Name)),
+		"Multicast route for %s in VRF %d does not forward to %s", group, vrfID, details.Name)
+}
+
 // CreatePod creates docker container that will be used as pod for CNI testing
 func CreatePod() {
 	// docker container cleanup (failed test that didn't properly clean up docker containers?)
@@ -425,7 +452,7 @@ func AssertUnnumberedInterface(swIfIndex uint32, interfaceDescriptiveName string
 		fmt.Sprintf("can't get unnumbered details of %s", interfaceDescriptiveName))
 	Expect(unnumberedDetails).ToNot(BeEmpty(), "can't find unnumbered interface")
 	Expect(unnumberedDetails[0].IPSwIfIndex).To(Equal(
-		interface_types.InterfaceIndex(common.VppManagerInfo.GetMainSwIfIndex())),
+		interface_types.InterfaceIndex(common.GetVppManagerInfo().GetMainSwIfIndex())),
 		fmt.Sprintf("Unnumberred %s doesn't get IP address from expected interface", interfaceDescriptiveName))
 }
 
@@ -0,0 +1,306 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchers
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+const (
+	// EgressGatewayAnnotation is set on namespaces whose pods should
+	// leave the cluster with a dedicated source address
+	EgressGatewayAnnotation = "cni.projectcalico.org/vppEgressGateway"
+	// calicoNodeIPv4Annotation is set by calico/node on kubernetes nodes
+	calicoNodeIPv4Annotation = "projectcalico.org/IPv4Address"
+)
+
+// EgressPolicySpec is the content of the EgressGatewayAnnotation
+type EgressPolicySpec struct {
+	EgressIP     string   `json:"egressIP"`
+	GatewayNodes []string `json:"gatewayNodes"`
+	PodSelector  string   `json:"podSelector,omitempty"`
+}
+
+type EgressPolicy struct {
+	Namespace    string
+	EgressIP     net.IP
+	GatewayNodes []string
+	PodSelector  string
+}
+
+// Matches returns whether a pod with the given labels is selected by the policy
+func (p *EgressPolicy) Matches(podLabels map[string]string) bool {
+	if p.PodSelector == "" {
+		return true
+	}
+	selector, err := labels.Parse(p.PodSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(podLabels))
+}
+
+// EgressPolicyState is the snapshot sent with EgressPoliciesChanged events
+type EgressPolicyState struct {
+	// Policies indexed by namespace
+	Policies map[string]*EgressPolicy
+	// NodeIPs are the IPv4 addresses of the nodes in the cluster
+	NodeIPs map[string]net.IP
+	// ReadyNodes contains the nodes with a Ready condition
+	ReadyNodes map[string]bool
+}
+
+// GetActiveGateways assigns each egress IP to the first ready node of its
+// gateway list. A node can be the gateway of several egress IPs. It returns
+// the gateway node indexed by egress IP
+func (st *EgressPolicyState) GetActiveGateways() map[string]string {
+	// When several namespaces use the same egress IP, the gateway list of
+	// the first namespace is used, so that all the nodes compute the same
+	// assignment
+	namespaces := make([]string, 0, len(st.Policies))
+	for namespace := range st.Policies {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	activeGateways := make(map[string]string)
+	assigned := make(map[string]bool)
+	for _, namespace := range namespaces {
+		policy := st.Policies[namespace]
+		if assigned[policy.EgressIP.String()] {
+			continue
+		}
+		assigned[policy.EgressIP.String()] = true
+		for _, nodeName := range policy.GatewayNodes {
+			if st.ReadyNodes[nodeName] {
+				activeGateways[policy.EgressIP.String()] = nodeName
+				break
+			}
+		}
+	}
+	return activeGateways
+}
+
+// GetActiveGateway returns the node currently acting as the gateway of the
+// policy egress IP, or "" if none of its gateway nodes is available
+func (st *EgressPolicyState) GetActiveGateway(policy *EgressPolicy) string {
+	return st.GetActiveGateways()[policy.EgressIP.String()]
+}
+
+// GetPodPolicy returns the policy applying to a pod, or nil
+func (st *EgressPolicyState) GetPodPolicy(namespace string, podLabels map[string]string) *EgressPolicy {
+	policy, found := st.Policies[namespace]
+	if !found || !policy.Matches(podLabels) {
+		return nil
+	}
+	return policy
+}
+
+type EgressPolicyWatcher struct {
+	log *logrus.Entry
+
+	namespaceStore    cache.Store
+	nodeStore         cache.Store
+	namespaceInformer cache.Controller
+	nodeInformer      cache.Controller
+
+	changed chan struct{}
+	state   *EgressPolicyState
+}
+
+func parseEgressPolicy(namespace string, value string) (*EgressPolicy, error) {
+	var spec EgressPolicySpec
+	err := json.Unmarshal([]byte(value), &spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse '%s' as JSON", value)
+	}
+	egressIP := net.ParseIP(spec.EgressIP)
+	if egressIP == nil || egressIP.To4() == nil {
+		return nil, errors.Errorf("egressIP %s is not an IPv4 address", spec.EgressIP)
+	}
+	if len(spec.GatewayNodes) == 0 {
+		return nil, errors.Errorf("no gatewayNodes specified")
+	}
+	if spec.PodSelector != "" {
+		_, err = labels.Parse(spec.PodSelector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid podSelector %s", spec.PodSelector)
+		}
+	}
+	return &EgressPolicy{
+		Namespace:    namespace,
+		EgressIP:     egressIP.To4(),
+		GatewayNodes: spec.GatewayNodes,
+		PodSelector:  spec.PodSelector,
+	}, nil
+}
+
+func getNodeIP4(node *v1.Node) net.IP {
+	if ip, _, err := net.ParseCIDR(node.Annotations[calicoNodeIPv4Annotation]); err == nil {
+		return ip.To4()
+	}
+	for _, address := range node.Status.Addresses {
+		if address.Type != v1.NodeInternalIP {
+			continue
+		}
+		if ip := net.ParseIP(address.Address); ip != nil && ip.To4() != nil {
+			return ip.To4()
+		}
+	}
+	return nil
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (w *EgressPolicyWatcher) notify() {
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (w *EgressPolicyWatcher) computeState() *EgressPolicyState {
+	state := &EgressPolicyState{
+		Policies:   make(map[string]*EgressPolicy),
+		NodeIPs:    make(map[string]net.IP),
+		ReadyNodes: make(map[string]bool),
+	}
+	for _, obj := range w.namespaceStore.List() {
+		namespace, ok := obj.(*v1.Namespace)
+		if !ok {
+			continue
+		}
+		value, found := namespace.Annotations[EgressGatewayAnnotation]
+		if !found {
+			continue
+		}
+		policy, err := parseEgressPolicy(namespace.Name, value)
+		if err != nil {
+			w.log.Warnf("Ignoring egress policy of namespace %s: %s", namespace.Name, err)
+			continue
+		}
+		state.Policies[namespace.Name] = policy
+	}
+	for _, obj := range w.nodeStore.List() {
+		node, ok := obj.(*v1.Node)
+		if !ok {
+			continue
+		}
+		if ip := getNodeIP4(node); ip != nil {
+			state.NodeIPs[node.Name] = ip
+		}
+		if isNodeReady(node) {
+			state.ReadyNodes[node.Name] = true
+		}
+	}
+	return state
+}
+
+func NewEgressPolicyWatcher(k8sclient *kubernetes.Clientset, log *logrus.Entry) *EgressPolicyWatcher {
+	w := &EgressPolicyWatcher{
+		log:     log,
+		changed: make(chan struct{}, 1),
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.notify() },
+		UpdateFunc: func(old interface{}, obj interface{}) { w.notify() },
+		DeleteFunc: func(obj interface{}) { w.notify() },
+	}
+	w.namespaceStore, w.namespaceInformer = cache.NewInformerWithOptions(
+		cache.InformerOptions{
+			ListerWatcher: cache.NewListWatchFromClient(
+				k8sclient.CoreV1().RESTClient(),
+				"namespaces",
+				"",
+				fields.Everything(),
+			),
+			ObjectType:   &v1.Namespace{},
+			ResyncPeriod: 60 * time.Second,
+			Handler:      handler,
+		},
+	)
+	w.nodeStore, w.nodeInformer = cache.NewInformerWithOptions(
+		cache.InformerOptions{
+			ListerWatcher: cache.NewListWatchFromClient(
+				k8sclient.CoreV1().RESTClient(),
+				"nodes",
+				"",
+				fields.Everything(),
+			),
+			ObjectType:   &v1.Node{},
+			ResyncPeriod: 60 * time.Second,
+			Handler:      handler,
+		},
+	)
+	return w
+}
+
+// WatchEgressPolicies sends an EgressPoliciesChanged event every time the
+// egress policies, the node addresses or the nodes readiness change
+func (w *EgressPolicyWatcher) WatchEgressPolicies(t *tomb.Tomb) error {
+	if !*config.GetCalicoVppFeatureGates().EgressGatewayEnabled {
+		<-t.Dying()
+		return nil
+	}
+	t.Go(func() error { w.namespaceInformer.Run(t.Dying()); return nil })
+	t.Go(func() error { w.nodeInformer.Run(t.Dying()); return nil })
+	if !cache.WaitForCacheSync(t.Dying(), w.namespaceInformer.HasSynced, w.nodeInformer.HasSynced) {
+		return nil
+	}
+	w.log.Info("Egress policy watcher synced")
+	w.notify()
+
+	for {
+		select {
+		case <-t.Dying():
+			w.log.Warn("Egress policy watcher asked to stop")
+			return nil
+		case <-w.changed:
+			state := w.computeState()
+			if reflect.DeepEqual(state, w.state) {
+				continue
+			}
+			w.log.Infof("Egress policies changed: %d policies, %d ready nodes", len(state.Policies), len(state.ReadyNodes))
+			w.state = state
+			common.SendEvent(common.CalicoVppEvent{
+				Type: common.EgressPoliciesChanged,
+				New:  state,
+			})
+		}
+	}
+}
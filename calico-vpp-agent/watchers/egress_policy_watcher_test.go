@@ -0,0 +1,114 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchers
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWatchers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Watchers Suite")
+}
+
+func testEgressPolicy(namespace string, egressIP string, gatewayNodes ...string) *EgressPolicy {
+	return &EgressPolicy{
+		Namespace:    namespace,
+		EgressIP:     net.ParseIP(egressIP).To4(),
+		GatewayNodes: gatewayNodes,
+	}
+}
+
+var _ = Describe("Egress policy watcher", func() {
+	It("Parses egress policies", func() {
+		policy, err := parseEgressPolicy("ns1", `{
+			"egressIP": "192.168.0.200",
+			"gatewayNodes": ["node-a", "node-b"],
+			"podSelector": "app in (billing, checkout)"
+		}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policy.Namespace).To(Equal("ns1"))
+		Expect(policy.EgressIP.String()).To(Equal("192.168.0.200"))
+		Expect(policy.GatewayNodes).To(Equal([]string{"node-a", "node-b"}))
+		Expect(policy.PodSelector).To(Equal("app in (billing, checkout)"))
+
+		for _, value := range []string{
+			`192.168.0.200`,
+			`{"egressIP": "2001:db8::1", "gatewayNodes": ["node-a"]}`,
+			`{"egressIP": "not-an-ip", "gatewayNodes": ["node-a"]}`,
+			`{"egressIP": "192.168.0.200"}`,
+			`{"egressIP": "192.168.0.200", "gatewayNodes": ["node-a"], "podSelector": "app in ("}`,
+		} {
+			_, err = parseEgressPolicy("ns1", value)
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+
+	It("Matches pods with the policy selector", func() {
+		policy := testEgressPolicy("ns1", "192.168.0.200", "node-a")
+		Expect(policy.Matches(nil)).To(BeTrue())
+		Expect(policy.Matches(map[string]string{"app": "web"})).To(BeTrue())
+
+		policy.PodSelector = "app in (billing, checkout)"
+		Expect(policy.Matches(map[string]string{"app": "billing"})).To(BeTrue())
+		Expect(policy.Matches(map[string]string{"app": "web"})).To(BeFalse())
+		Expect(policy.Matches(nil)).To(BeFalse())
+
+		state := &EgressPolicyState{Policies: map[string]*EgressPolicy{"ns1": policy}}
+		Expect(state.GetPodPolicy("ns1", map[string]string{"app": "checkout"})).To(Equal(policy))
+		Expect(state.GetPodPolicy("ns1", map[string]string{"app": "web"})).To(BeNil())
+		Expect(state.GetPodPolicy("ns2", map[string]string{"app": "checkout"})).To(BeNil())
+	})
+
+	It("Elects the first ready gateway", func() {
+		policy := testEgressPolicy("ns1", "192.168.0.200", "node-a", "node-b")
+		state := &EgressPolicyState{
+			Policies:   map[string]*EgressPolicy{"ns1": policy},
+			ReadyNodes: map[string]bool{"node-a": true, "node-b": true},
+		}
+		Expect(state.GetActiveGateway(policy)).To(Equal("node-a"))
+		delete(state.ReadyNodes, "node-a")
+		Expect(state.GetActiveGateway(policy)).To(Equal("node-b"))
+		delete(state.ReadyNodes, "node-b")
+		Expect(state.GetActiveGateway(policy)).To(BeEmpty())
+	})
+
+	It("Assigns several egress IPs to a gateway node", func() {
+		policy1 := testEgressPolicy("ns1", "192.168.0.201", "node-a", "node-b")
+		policy2 := testEgressPolicy("ns2", "192.168.0.200", "node-a", "node-b")
+		// Same egress IP as ns2, its gateway list is ignored
+		policy3 := testEgressPolicy("ns3", "192.168.0.200", "node-c")
+		state := &EgressPolicyState{
+			Policies:   map[string]*EgressPolicy{"ns1": policy1, "ns2": policy2, "ns3": policy3},
+			ReadyNodes: map[string]bool{"node-a": true, "node-b": true, "node-c": true},
+		}
+		Expect(state.GetActiveGateways()).To(Equal(map[string]string{
+			"192.168.0.200": "node-a",
+			"192.168.0.201": "node-a",
+		}))
+		Expect(state.GetActiveGateway(policy3)).To(Equal("node-a"))
+
+		delete(state.ReadyNodes, "node-a")
+		Expect(state.GetActiveGateways()).To(Equal(map[string]string{
+			"192.168.0.200": "node-b",
+			"192.168.0.201": "node-b",
+		}))
+	})
+})
@@ -33,6 +33,7 @@ import (
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
 	networkv3 "github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/network"
 	nadv1 "github.com/projectcalico/vpp-dataplane/v3/multinet-monitor/multinettypes"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
 )
 
@@ -50,6 +51,8 @@ type NetworkDefinition struct {
 	Name                string
 	Range               string
 	NetAttachDefs       string
+	// SRIOV is set for networks whose pods get a VF of an uplink
+	SRIOV *networkv3.SRIOVSpec
 }
 
 type NetWatcher struct {
@@ -283,13 +286,28 @@ func (w *NetWatcher) onNadAdded(nad *netv1.NetworkAttachmentDefinition) error {
 }
 
 func (w *NetWatcher) OnNetAdded(net *networkv3.Network) error {
-	if _, ok := common.VppManagerInfo.PhysicalNets[net.Spec.PhysicalNetworkName]; !ok {
+	if _, ok := common.GetVppManagerInfo().PhysicalNets[net.Spec.PhysicalNetworkName]; !ok {
 		return errors.Errorf("physical network %s is not defined", net.Spec.PhysicalNetworkName)
 	}
+	if net.Spec.SRIOV != nil {
+		uplinkStatus, ok := common.GetVppManagerInfo().UplinkStatuses[net.Spec.SRIOV.Uplink]
+		if !ok {
+			return errors.Errorf("sriov uplink %s of network %s is not defined", net.Spec.SRIOV.Uplink, net.Name)
+		}
+		numVFs, err := utils.GetInterfaceNumVFs(uplinkStatus.PciID)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get num VFs of sriov uplink %s", net.Spec.SRIOV.Uplink)
+		}
+		err = checkSriovVlans(net.Name, net.Spec.SRIOV, numVFs, w.networkDefinitions)
+		if err != nil {
+			return err
+		}
+	}
 	netDef, err := w.CreateNetwork(net.Name, uint32(net.Spec.VNI), net.Spec.Range, net.Spec.PhysicalNetworkName)
 	if err != nil {
 		return err
 	}
+	netDef.SRIOV = net.Spec.SRIOV
 	for nad, net := range w.nads {
 		if net == netDef.Name {
 			netDef.NetAttachDefs = nad
@@ -302,6 +320,35 @@ func (w *NetWatcher) OnNetAdded(net *networkv3.Network) error {
 	return nil
 }
 
+// getSriovVlans returns the VLANs an SR-IOV network gives to its pods. VF 0
+// is used by VPP, and VF n of the uplink gets VLAN vlan + n - 1
+func getSriovVlans(sriov *networkv3.SRIOVSpec, numVFs int) (first, last int) {
+	return sriov.VLAN, sriov.VLAN + numVFs - 2
+}
+
+// checkSriovVlans checks that the VLANs of an SR-IOV network are valid, and
+// that no other SR-IOV network on the same uplink uses them
+func checkSriovVlans(netName string, sriov *networkv3.SRIOVSpec, numVFs int, networks map[string]*NetworkDefinition) error {
+	if numVFs < 2 {
+		return errors.Errorf("sriov uplink %s of network %s has no VF for pods", sriov.Uplink, netName)
+	}
+	first, last := getSriovVlans(sriov, numVFs)
+	if first < 1 || last > 4094 {
+		return errors.Errorf("invalid sriov vlans %d-%d for network %s", first, last, netName)
+	}
+	for name, netDef := range networks {
+		if name == netName || netDef.SRIOV == nil || netDef.SRIOV.Uplink != sriov.Uplink {
+			continue
+		}
+		otherFirst, otherLast := getSriovVlans(netDef.SRIOV, numVFs)
+		if first <= otherLast && otherFirst <= last {
+			return errors.Errorf("sriov vlans %d-%d of network %s overlap with vlans %d-%d of network %s",
+				first, last, netName, otherFirst, otherLast, name)
+		}
+	}
+	return nil
+}
+
 func (w *NetWatcher) OnNetChanged(old, new *networkv3.Network) {
 	// TODO handle network change
 }
@@ -325,8 +372,8 @@ func (w *NetWatcher) CreateNetwork(networkName string, networkVni uint32, netRan
 		return w.networkDefinitions[networkName], nil
 	}
 	w.log.Infof("adding network %s", networkName)
-	vrfID := common.VppManagerInfo.PhysicalNets[phyNet].VrfID
-	podVrfID := common.VppManagerInfo.PhysicalNets[phyNet].PodVrfID
+	vrfID := common.GetVppManagerInfo().PhysicalNets[phyNet].VrfID
+	podVrfID := common.GetVppManagerInfo().PhysicalNets[phyNet].PodVrfID
 	netDef = &NetworkDefinition{
 		VRF:                 VRF{Tables: [2]uint32{vrfID, vrfID}},
 		PodVRF:              VRF{Tables: [2]uint32{podVrfID, podVrfID}},
@@ -0,0 +1,56 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	networkv3 "github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/network"
+)
+
+var _ = Describe("Network watcher", func() {
+	networks := map[string]*NetworkDefinition{
+		"blue":  {Name: "blue", SRIOV: &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 100}},
+		"red":   {Name: "red", SRIOV: &networkv3.SRIOVSpec{Uplink: "eth2", VLAN: 100}},
+		"green": {Name: "green"},
+	}
+
+	It("Gives pods the VLANs of VFs 1 to numVFs-1", func() {
+		first, last := getSriovVlans(&networkv3.SRIOVSpec{VLAN: 100}, 8)
+		Expect(first).To(Equal(100))
+		Expect(last).To(Equal(106))
+	})
+
+	It("Checks the VLAN range of SR-IOV networks", func() {
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 4088}, 8, nil)).To(Succeed())
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 4089}, 8, nil)).ToNot(Succeed())
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 0}, 8, nil)).ToNot(Succeed())
+		// VF 0 is used by VPP
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 100}, 1, nil)).ToNot(Succeed())
+	})
+
+	It("Rejects overlapping VLANs on the same uplink", func() {
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 106}, 8, networks)).ToNot(Succeed())
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 94}, 8, networks)).ToNot(Succeed())
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 107}, 8, networks)).To(Succeed())
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 93}, 8, networks)).To(Succeed())
+		// Other uplinks have their own VLANs
+		Expect(checkSriovVlans("net", &networkv3.SRIOVSpec{Uplink: "eth3", VLAN: 100}, 8, networks)).To(Succeed())
+		// A network does not overlap with itself when added again
+		Expect(checkSriovVlans("blue", &networkv3.SRIOVSpec{Uplink: "eth1", VLAN: 100}, 8, networks)).To(Succeed())
+	})
+})
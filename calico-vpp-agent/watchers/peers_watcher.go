@@ -42,6 +42,10 @@ import (
 	"github.com/projectcalico/vpp-dataplane/v3/config"
 )
 
+const (
+	meshPeerSelector = "all()"
+)
+
 type LocalBGPPeer struct {
 	Peer           *bgpapi.Peer
 	BGPFilterNames []string
@@ -286,7 +290,7 @@ func (w *PeerWatcher) resyncAndCreateWatcher(state map[string]*bgpPeer) error {
 				},
 				Spec: calicov3.BGPPeerSpec{
 					Node:         *config.NodeName,
-					PeerSelector: "all()",
+					PeerSelector: meshPeerSelector,
 				},
 			})
 		} else {
@@ -438,6 +442,7 @@ func (w *PeerWatcher) createBGPPeer(ip string, asn uint32, peerSpec *calicov3.BG
 		Conf: &bgpapi.PeerConf{
 			NeighborAddress: ipAddr.String(),
 			PeerAsn:         asn,
+			Description:     string(getBGPPeerType(peerSpec)),
 		},
 		GracefulRestart: &bgpapi.GracefulRestart{
 			Enabled:             true,
@@ -457,6 +462,18 @@ func (w *PeerWatcher) createBGPPeer(ip string, asn uint32, peerSpec *calicov3.BG
 	return peer, nil
 }
 
+// getBGPPeerType returns the kind of peering a BGPPeer spec describes, as
+// reported in CalicoNodeStatus. It is stored in the GoBGP peer description.
+func getBGPPeerType(peerSpec *calicov3.BGPPeerSpec) calicov3.BGPPeerType {
+	if peerSpec.Node == *config.NodeName && peerSpec.PeerSelector == meshPeerSelector {
+		return calicov3.BGPPeerTypeNodeMesh
+	}
+	if peerSpec.Node != "" || peerSpec.NodeSelector != "" {
+		return calicov3.BGPPeerTypeNodePeer
+	}
+	return calicov3.BGPPeerTypeGlobalPeer
+}
+
 func (w *PeerWatcher) addBGPPeer(ip string, asn uint32, peerSpec *calicov3.BGPPeerSpec) error {
 	peer, err := w.createBGPPeer(ip, asn, peerSpec)
 	if err != nil {
@@ -0,0 +1,200 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchers
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+// PodAnnotationsUpdate is sent with PodAnnotationsChanged events
+type PodAnnotationsUpdate struct {
+	Namespace   string
+	Name        string
+	Annotations map[string]string
+	// QosClass is the kubernetes QoS class of the pod
+	QosClass string
+}
+
+// PodWorkloadID returns the WorkloadID of the LocalPodSpecs of the pod
+func (u *PodAnnotationsUpdate) PodWorkloadID() string {
+	return u.Namespace + "/" + u.Name
+}
+
+// PodSettingsReport is sent with PodSettingsNeedRestart events, and
+// reported as a warning event on the pod
+type PodSettingsReport struct {
+	Namespace string
+	Name      string
+	Message   string
+}
+
+// PodAnnotationWatcher watches the annotations of the pods running on this
+// node, so that their interfaces can be updated without recreating them
+type PodAnnotationWatcher struct {
+	log *logrus.Entry
+
+	k8sclient   *kubernetes.Clientset
+	podStore    cache.Store
+	podInformer cache.Controller
+
+	eventChan chan common.CalicoVppEvent
+}
+
+func NewPodAnnotationWatcher(k8sclient *kubernetes.Clientset, log *logrus.Entry) *PodAnnotationWatcher {
+	w := &PodAnnotationWatcher{
+		log:       log,
+		k8sclient: k8sclient,
+		eventChan: make(chan common.CalicoVppEvent, common.ChanSize),
+	}
+	w.podStore, w.podInformer = cache.NewInformerWithOptions(
+		cache.InformerOptions{
+			ListerWatcher: cache.NewListWatchFromClient(
+				k8sclient.CoreV1().RESTClient(),
+				"pods",
+				"",
+				fields.OneTermEqualSelector("spec.nodeName", *config.NodeName),
+			),
+			ObjectType:   &v1.Pod{},
+			ResyncPeriod: 60 * time.Second,
+			Handler: cache.ResourceEventHandlerFuncs{
+				UpdateFunc: w.onPodUpdate,
+			},
+		},
+	)
+	reg := common.RegisterHandler(w.eventChan, "pod annotation watcher events")
+	reg.ExpectEvents(common.PodSettingsNeedRestart)
+	return w
+}
+
+func (w *PodAnnotationWatcher) sendPodAnnotations(pod *v1.Pod) {
+	common.SendEvent(common.CalicoVppEvent{
+		Type: common.PodAnnotationsChanged,
+		New: &PodAnnotationsUpdate{
+			Namespace:   pod.Namespace,
+			Name:        pod.Name,
+			Annotations: pod.Annotations,
+			QosClass:    string(pod.Status.QOSClass),
+		},
+	})
+}
+
+func (w *PodAnnotationWatcher) onPodUpdate(old interface{}, obj interface{}) {
+	oldPod, ok := old.(*v1.Pod)
+	if !ok {
+		return
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	// The pod gets its IP once its interfaces are created, which is when
+	// settings derived from its kubernetes QoS class can be applied if the
+	// CNI Add could not get it
+	gotIP := oldPod.Status.PodIP == "" && pod.Status.PodIP != ""
+	if reflect.DeepEqual(oldPod.Annotations, pod.Annotations) && oldPod.Status.QOSClass == pod.Status.QOSClass && !gotIP {
+		return
+	}
+	w.log.Infof("Annotations of pod %s/%s changed", pod.Namespace, pod.Name)
+	w.sendPodAnnotations(pod)
+}
+
+// GetPodQosClass returns the kubernetes QoS class of a pod running on this
+// node. Pods are looked up in the API server when they are not cached yet,
+// as the CNI Add of a pod can happen before the informer receives it
+func (w *PodAnnotationWatcher) GetPodQosClass(namespace string, name string) (string, error) {
+	obj, found, err := w.podStore.GetByKey(namespace + "/" + name)
+	if err == nil && found {
+		if pod, ok := obj.(*v1.Pod); ok && pod.Status.QOSClass != "" {
+			return string(pod.Status.QOSClass), nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pod, err := w.k8sclient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting pod %s/%s", namespace, name)
+	}
+	return string(pod.Status.QOSClass), nil
+}
+
+func (w *PodAnnotationWatcher) reportPodSettings(recorder record.EventRecorder, report *PodSettingsReport) {
+	obj, found, err := w.podStore.GetByKey(report.Namespace + "/" + report.Name)
+	if err != nil || !found {
+		w.log.Warnf("Pod %s/%s not found for report: %s", report.Namespace, report.Name, report.Message)
+		return
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	recorder.Event(pod, v1.EventTypeWarning, "RestartRequired", report.Message)
+}
+
+// WatchPodAnnotations sends a PodAnnotationsChanged event every time the
+// annotations of a pod running on this node change
+func (w *PodAnnotationWatcher) WatchPodAnnotations(t *tomb.Tomb) error {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: w.k8sclient.CoreV1().Events("")})
+	defer broadcaster.Shutdown()
+	recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "calico-vpp-agent", Host: *config.NodeName})
+
+	t.Go(func() error { w.podInformer.Run(t.Dying()); return nil })
+	if !cache.WaitForCacheSync(t.Dying(), w.podInformer.HasSynced) {
+		return nil
+	}
+	w.log.Info("Pod annotation watcher synced")
+	// Annotations might have changed while the agent was not running
+	for _, obj := range w.podStore.List() {
+		if pod, ok := obj.(*v1.Pod); ok {
+			w.sendPodAnnotations(pod)
+		}
+	}
+
+	for {
+		select {
+		case <-t.Dying():
+			w.log.Warn("Pod annotation watcher asked to stop")
+			return nil
+		case evt := <-w.eventChan:
+			switch evt.Type {
+			case common.PodSettingsNeedRestart:
+				report, ok := evt.New.(*PodSettingsReport)
+				if !ok {
+					w.log.Errorf("evt.New is not a (*PodSettingsReport) %v", evt.New)
+					continue
+				}
+				w.reportPodSettings(recorder, report)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchers
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+const (
+	// uplinkFailoverPollInterval is how often the vpp manager info is
+	// read while vpp-manager may move the node addresses
+	uplinkFailoverPollInterval = time.Second
+)
+
+// UplinkFailoverWatcher follows the uplink holding the node addresses, as
+// moved by vpp-manager, and points the unnumbered interfaces to it
+type UplinkFailoverWatcher struct {
+	log *logrus.Entry
+	vpp *vpplink.VppLink
+}
+
+func NewUplinkFailoverWatcher(vpp *vpplink.VppLink, log *logrus.Entry) *UplinkFailoverWatcher {
+	return &UplinkFailoverWatcher{
+		log: log,
+		vpp: vpp,
+	}
+}
+
+// moveUnnumbered points the interfaces unnumbered to the uplink with
+// swIfIndex from to the uplink with swIfIndex to
+func (w *UplinkFailoverWatcher) moveUnnumbered(from, to uint32) {
+	unnumbered, err := w.vpp.InterfaceGetUnnumbered(types.InvalidID)
+	if err != nil {
+		w.log.Errorf("Error listing unnumbered interfaces: %v", err)
+		return
+	}
+	for _, details := range unnumbered {
+		if uint32(details.IPSwIfIndex) != from {
+			continue
+		}
+		err = w.vpp.InterfaceSetUnnumbered(uint32(details.SwIfIndex), to)
+		if err != nil {
+			w.log.Errorf("Error moving unnumbered interface %d to uplink %d: %v", details.SwIfIndex, to, err)
+		}
+	}
+}
+
+// checkMainUplink re-reads the vpp manager info, and follows the node
+// addresses if they moved
+func (w *UplinkFailoverWatcher) checkMainUplink() {
+	info, err := common.ReadVppManagerInfo()
+	if err != nil {
+		w.log.Errorf("Error reading vpp manager info: %v", err)
+		return
+	}
+	previous := common.GetVppManagerInfo()
+	if info.VppPid != previous.VppPid {
+		// VPP restarted, the agent restarts with it
+		return
+	}
+	from, to := previous.GetMainSwIfIndex(), info.GetMainSwIfIndex()
+	if from != to {
+		w.log.Warnf("Node addresses moved from uplink %d to %d", from, to)
+		w.moveUnnumbered(from, to)
+	}
+	common.SetVppManagerInfo(info)
+}
+
+func (w *UplinkFailoverWatcher) WatchUplinkFailover(t *tomb.Tomb) error {
+	conf := config.GetCalicoVppInterfaces().UplinkFailover
+	if conf == nil {
+		w.log.Infof("Uplink failover not configured")
+		return nil
+	}
+	events := make(chan types.InterfaceEvent, 10)
+	for name, uplinkStatus := range common.GetVppManagerInfo().UplinkStatuses {
+		if !uplinkStatus.IsMain && !uplinkStatus.IsStandby {
+			continue
+		}
+		watcher, err := w.vpp.WatchInterfaceEvents(uplinkStatus.SwIfIndex)
+		if err != nil {
+			w.log.Errorf("Error watching uplink %s: %v", name, err)
+			continue
+		}
+		defer watcher.Stop()
+		t.Go(func() error {
+			for {
+				select {
+				case <-t.Dying():
+					return nil
+				case event, ok := <-watcher.Events():
+					if !ok {
+						return nil
+					}
+					select {
+					case events <- event:
+					case <-t.Dying():
+						return nil
+					}
+				}
+			}
+		})
+	}
+	// vpp-manager moves the addresses once links are stable
+	// for the hold-down time after an event
+	watchFor := *conf.HoldDown
+	if *conf.RecoverHoldDown > watchFor {
+		watchFor = *conf.RecoverHoldDown
+	}
+	watchFor += 5 * time.Second
+
+	ticker := time.NewTicker(uplinkFailoverPollInterval)
+	defer ticker.Stop()
+	// Addresses may have moved before the agent started
+	w.checkMainUplink()
+	var watchUntil time.Time
+	for {
+		select {
+		case <-t.Dying():
+			w.log.Warn("Uplink failover watcher asked to stop")
+			return nil
+		case event := <-events:
+			w.log.Debugf("Uplink %d event %d", event.SwIfIndex, event.Type)
+			watchUntil = time.Now().Add(watchFor)
+		case <-ticker.C:
+			if time.Now().Before(watchUntil) {
+				w.checkMainUplink()
+			}
+		}
+	}
+}
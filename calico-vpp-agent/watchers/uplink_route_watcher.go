@@ -130,8 +130,8 @@ func (r *RouteWatcher) safeAddrClose() {
 
 func GetUplinkMtu() int {
 	hostMtu := vpplink.CalicoVppMaxMTu
-	if len(common.VppManagerInfo.UplinkStatuses) != 0 {
-		for _, v := range common.VppManagerInfo.UplinkStatuses {
+	if len(common.GetVppManagerInfo().UplinkStatuses) != 0 {
+		for _, v := range common.GetVppManagerInfo().UplinkStatuses {
 			if v.Mtu < hostMtu {
 				hostMtu = v.Mtu
 			}
@@ -149,7 +149,7 @@ func (r *RouteWatcher) getNetworkRoute(network string, physicalNet string) (rout
 	}
 	var routes []*netlink.Route
 	var order int
-	for _, uplinkStatus := range common.VppManagerInfo.UplinkStatuses {
+	for _, uplinkStatus := range common.GetVppManagerInfo().UplinkStatuses {
 		if uplinkStatus.PhysicalNetworkName == physicalNet {
 			gw := uplinkStatus.FakeNextHopIP4
 			if cidr.IP.To4() == nil {
@@ -184,7 +184,7 @@ func (r *RouteWatcher) WatchRoutes(t *tomb.Tomb) error {
 		// running on the host to ensure correct source address selection if the host has multiple interfaces
 		r.log.Infof("Adding route to service prefix %s through VPP", serviceCIDR.String())
 		var order int
-		for _, uplinkStatus := range common.VppManagerInfo.UplinkStatuses {
+		for _, uplinkStatus := range common.GetVppManagerInfo().UplinkStatuses {
 			gw := uplinkStatus.FakeNextHopIP4
 			if serviceCIDR.IP.To4() == nil {
 				gw = uplinkStatus.FakeNextHopIP6
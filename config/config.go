@@ -21,8 +21,8 @@ import (
 	"fmt"
 	"net"
 	"os"
-	"os/exec"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -40,11 +40,23 @@ import (
 const (
 	CNIServerSocket      = "/var/run/calico/cni-server.sock"
 	FelixDataplaneSocket = "/var/run/calico/felix-dataplane.sock"
+	CaptureServerSocket  = "/var/run/calico/capture-server.sock"
 	VppAPISocket         = "/var/run/vpp/vpp-api.sock"
 	VppManagerInfoFile   = "/var/run/vpp/vppmanagerinfofile"
 	CniServerStateFile   = "/var/run/vpp/calico_vpp_pod_state"
+	CniServerStateDir    = "/var/run/vpp/calico_vpp_pods"
 	CalicoVppPidFile     = "/var/run/vpp/calico_vpp.pid"
+	VhostUserSocketDir   = "/var/run/vpp/vhost-user"
 	CalicoVppVersionFile = "/etc/calicovppversion"
+	// UplinkDriverStateFile persists the uplink drivers that worked
+	// on the node, in a host directory surviving reboots
+	UplinkDriverStateFile = "/var/lib/vpp/calico_vpp_uplink_drivers.json"
+	// UplinkDriversAnnotation is set on the node to the active uplink
+	// drivers and the reason they were chosen
+	UplinkDriversAnnotation = "cni.projectcalico.org/vppUplinkDrivers"
+	// BGPPeerErrorsAnnotation is set on CalicoNodeStatus objects to the
+	// last error of each BGP peer, which their status has no field for
+	BGPPeerErrorsAnnotation = "cni.projectcalico.org/vppBGPPeerErrors"
 
 	DefaultVXLANVni      = 4096
 	DefaultVXLANPort     = 4789
@@ -80,6 +92,9 @@ var (
 	CalicoVppFeatureGates            = JSONEnvVar("CALICOVPP_FEATURE_GATES", &CalicoVppFeatureGatesConfigType{})
 	CalicoVppIpsec                   = JSONEnvVar("CALICOVPP_IPSEC", &CalicoVppIpsecConfigType{})
 	CalicoVppSrv6                    = JSONEnvVar("CALICOVPP_SRV6", &CalicoVppSrv6ConfigType{})
+	CalicoVppMulticast               = JSONEnvVar("CALICOVPP_MULTICAST", &CalicoVppMulticastConfigType{})
+	CalicoVppQos                     = JSONEnvVar("CALICOVPP_QOS", &CalicoVppQosConfigType{})
+	CalicoVppHealth                  = JSONEnvVar("CALICOVPP_HEALTH", &CalicoVppHealthConfigType{})
 	CalicoVppInitialConfig           = JSONEnvVar("CALICOVPP_INITIAL_CONFIG", &CalicoVppInitialConfigConfigType{})
 	CalicoVppGracefulShutdownTimeout = EnvVar("CALICOVPP_GRACEFUL_SHUTDOWN_TIMEOUT", 10*time.Second, time.ParseDuration)
 	LogFormat                        = StringEnvVar("CALICOVPP_LOG_FORMAT", "")
@@ -124,6 +139,13 @@ var (
 	HookScriptVppDoneOk = StringEnvVar("CALICOVPP_HOOK_VPP_DONE_OK", DefaultHookScript)
 	/* Bash script template run when VPP stops with an error */
 	HookScriptVppErrored = StringEnvVar("CALICOVPP_HOOK_VPP_ERRORED", DefaultHookScript)
+	/* Bash script template run before creating the uplinks in VPP */
+	HookScriptBeforeUplinkCreate = StringEnvVar("CALICOVPP_HOOK_BEFORE_UPLINK_CREATE", "")
+	/* Bash script template run once the uplinks are configured in VPP */
+	HookScriptAfterUplinkCreate = StringEnvVar("CALICOVPP_HOOK_AFTER_UPLINK_CREATE", "")
+	/* Bash script template run after VPP stops, before restoring
+	   the linux configuration of the uplinks */
+	HookScriptBeforeRestore = StringEnvVar("CALICOVPP_HOOK_BEFORE_RESTORE", "")
 
 	AllHooks = []*string{
 		HookScriptBeforeIfRead,
@@ -131,8 +153,13 @@ var (
 		HookScriptVppRunning,
 		HookScriptVppDoneOk,
 		HookScriptVppErrored,
+		HookScriptBeforeUplinkCreate,
+		HookScriptAfterUplinkCreate,
+		HookScriptBeforeRestore,
 	}
 
+	CalicoVppHooks = JSONEnvVar("CALICOVPP_HOOKS", &CalicoVppHooksConfigType{})
+
 	Info = &VppManagerInfo{}
 
 	// VppHostPuntFakeGatewayAddress is the fake gateway we use with a static neighbor
@@ -140,31 +167,15 @@ var (
 	VppHostPuntFakeGatewayAddress = net.ParseIP("169.254.0.1")
 )
 
-func RunHook(hookScript *string, hookName string, params *VppManagerParams, log *logrus.Logger) {
-	if *hookScript == "" {
-		return
-	}
-	template, err := TemplateScriptReplace(*hookScript, params, nil)
-	if err != nil {
-		log.Warnf("Running hook %s errored with %s", hookName, err)
-		return
-	}
-
-	cmd := exec.Command("/bin/bash", "-c", template, hookName)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err = cmd.Run()
-	if err != nil {
-		log.Warnf("Running hook %s errored with %s", hookName, err)
-		return
-	}
-}
-
 func GetCalicoVppDebug() *CalicoVppDebugConfigType                 { return *CalicoVppDebug }
 func GetCalicoVppInterfaces() *CalicoVppInterfacesConfigType       { return *CalicoVppInterfaces }
 func GetCalicoVppFeatureGates() *CalicoVppFeatureGatesConfigType   { return *CalicoVppFeatureGates }
 func GetCalicoVppIpsec() *CalicoVppIpsecConfigType                 { return *CalicoVppIpsec }
 func GetCalicoVppSrv6() *CalicoVppSrv6ConfigType                   { return *CalicoVppSrv6 }
+func GetCalicoVppMulticast() *CalicoVppMulticastConfigType         { return *CalicoVppMulticast }
+func GetCalicoVppQos() *CalicoVppQosConfigType                     { return *CalicoVppQos }
+func GetCalicoVppHealth() *CalicoVppHealthConfigType               { return *CalicoVppHealth }
+func GetCalicoVppHooks() *CalicoVppHooksConfigType                 { return *CalicoVppHooks }
 func GetCalicoVppInitialConfig() *CalicoVppInitialConfigConfigType { return *CalicoVppInitialConfig }
 
 type InterfaceSpec struct {
@@ -228,6 +239,67 @@ func (i *InterfaceSpec) Validate(maxIfSpec *InterfaceSpec) error {
 	return nil
 }
 
+// MemifOptions are the settings only applying to memif interfaces, they are
+// given in the memif interface spec next to the InterfaceSpec fields
+type MemifOptions struct {
+	// BufferSize is the size in bytes of the memif buffers, 0 for the VPP default
+	BufferSize int `json:"bufferSize"`
+	// RxWorkers and TxWorkers pin the queue i on the worker RxWorkers[i],
+	// the other queues are balanced between the workers
+	RxWorkers []int `json:"rxWorkers"`
+	TxWorkers []int `json:"txWorkers"`
+	// Slave makes VPP the memif slave, connecting to a socket created by the pod
+	Slave bool `json:"slave"`
+	// ZeroCopy lets the VPP slave use the pod buffers without copying them
+	ZeroCopy bool `json:"zeroCopy"`
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// GetRingSize returns the size of the memif rings, 0 for the VPP default.
+// VPP uses the same size for the rx and tx rings of a memif, which is a
+// power of 2, so the larger queue size is used for both.
+func (o *MemifOptions) GetRingSize(ifSpec *InterfaceSpec) int {
+	queueSize := max(ifSpec.RxQueueSize, ifSpec.TxQueueSize)
+	if queueSize <= 0 {
+		return 0
+	}
+	ringSize := 1
+	for ringSize < queueSize {
+		ringSize <<= 1
+	}
+	return ringSize
+}
+
+func (o *MemifOptions) Validate(ifSpec *InterfaceSpec) error {
+	if ifSpec.NumRxQueues > 255 || ifSpec.NumTxQueues > 255 {
+		return errors.Errorf("memif supports at most 255 queues")
+	}
+	// A queue size of 0 keeps the VPP default
+	for _, queueSize := range []int{ifSpec.RxQueueSize, ifSpec.TxQueueSize} {
+		if queueSize != 0 && !isPowerOfTwo(queueSize) {
+			return errors.Errorf("memif queue sizes should be powers of 2")
+		}
+	}
+	if o.BufferSize < 0 || o.BufferSize > 65535 {
+		return errors.Errorf("memif buffer size should be between 0 and 65535")
+	}
+	if len(o.RxWorkers) > ifSpec.NumRxQueues || len(o.TxWorkers) > ifSpec.NumTxQueues {
+		return errors.Errorf("more workers than queues given")
+	}
+	for _, worker := range append(append([]int{}, o.RxWorkers...), o.TxWorkers...) {
+		if worker < 0 {
+			return errors.Errorf("invalid worker %d", worker)
+		}
+	}
+	if o.ZeroCopy && !o.Slave {
+		return errors.Errorf("zero-copy is only supported when VPP is the memif slave")
+	}
+	return nil
+}
+
 type UplinkInterfaceSpec struct {
 	InterfaceSpec
 	IsMain              bool              `json:"isMain"`
@@ -237,13 +309,92 @@ type UplinkInterfaceSpec struct {
 	NewDriverName       string            `json:"newDriver"`
 	Annotations         map[string]string `json:"annotations"`
 	// Mtu is the User specified MTU for uplink & the tap
-	Mtu       int    `json:"mtu"`
+	Mtu int `json:"mtu"`
+	// NumVFs is the number of VFs created on the PF of an avf uplink,
+	// VF 0 is used by VPP and the others can be given to pods
+	NumVFs int `json:"numVFs"`
+	// VlanID makes the VLAN sub-interface of InterfaceName with this id
+	// the uplink, InterfaceName itself being taken by the driver
+	VlanID int `json:"vlanId"`
+	// InnerVlanID is the inner VLAN id of QinQ uplinks
+	InnerVlanID int `json:"innerVlanId"`
+	// VlanProtocol is the protocol of the outer VLAN tag, 802.1q
+	// (default) or 802.1ad
+	VlanProtocol string `json:"vlanProtocol"`
+	// AfXdp tunes af_xdp uplinks
+	AfXdp *AfXdpConfig `json:"afXdp,omitempty"`
+	// IsStandby uplinks take over the node addresses when the main
+	// uplink is down, if uplinkFailover is configured
+	IsStandby bool   `json:"isStandby"`
 	SwIfIndex uint32 `json:"-"`
+	// ParentSwIfIndex is the interface created by the driver for VLAN
+	// uplinks, SwIfIndex being its sub-interface
+	ParentSwIfIndex uint32 `json:"-"`
 
 	// uplinkInterfaceIndex is the index of the uplinkInterface in the list
 	uplinkInterfaceIndex int `json:"-"`
 }
 
+type AfXdpMode string
+
+const (
+	AfXdpModeAuto     AfXdpMode = "auto"
+	AfXdpModeCopy     AfXdpMode = "copy"
+	AfXdpModeZeroCopy AfXdpMode = "zero-copy"
+)
+
+type AfXdpConfig struct {
+	// Mode is auto (the default), copy or zero-copy. zero-copy
+	// falls back to copy when the NIC driver does not support it
+	Mode AfXdpMode `json:"mode"`
+	// CombinedChannels is the number of combined channels set on the
+	// NIC with ethtool. Defaults to the number of rx queues
+	CombinedChannels int `json:"combinedChannels"`
+	// BusyPoll configures the NIC for preferred busy polling
+	BusyPoll *AfXdpBusyPollConfig `json:"busyPoll,omitempty"`
+	// Program is an XDP program pinned in bpffs, attached to the NIC
+	// instead of the default program, or an object file loaded by VPP.
+	// It should redirect the traffic for VPP to the xsks_map
+	Program string `json:"program"`
+}
+
+type AfXdpBusyPollConfig struct {
+	// NapiDeferHardIrqs is the number of empty polls before NIC
+	// interrupts are re-enabled. Defaults to 2
+	NapiDeferHardIrqs int `json:"napiDeferHardIrqs"`
+	// GroFlushTimeout is the timeout in nanoseconds after which NIC
+	// interrupts are re-enabled. Defaults to 200000
+	GroFlushTimeout int `json:"groFlushTimeout"`
+}
+
+func (cfg *AfXdpConfig) Validate(numRxQueues int) error {
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = AfXdpModeAuto
+	case AfXdpModeAuto, AfXdpModeCopy, AfXdpModeZeroCopy:
+	default:
+		return errors.Errorf("unknown mode %s, should be %s, %s or %s", cfg.Mode, AfXdpModeAuto, AfXdpModeCopy, AfXdpModeZeroCopy)
+	}
+	if cfg.CombinedChannels < 0 {
+		return errors.Errorf("combinedChannels should be positive, got %d", cfg.CombinedChannels)
+	}
+	if cfg.CombinedChannels != 0 && cfg.CombinedChannels < numRxQueues {
+		return errors.Errorf("combinedChannels %d is less than the %d rx queues", cfg.CombinedChannels, numRxQueues)
+	}
+	if cfg.BusyPoll != nil {
+		if cfg.BusyPoll.NapiDeferHardIrqs < 0 || cfg.BusyPoll.GroFlushTimeout < 0 {
+			return errors.Errorf("busyPoll values should be positive")
+		}
+		if cfg.BusyPoll.NapiDeferHardIrqs == 0 {
+			cfg.BusyPoll.NapiDeferHardIrqs = 2
+		}
+		if cfg.BusyPoll.GroFlushTimeout == 0 {
+			cfg.BusyPoll.GroFlushTimeout = 200000
+		}
+	}
+	return nil
+}
+
 func (u *UplinkInterfaceSpec) GetVppSideHardwareAddress() net.HardwareAddr {
 	mac, _ := net.ParseMAC(BaseVppSideHardwareAddress)
 	mac[len(mac)-1] = byte(u.uplinkInterfaceIndex)
@@ -257,11 +408,46 @@ func (u *UplinkInterfaceSpec) SetUplinkInterfaceIndex(uplinkInterfaceIndex int)
 	u.uplinkInterfaceIndex = uplinkInterfaceIndex
 }
 
+// IsVlan returns whether the uplink is a VLAN sub-interface
+func (u *UplinkInterfaceSpec) IsVlan() bool {
+	return u.VlanID != 0
+}
+
+// GetVlanProtocol returns the protocol of the outer VLAN tag
+func (u *UplinkInterfaceSpec) GetVlanProtocol() netlink.VlanProtocol {
+	if u.VlanProtocol == "" {
+		return netlink.VLAN_PROTOCOL_8021Q
+	}
+	return netlink.StringToVlanProtocol(strings.ToLower(u.VlanProtocol))
+}
+
 func (u *UplinkInterfaceSpec) Validate(maxIfSpec *InterfaceSpec) (err error) {
 	if !u.IsMain && u.VppDriver == "" {
 		return errors.Errorf("vpp driver should be specified for secondary uplink interfaces")
 	}
-	return u.InterfaceSpec.Validate(maxIfSpec)
+	if u.IsMain && u.IsStandby {
+		return errors.Errorf("the main uplink cannot be a standby uplink")
+	}
+	if u.VlanID < 0 || u.VlanID > 4094 || u.InnerVlanID < 0 || u.InnerVlanID > 4094 {
+		return errors.Errorf("vlan ids should be between 1 and 4094, or 0 for none")
+	}
+	if u.InnerVlanID != 0 && u.VlanID == 0 {
+		return errors.Errorf("innerVlanId requires vlanId")
+	}
+	if u.GetVlanProtocol() == netlink.VLAN_PROTOCOL_UNKNOWN {
+		return errors.Errorf("unknown vlan protocol %s, should be 802.1q or 802.1ad", u.VlanProtocol)
+	}
+	err = u.InterfaceSpec.Validate(maxIfSpec)
+	if err != nil {
+		return err
+	}
+	if u.AfXdp != nil {
+		err = u.AfXdp.Validate(u.NumRxQueues)
+		if err != nil {
+			return errors.Wrap(err, "invalid afXdp")
+		}
+	}
+	return nil
 }
 
 func (u *UplinkInterfaceSpec) String() string {
@@ -301,12 +487,15 @@ func (cfg *CalicoVppDebugConfigType) Validate() (err error) {
 }
 
 type CalicoVppFeatureGatesConfigType struct {
-	MemifEnabled      *bool `json:"memifEnabled,omitempty"`
-	VCLEnabled        *bool `json:"vclEnabled,omitempty"`
-	MultinetEnabled   *bool `json:"multinetEnabled,omitempty"`
-	SRv6Enabled       *bool `json:"srv6Enabled,omitempty"`
-	IPSecEnabled      *bool `json:"ipsecEnabled,omitempty"`
-	PrometheusEnabled *bool `json:"prometheusEnabled,omitempty"`
+	MemifEnabled         *bool `json:"memifEnabled,omitempty"`
+	VCLEnabled           *bool `json:"vclEnabled,omitempty"`
+	MultinetEnabled      *bool `json:"multinetEnabled,omitempty"`
+	SRv6Enabled          *bool `json:"srv6Enabled,omitempty"`
+	IPSecEnabled         *bool `json:"ipsecEnabled,omitempty"`
+	PrometheusEnabled    *bool `json:"prometheusEnabled,omitempty"`
+	MulticastEnabled     *bool `json:"multicastEnabled,omitempty"`
+	EgressGatewayEnabled *bool `json:"egressGatewayEnabled,omitempty"`
+	VhostUserEnabled     *bool `json:"vhostUserEnabled,omitempty"`
 }
 
 func (cfg *CalicoVppFeatureGatesConfigType) Validate() (err error) {
@@ -316,6 +505,9 @@ func (cfg *CalicoVppFeatureGatesConfigType) Validate() (err error) {
 	cfg.SRv6Enabled = DefaultToPtr(cfg.SRv6Enabled, false)
 	cfg.IPSecEnabled = DefaultToPtr(cfg.IPSecEnabled, false)
 	cfg.PrometheusEnabled = DefaultToPtr(cfg.PrometheusEnabled, false)
+	cfg.MulticastEnabled = DefaultToPtr(cfg.MulticastEnabled, false)
+	cfg.EgressGatewayEnabled = DefaultToPtr(cfg.EgressGatewayEnabled, false)
+	cfg.VhostUserEnabled = DefaultToPtr(cfg.VhostUserEnabled, false)
 	return nil
 }
 
@@ -336,6 +528,149 @@ func (cfg *CalicoVppSrv6ConfigType) String() string {
 	return string(b)
 }
 
+type CalicoVppMulticastConfigType struct {
+	// ForwardToUplink forwards multicast sent by pods over the
+	// main uplink. Defaults to true
+	ForwardToUplink *bool `json:"forwardToUplink,omitempty"`
+	// ReplicateToTunnels replicates multicast sent by pods into
+	// every tunnel to the other nodes. Defaults to false
+	ReplicateToTunnels *bool `json:"replicateToTunnels,omitempty"`
+}
+
+func (cfg *CalicoVppMulticastConfigType) Validate() (err error) {
+	cfg.ForwardToUplink = DefaultToPtr(cfg.ForwardToUplink, true)
+	cfg.ReplicateToTunnels = DefaultToPtr(cfg.ReplicateToTunnels, false)
+	return nil
+}
+
+func (cfg *CalicoVppMulticastConfigType) String() string {
+	b, _ := json.MarshalIndent(cfg, "", "  ")
+	return string(b)
+}
+
+// QosClass is a class of traffic pods can be assigned to, which marks
+// their packets but is not scheduled by class on the uplinks
+type QosClass struct {
+	// Dscp is set in the IP header of the packets sent by the
+	// pods of the class, between 0 and 63
+	Dscp uint8 `json:"dscp"`
+}
+
+// KubernetesQosClasses are the QoS classes kubernetes assigns to pods
+var KubernetesQosClasses = []string{"Guaranteed", "Burstable", "BestEffort"}
+
+type CalicoVppQosConfigType struct {
+	// Classes are the classes pods can be assigned to with
+	// the cni.projectcalico.org/vppQosClass annotation
+	Classes map[string]QosClass `json:"classes,omitempty"`
+	// KubernetesClasses assigns the pods without annotation to
+	// a class, depending on their kubernetes QoS class
+	KubernetesClasses map[string]string `json:"kubernetesClasses,omitempty"`
+}
+
+func (cfg *CalicoVppQosConfigType) Validate() (err error) {
+	for name, class := range cfg.Classes {
+		if class.Dscp > 63 {
+			return errors.Errorf("dscp %d of class %s should be between 0 and 63", class.Dscp, name)
+		}
+	}
+	for kubernetesClass, name := range cfg.KubernetesClasses {
+		if !slices.Contains(KubernetesQosClasses, kubernetesClass) {
+			return errors.Errorf("unknown kubernetes QoS class %s, should be one of %v", kubernetesClass, KubernetesQosClasses)
+		}
+		if _, ok := cfg.Classes[name]; !ok {
+			return errors.Errorf("kubernetes QoS class %s maps to unknown class %s", kubernetesClass, name)
+		}
+	}
+	return nil
+}
+
+// HasClasses returns whether pods can be assigned to QoS classes. The
+// DSCP of pod packets is then copied into the outer header of the IPIP
+// tunnels
+func (cfg *CalicoVppQosConfigType) HasClasses() bool {
+	return len(cfg.Classes) > 0
+}
+
+// GetPodClass returns the class of a pod given its annotated class
+// and its kubernetes QoS class, or an empty name if it has none
+func (cfg *CalicoVppQosConfigType) GetPodClass(annotatedClass string, kubernetesClass string) (name string, class QosClass, err error) {
+	name = annotatedClass
+	if name == "" {
+		name = cfg.KubernetesClasses[kubernetesClass]
+		if name == "" {
+			return "", QosClass{}, nil
+		}
+	}
+	class, ok := cfg.Classes[name]
+	if !ok {
+		return "", QosClass{}, errors.Errorf("unknown QoS class %s", name)
+	}
+	return name, class, nil
+}
+
+func (cfg *CalicoVppQosConfigType) String() string {
+	b, _ := json.MarshalIndent(cfg, "", "  ")
+	return string(b)
+}
+
+// HealthChecks are the checks run by the health endpoints of
+// vpp-manager and the agent
+var HealthChecks = []string{"vpp-api", "stats-segment", "uplinks", "vpp-manager", "cni", "felix", "bgp"}
+
+type CalicoVppHealthConfigType struct {
+	// Enabled serves the /healthz, /readyz and /metrics health
+	// endpoints in vpp-manager and the agent. Defaults to false
+	Enabled *bool `json:"enabled,omitempty"`
+	// VppManagerListenEndpoint is where vpp-manager serves its
+	// health endpoints. Defaults to :8889
+	VppManagerListenEndpoint string `json:"vppManagerListenEndpoint"`
+	// AgentListenEndpoint is where the agent serves its health
+	// endpoints. Defaults to :8890
+	AgentListenEndpoint string `json:"agentListenEndpoint"`
+	// CheckInterval is the interval at which checks run. Defaults to 5 seconds
+	CheckInterval *time.Duration `json:"checkInterval"`
+	// CheckTimeout is the time after which a check is failed. Defaults to 2 seconds
+	CheckTimeout *time.Duration `json:"checkTimeout"`
+	// StatsMaxAge is the time after which the stats segment is stale
+	// if VPP did not update it. Defaults to 30 seconds
+	StatsMaxAge *time.Duration `json:"statsMaxAge"`
+	// DisabledChecks are names of HealthChecks not to run
+	DisabledChecks []string `json:"disabledChecks,omitempty"`
+}
+
+func (cfg *CalicoVppHealthConfigType) Validate() (err error) {
+	cfg.Enabled = DefaultToPtr(cfg.Enabled, false)
+	if cfg.VppManagerListenEndpoint == "" {
+		cfg.VppManagerListenEndpoint = ":8889"
+	}
+	if cfg.AgentListenEndpoint == "" {
+		cfg.AgentListenEndpoint = ":8890"
+	}
+	cfg.CheckInterval = DefaultToPtr(cfg.CheckInterval, 5*time.Second)
+	cfg.CheckTimeout = DefaultToPtr(cfg.CheckTimeout, 2*time.Second)
+	cfg.StatsMaxAge = DefaultToPtr(cfg.StatsMaxAge, 30*time.Second)
+	if *cfg.CheckInterval <= 0 || *cfg.CheckTimeout <= 0 || *cfg.StatsMaxAge <= 0 {
+		return errors.Errorf("checkInterval, checkTimeout and statsMaxAge should be positive")
+	}
+	for _, check := range cfg.DisabledChecks {
+		if !slices.Contains(HealthChecks, check) {
+			return errors.Errorf("unknown health check %s, should be one of %v", check, HealthChecks)
+		}
+	}
+	return nil
+}
+
+// IsCheckEnabled returns whether the named health check should run
+func (cfg *CalicoVppHealthConfigType) IsCheckEnabled(check string) bool {
+	return !slices.Contains(cfg.DisabledChecks, check)
+}
+
+func (cfg *CalicoVppHealthConfigType) String() string {
+	b, _ := json.MarshalIndent(cfg, "", "  ")
+	return string(b)
+}
+
 type CalicoVppIpsecConfigType struct {
 	CrossIpsecTunnels        *bool `json:"crossIPSecTunnels,omitempty"`
 	IpsecNbAsyncCryptoThread int   `json:"nbAsyncCryptoThreads"`
@@ -365,6 +700,27 @@ type CalicoVppInterfacesConfigType struct {
 	MaxPodIfSpec     *InterfaceSpec        `json:"maxPodIfSpec,omitempty"`
 	VppHostTapSpec   *InterfaceSpec        `json:"vppHostTapSpec,omitempty"`
 	UplinkInterfaces []UplinkInterfaceSpec `json:"uplinkInterfaces,omitempty"`
+	// UplinkFailover moves the node addresses from the main uplink to a
+	// standby uplink while it is down. Disabled when nil
+	UplinkFailover *UplinkFailoverConfigType `json:"uplinkFailover,omitempty"`
+}
+
+type UplinkFailoverConfigType struct {
+	// HoldDown is how long the main uplink stays down before
+	// failing over. Defaults to 3 seconds
+	HoldDown *time.Duration `json:"holdDown,omitempty"`
+	// RecoverHoldDown is how long the main uplink stays up before
+	// failing back. Defaults to 10 seconds
+	RecoverHoldDown *time.Duration `json:"recoverHoldDown,omitempty"`
+}
+
+func (cfg *UplinkFailoverConfigType) Validate() error {
+	cfg.HoldDown = DefaultToPtr(cfg.HoldDown, 3*time.Second)
+	cfg.RecoverHoldDown = DefaultToPtr(cfg.RecoverHoldDown, 10*time.Second)
+	if *cfg.HoldDown < 0 || *cfg.RecoverHoldDown < 0 {
+		return errors.Errorf("hold down times should be positive")
+	}
+	return nil
 }
 
 func (cfg *CalicoVppInterfacesConfigType) Validate() (err error) {
@@ -397,6 +753,12 @@ func (cfg *CalicoVppInterfacesConfigType) Validate() (err error) {
 	}
 	_ = cfg.VppHostTapSpec.Validate(nil)
 
+	if cfg.UplinkFailover != nil {
+		err = cfg.UplinkFailover.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid uplinkFailover")
+		}
+	}
 	return
 }
 
@@ -440,9 +802,77 @@ type CalicoVppInitialConfigConfigType struct { //out of agent and vppmanager
 	// issued using a pre-existing vlib buffer hence dropping a packet
 	// defaults to 30 seconds. Use 0 to disable.
 	IP6NeighborsMaxAge *uint32 `json:"ip6NeighborsMaxAge"`
+	// AutoTune sizes the VPP cores and buffers from the NUMA
+	// resources available to the node. Disabled when nil
+	AutoTune *VppAutoTuneConfigType `json:"autoTune"`
+	// VppRestart makes vpp-manager relaunch VPP when it exits
+	// unexpectedly, the agent reprogramming it without restarting.
+	// Disabled when nil
+	VppRestart *VppRestartConfigType `json:"vppRestart"`
+}
+
+type VppRestartConfigType struct {
+	// MaxRestarts is the number of restarts allowed within
+	// RestartWindow before vpp-manager gives up. Defaults to 5
+	MaxRestarts int `json:"maxRestarts"`
+	// RestartWindow defaults to 10 minutes
+	RestartWindow *time.Duration `json:"restartWindow"`
+	// Backoff is the time vpp-manager waits before restarting
+	// VPP. Defaults to 2 seconds
+	Backoff *time.Duration `json:"backoff"`
+	// AgentWaitTimeout is the time the agent waits for the restarted
+	// VPP before exiting. Defaults to 5 minutes
+	AgentWaitTimeout *time.Duration `json:"agentWaitTimeout"`
+}
+
+func (cfg *VppRestartConfigType) Validate() (err error) {
+	if cfg.MaxRestarts == 0 {
+		cfg.MaxRestarts = 5
+	}
+	if cfg.MaxRestarts < 0 {
+		return errors.Errorf("maxRestarts should be positive, got %d", cfg.MaxRestarts)
+	}
+	cfg.RestartWindow = DefaultToPtr(cfg.RestartWindow, 10*time.Minute)
+	cfg.Backoff = DefaultToPtr(cfg.Backoff, 2*time.Second)
+	cfg.AgentWaitTimeout = DefaultToPtr(cfg.AgentWaitTimeout, 5*time.Minute)
+	return nil
+}
+
+type VppAutoTuneConfigType struct {
+	// MaxWorkers caps the number of workers VPP is given,
+	// 0 means all the cpus left on the NIC NUMA node
+	MaxWorkers int `json:"maxWorkers"`
+	// HugepagesPercent is the share of the free hugepages of the
+	// NIC NUMA node used for VPP buffers. Defaults to 50
+	HugepagesPercent int `json:"hugepagesPercent"`
+}
+
+func (cfg *VppAutoTuneConfigType) Validate() (err error) {
+	if cfg.MaxWorkers < 0 {
+		return errors.Errorf("maxWorkers should be positive, got %d", cfg.MaxWorkers)
+	}
+	if cfg.HugepagesPercent == 0 {
+		cfg.HugepagesPercent = 50
+	}
+	if cfg.HugepagesPercent < 0 || cfg.HugepagesPercent > 100 {
+		return errors.Errorf("hugepagesPercent should be between 1 and 100, got %d", cfg.HugepagesPercent)
+	}
+	return nil
 }
 
 func (cfg *CalicoVppInitialConfigConfigType) Validate() (err error) {
+	if cfg.AutoTune != nil {
+		err = cfg.AutoTune.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid autoTune")
+		}
+	}
+	if cfg.VppRestart != nil {
+		err = cfg.VppRestart.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid vppRestart")
+		}
+	}
 	if cfg.PrometheusListenEndpoint == "" {
 		cfg.PrometheusListenEndpoint = ":8888"
 	}
@@ -535,6 +965,7 @@ const (
 	DriverVirtioPci     = "virtio-pci"
 	DriverI40E          = "i40e"
 	DriverICE           = "ice"
+	DriverIAVF          = "iavf"
 	DriverMLX5Core      = "mlx5_core"
 	DriverVmxNet3       = "vmxnet3"
 )
@@ -554,6 +985,24 @@ type UplinkStatus struct {
 	IsMain              bool
 	Mtu                 int
 	PhysicalNetworkName string
+	// PciID is the PCI address of the uplink NIC
+	PciID string
+	// BondMembers is the state of the members of bond uplinks
+	BondMembers []BondMemberStatus
+	// Driver is the VPP driver of the uplink
+	Driver string
+	// DriverReason is why vpp-manager chose Driver
+	DriverReason string
+	// IsUp is the link state of the uplink in VPP
+	IsUp bool
+	// IsStandby uplinks take over the node addresses while the main
+	// uplink is down
+	IsStandby bool
+	// HasNodeAddresses is whether the uplink holds the node addresses,
+	// the main uplink unless it failed over to a standby
+	HasNodeAddresses bool
+	// Transitions are the last failover transitions of the uplink
+	Transitions []UplinkTransition
 
 	// FakeNextHopIP4 is the computed next hop for v4 routes added
 	// in linux to (ServiceCIDR, podCIDR, etc...) towards this interface
@@ -563,6 +1012,12 @@ type UplinkStatus struct {
 	FakeNextHopIP6 net.IP
 }
 
+type BondMemberStatus struct {
+	Name      string
+	SwIfIndex uint32
+	IsUp      bool
+}
+
 type PhysicalNetwork struct {
 	VrfID    uint32
 	PodVrfID uint32
@@ -572,9 +1027,48 @@ type VppManagerInfo struct {
 	Status         vppManagerStatus
 	UplinkStatuses map[string]UplinkStatus
 	PhysicalNets   map[string]PhysicalNetwork
+	// VppPid is the pid of the VPP process, whose filesystem is
+	// reachable under /proc/<pid>/root
+	VppPid int
+	// VppRestarts is the number of times vpp-manager relaunched
+	// VPP after it exited unexpectedly
+	VppRestarts int
+	// LastVppRecovery is the time between the last unexpected
+	// exit of VPP and the restarted VPP being ready
+	LastVppRecovery time.Duration
+	// UplinkFailovers is the number of times the node addresses
+	// moved between uplinks
+	UplinkFailovers int
+}
+
+// maxUplinkTransitions is the number of transitions kept per uplink
+const maxUplinkTransitions = 10
+
+type UplinkTransition struct {
+	Time time.Time
+	// From and To are the names of the uplinks the node
+	// addresses moved from and to
+	From   string
+	To     string
+	Reason string
 }
 
+// AddTransition records a failover transition in the uplink status
+func (u *UplinkStatus) AddTransition(transition UplinkTransition) {
+	u.Transitions = append(u.Transitions, transition)
+	if len(u.Transitions) > maxUplinkTransitions {
+		u.Transitions = u.Transitions[len(u.Transitions)-maxUplinkTransitions:]
+	}
+}
+
+// GetMainSwIfIndex returns the uplink holding the node addresses, the
+// main uplink unless it failed over to a standby
 func (i *VppManagerInfo) GetMainSwIfIndex() uint32 {
+	for _, u := range i.UplinkStatuses {
+		if u.HasNodeAddresses && !u.IsMain {
+			return u.SwIfIndex
+		}
+	}
 	for _, u := range i.UplinkStatuses {
 		if u.IsMain {
 			return u.SwIfIndex
@@ -650,6 +1144,43 @@ type LinuxInterfaceState struct {
 	InterfaceName string
 	IsTunTap      bool
 	IsVeth        bool
+	// Vlans are the linux VLAN devices of VLAN uplinks, outermost first.
+	// IsUp, Addresses, Routes, HardwareAddr and Mtu are those of the
+	// innermost one.
+	Vlans []LinuxVlan
+	// Bond is set when the uplink is a linux bond, VPP then takes over
+	// its members and bonds them itself
+	Bond *LinuxBond
+}
+
+// LinuxBond is the configuration of a linux bond to recreate when VPP stops
+type LinuxBond struct {
+	HardwareAddr   net.HardwareAddr
+	Mtu            int
+	Mode           netlink.BondMode
+	XmitHashPolicy netlink.BondXmitHashPolicy
+	LacpRate       netlink.BondLacpRate
+	Miimon         int
+	UpDelay        int
+	DownDelay      int
+	Members        []*LinuxInterfaceState
+}
+
+// LinuxVlan is a linux VLAN device to recreate when VPP stops
+type LinuxVlan struct {
+	Name     string
+	VlanID   int
+	Protocol netlink.VlanProtocol
+	Mtu      int
+}
+
+// GetHostInterfaceName returns the name of the linux interface holding
+// the uplink addresses, which is also the name of the tap created by VPP
+func (c *LinuxInterfaceState) GetHostInterfaceName() string {
+	if len(c.Vlans) != 0 {
+		return c.Vlans[len(c.Vlans)-1].Name
+	}
+	return c.InterfaceName
 }
 
 func (c *LinuxInterfaceState) AddressString() string {
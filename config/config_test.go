@@ -16,10 +16,15 @@
 package config
 
 import (
+	"encoding/json"
 	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 
 	. "github.com/onsi/ginkgo"
@@ -96,4 +101,189 @@ var _ = Describe("Test Common Config", func() {
 		Expect(errs[0]).To(HaveOccurred())
 
 	})
+
+	It("Test Memif Options", func() {
+		ifSpec := &InterfaceSpec{NumRxQueues: 2, NumTxQueues: 1, RxQueueSize: 1024, TxQueueSize: 256}
+		Expect((&MemifOptions{RxWorkers: []int{1, 3}}).Validate(ifSpec)).To(Succeed())
+		Expect((&MemifOptions{RxWorkers: []int{1, 2, 3}}).Validate(ifSpec)).ToNot(Succeed())
+		Expect((&MemifOptions{TxWorkers: []int{-1}}).Validate(ifSpec)).ToNot(Succeed())
+		Expect((&MemifOptions{ZeroCopy: true}).Validate(ifSpec)).ToNot(Succeed())
+		Expect((&MemifOptions{ZeroCopy: true, Slave: true}).Validate(ifSpec)).To(Succeed())
+		Expect((&MemifOptions{}).Validate(&InterfaceSpec{NumRxQueues: 1, NumTxQueues: 1, RxQueueSize: 1000, TxQueueSize: 1024})).ToNot(Succeed())
+		Expect((&MemifOptions{}).Validate(&InterfaceSpec{NumRxQueues: 1, NumTxQueues: 1})).To(Succeed())
+		Expect((&MemifOptions{}).Validate(&InterfaceSpec{NumRxQueues: 1, NumTxQueues: 1, TxQueueSize: 512})).To(Succeed())
+		Expect((&MemifOptions{}).Validate(&InterfaceSpec{NumRxQueues: 1, NumTxQueues: 1, RxQueueSize: -1})).ToNot(Succeed())
+
+		Expect((&MemifOptions{}).GetRingSize(ifSpec)).To(Equal(1024))
+		Expect((&MemifOptions{}).GetRingSize(&InterfaceSpec{RxQueueSize: 1000, TxQueueSize: 300})).To(Equal(1024))
+		Expect((&MemifOptions{}).GetRingSize(&InterfaceSpec{})).To(Equal(0))
+		// VPP uses a single ring size, the larger queue size is used for both
+		Expect((&MemifOptions{}).GetRingSize(&InterfaceSpec{TxQueueSize: 512})).To(Equal(512))
+	})
+
+	It("Test QoS Classes", func() {
+		qos := &CalicoVppQosConfigType{
+			Classes: map[string]QosClass{
+				"latency": {Dscp: 46},
+				"bulk":    {Dscp: 8},
+			},
+			KubernetesClasses: map[string]string{"BestEffort": "bulk"},
+		}
+		Expect(qos.Validate()).To(Succeed())
+		Expect((&CalicoVppQosConfigType{Classes: map[string]QosClass{"bad": {Dscp: 64}}}).Validate()).ToNot(Succeed())
+		Expect((&CalicoVppQosConfigType{KubernetesClasses: map[string]string{"BestEffort": "bulk"}}).Validate()).ToNot(Succeed())
+		Expect((&CalicoVppQosConfigType{Classes: qos.Classes, KubernetesClasses: map[string]string{"Best": "bulk"}}).Validate()).ToNot(Succeed())
+
+		name, class, err := qos.GetPodClass("latency", "BestEffort")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("latency"))
+		Expect(class.Dscp).To(Equal(uint8(46)))
+		name, class, err = qos.GetPodClass("", "BestEffort")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("bulk"))
+		Expect(class.Dscp).To(Equal(uint8(8)))
+		name, _, err = qos.GetPodClass("", "Guaranteed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(BeEmpty())
+		_, _, err = qos.GetPodClass("unknown", "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Test Uplink Vlans", func() {
+		Expect((&UplinkInterfaceSpec{IsMain: true, VlanID: 100, InnerVlanID: 10}).Validate(nil)).To(Succeed())
+		Expect((&UplinkInterfaceSpec{IsMain: true, VlanID: 4095}).Validate(nil)).ToNot(Succeed())
+		Expect((&UplinkInterfaceSpec{IsMain: true, InnerVlanID: 10}).Validate(nil)).ToNot(Succeed())
+		Expect((&UplinkInterfaceSpec{IsMain: true, VlanID: 100, VlanProtocol: "802.1x"}).Validate(nil)).ToNot(Succeed())
+
+		Expect((&UplinkInterfaceSpec{VlanID: 100}).GetVlanProtocol()).To(Equal(netlink.VLAN_PROTOCOL_8021Q))
+		Expect((&UplinkInterfaceSpec{VlanID: 100, VlanProtocol: "802.1AD"}).GetVlanProtocol()).To(Equal(netlink.VLAN_PROTOCOL_8021AD))
+
+		state := &LinuxInterfaceState{InterfaceName: "eth1"}
+		Expect(state.GetHostInterfaceName()).To(Equal("eth1"))
+		state.Vlans = []LinuxVlan{{Name: "eth1.100", VlanID: 100}, {Name: "eth1.100.10", VlanID: 10}}
+		Expect(state.GetHostInterfaceName()).To(Equal("eth1.100.10"))
+	})
+
+	It("Test AutoTune", func() {
+		cfg := &CalicoVppInitialConfigConfigType{AutoTune: &VppAutoTuneConfigType{}}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(cfg.AutoTune.HugepagesPercent).To(Equal(50))
+		cfg.AutoTune = &VppAutoTuneConfigType{HugepagesPercent: 101}
+		Expect(cfg.Validate()).ToNot(Succeed())
+		cfg.AutoTune = &VppAutoTuneConfigType{MaxWorkers: -1}
+		Expect(cfg.Validate()).ToNot(Succeed())
+	})
+
+	It("Test AfXdp", func() {
+		spec := &UplinkInterfaceSpec{IsMain: true, AfXdp: &AfXdpConfig{BusyPoll: &AfXdpBusyPollConfig{}}}
+		spec.NumRxQueues = 4
+		Expect(spec.Validate(nil)).To(Succeed())
+		Expect(spec.AfXdp.Mode).To(Equal(AfXdpModeAuto))
+		Expect(spec.AfXdp.BusyPoll.NapiDeferHardIrqs).To(Equal(2))
+		spec.AfXdp = &AfXdpConfig{Mode: "turbo"}
+		Expect(spec.Validate(nil)).ToNot(Succeed())
+		spec.AfXdp = &AfXdpConfig{CombinedChannels: 2}
+		Expect(spec.Validate(nil)).ToNot(Succeed())
+	})
+
+	It("Test UplinkFailover", func() {
+		cfg := &CalicoVppInterfacesConfigType{UplinkFailover: &UplinkFailoverConfigType{}}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(*cfg.UplinkFailover.HoldDown).To(Equal(3 * time.Second))
+		Expect(*cfg.UplinkFailover.RecoverHoldDown).To(Equal(10 * time.Second))
+		spec := &UplinkInterfaceSpec{IsMain: true, IsStandby: true}
+		Expect(spec.Validate(nil)).ToNot(Succeed())
+
+		info := &VppManagerInfo{UplinkStatuses: map[string]UplinkStatus{
+			"eth0": {SwIfIndex: 1, IsMain: true, HasNodeAddresses: true},
+			"eth1": {SwIfIndex: 2, IsStandby: true},
+		}}
+		Expect(info.GetMainSwIfIndex()).To(Equal(uint32(1)))
+		info.UplinkStatuses["eth0"] = UplinkStatus{SwIfIndex: 1, IsMain: true}
+		info.UplinkStatuses["eth1"] = UplinkStatus{SwIfIndex: 2, IsStandby: true, HasNodeAddresses: true}
+		Expect(info.GetMainSwIfIndex()).To(Equal(uint32(2)))
+
+		status := &UplinkStatus{}
+		for i := 0; i < 2*maxUplinkTransitions; i++ {
+			status.AddTransition(UplinkTransition{From: "eth0", To: "eth1"})
+		}
+		Expect(status.Transitions).To(HaveLen(maxUplinkTransitions))
+	})
+
+	It("Test VppRestart", func() {
+		cfg := &CalicoVppInitialConfigConfigType{VppRestart: &VppRestartConfigType{}}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(cfg.VppRestart.MaxRestarts).To(Equal(5))
+		Expect(*cfg.VppRestart.RestartWindow).To(Equal(10 * time.Minute))
+		Expect(*cfg.VppRestart.AgentWaitTimeout).To(Equal(5 * time.Minute))
+		cfg.VppRestart = &VppRestartConfigType{MaxRestarts: -1}
+		Expect(cfg.Validate()).ToNot(Succeed())
+	})
+
+	It("Test Health", func() {
+		cfg := &CalicoVppHealthConfigType{}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(*cfg.Enabled).To(BeFalse())
+		Expect(cfg.AgentListenEndpoint).To(Equal(":8890"))
+		Expect(cfg.IsCheckEnabled("bgp")).To(BeTrue())
+		cfg = &CalicoVppHealthConfigType{DisabledChecks: []string{"bgp"}}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(cfg.IsCheckEnabled("bgp")).To(BeFalse())
+		cfg = &CalicoVppHealthConfigType{DisabledChecks: []string{"dns"}}
+		Expect(cfg.Validate()).ToNot(Succeed())
+	})
+
+	It("Test Hooks", func() {
+		timeout := 200 * time.Millisecond
+		hooks := GetCalicoVppHooks()
+		hooks.Timeout = &timeout
+		hooks.MaxOutputBytes = 8
+		hooks.Hooks = map[string]HookConfig{"BEFORE_VPP_RUN": {FailurePolicy: HookFailurePolicyFail}}
+		Expect(hooks.Validate()).To(Succeed())
+		hookTimeout, policy := hooks.GetHookConfig("VPP_RUNNING")
+		Expect(hookTimeout).To(Equal(timeout))
+		Expect(policy).To(Equal(HookFailurePolicyIgnore))
+		_, policy = hooks.GetHookConfig("BEFORE_VPP_RUN")
+		Expect(policy).To(Equal(HookFailurePolicyFail))
+
+		params := &VppManagerParams{UplinksSpecs: []UplinkInterfaceSpec{{InterfaceName: "eth0", IsMain: true}}}
+		conf := []*LinuxInterfaceState{{InterfaceName: "eth0", PciID: "0000:00:01.0"}}
+		log := logrus.New()
+
+		failing := "exit 3"
+		Expect(RunHook(&failing, "VPP_RUNNING", params, conf, log)).To(Succeed())
+		Expect(RunHook(&failing, "BEFORE_VPP_RUN", params, conf, log)).ToNot(Succeed())
+
+		hanging := "sleep 10"
+		start := time.Now()
+		err := RunHook(&hanging, "BEFORE_VPP_RUN", params, conf, log)
+		Expect(err).To(MatchError(ContainSubstring("timed out")))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+		dir, err := os.MkdirTemp("", "hooks")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		output := filepath.Join(dir, "context.json")
+		script := "cp $CALICOVPP_HOOK_CONTEXT " + output + " && [ $0 = $CALICOVPP_HOOK ] && echo __PCI_DEVICE_ID__"
+		Expect(RunHook(&script, "BEFORE_VPP_RUN", params, conf, log)).To(Succeed())
+		data, err := os.ReadFile(output)
+		Expect(err).ToNot(HaveOccurred())
+		hookContext := &HookContext{}
+		Expect(json.Unmarshal(data, hookContext)).To(Succeed())
+		Expect(hookContext.Hook).To(Equal("BEFORE_VPP_RUN"))
+		Expect(hookContext.Uplinks).To(HaveLen(1))
+		Expect(hookContext.Uplinks[0].Spec.InterfaceName).To(Equal("eth0"))
+		Expect(hookContext.Uplinks[0].PciID).To(Equal("0000:00:01.0"))
+
+		buffer := &limitedBuffer{limit: 8}
+		_, _ = buffer.Write([]byte(strings.Repeat("a", 6)))
+		_, _ = buffer.Write([]byte(strings.Repeat("b", 6)))
+		Expect(buffer.String()).To(Equal("aaaaaabb\n[4 bytes truncated]"))
+
+		hooks.Hooks = map[string]HookConfig{"AFTER_VPP_RUN": {}}
+		Expect(hooks.Validate()).ToNot(Succeed())
+		hooks.Hooks = map[string]HookConfig{"VPP_RUNNING": {FailurePolicy: "retry"}}
+		Expect(hooks.Validate()).ToNot(Succeed())
+		hooks.Hooks = nil
+	})
 })
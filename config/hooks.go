@@ -0,0 +1,294 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// HookNames are the names hooks are run with, as their $0
+var HookNames = []string{
+	"BEFORE_IF_READ",
+	"BEFORE_VPP_RUN",
+	"BEFORE_UPLINK_CREATE",
+	"AFTER_UPLINK_CREATE",
+	"VPP_RUNNING",
+	"BEFORE_RESTORE",
+	"VPP_DONE_OK",
+	"VPP_ERRORED",
+}
+
+type HookFailurePolicy string
+
+const (
+	// HookFailurePolicyIgnore logs hook failures and carries on
+	HookFailurePolicyIgnore HookFailurePolicy = "ignore"
+	// HookFailurePolicyFail stops VPP when a hook fails
+	HookFailurePolicyFail HookFailurePolicy = "fail"
+)
+
+type HookConfig struct {
+	// Timeout is the time after which the hook is killed
+	Timeout *time.Duration `json:"timeout,omitempty"`
+	// FailurePolicy is what to do when the hook fails or times out
+	FailurePolicy HookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+type CalicoVppHooksConfigType struct {
+	// HookConfig applies to all hooks. Timeout defaults to
+	// 5 minutes and FailurePolicy to ignore
+	HookConfig
+	// Hooks overrides HookConfig per hook name
+	Hooks map[string]HookConfig `json:"hooks,omitempty"`
+	// MaxOutputBytes limits the stdout and stderr of hooks
+	// logged by vpp-manager. Defaults to 16kB
+	MaxOutputBytes int `json:"maxOutputBytes"`
+}
+
+func (cfg *HookConfig) validate() error {
+	if cfg.Timeout != nil && *cfg.Timeout <= 0 {
+		return errors.Errorf("timeout should be positive, got %s", *cfg.Timeout)
+	}
+	switch cfg.FailurePolicy {
+	case "", HookFailurePolicyIgnore, HookFailurePolicyFail:
+		return nil
+	default:
+		return errors.Errorf("unknown failurePolicy %s, should be %s or %s",
+			cfg.FailurePolicy, HookFailurePolicyIgnore, HookFailurePolicyFail)
+	}
+}
+
+func (cfg *CalicoVppHooksConfigType) Validate() (err error) {
+	cfg.Timeout = DefaultToPtr(cfg.Timeout, 5*time.Minute)
+	if cfg.FailurePolicy == "" {
+		cfg.FailurePolicy = HookFailurePolicyIgnore
+	}
+	if cfg.MaxOutputBytes == 0 {
+		cfg.MaxOutputBytes = 16384
+	}
+	err = cfg.HookConfig.validate()
+	if err != nil {
+		return err
+	}
+	for name, hookConfig := range cfg.Hooks {
+		if !slices.Contains(HookNames, name) {
+			return errors.Errorf("unknown hook %s, should be one of %v", name, HookNames)
+		}
+		err = hookConfig.validate()
+		if err != nil {
+			return errors.Wrapf(err, "invalid hook %s", name)
+		}
+	}
+	return nil
+}
+
+// GetHookConfig returns the timeout and failure policy of a hook
+func (cfg *CalicoVppHooksConfigType) GetHookConfig(hookName string) (timeout time.Duration, policy HookFailurePolicy) {
+	timeout, policy = *cfg.Timeout, cfg.FailurePolicy
+	if hookConfig, ok := cfg.Hooks[hookName]; ok {
+		if hookConfig.Timeout != nil {
+			timeout = *hookConfig.Timeout
+		}
+		if hookConfig.FailurePolicy != "" {
+			policy = hookConfig.FailurePolicy
+		}
+	}
+	return timeout, policy
+}
+
+func (cfg *CalicoVppHooksConfigType) String() string {
+	b, _ := json.MarshalIndent(cfg, "", "  ")
+	return string(b)
+}
+
+// HookContext is written as JSON in the file named by the
+// CALICOVPP_HOOK_CONTEXT environment variable of hooks
+type HookContext struct {
+	Hook     string              `json:"hook"`
+	NodeName string              `json:"nodeName"`
+	Status   vppManagerStatus    `json:"status"`
+	VppPid   int                 `json:"vppPid,omitempty"`
+	Uplinks  []HookUplinkContext `json:"uplinks"`
+}
+
+type HookUplinkContext struct {
+	Spec UplinkInterfaceSpec `json:"spec"`
+	// SwIfIndex is set once VPP created the uplink
+	SwIfIndex uint32 `json:"swIfIndex,omitempty"`
+	// The fields below are the linux configuration of the uplink,
+	// once read by vpp-manager
+	PciID        string   `json:"pciId,omitempty"`
+	Driver       string   `json:"driver,omitempty"`
+	HardwareAddr string   `json:"hardwareAddr,omitempty"`
+	Mtu          int      `json:"mtu,omitempty"`
+	Addresses    []string `json:"addresses,omitempty"`
+	Routes       []string `json:"routes,omitempty"`
+	Vlans        []string `json:"vlans,omitempty"`
+	BondMembers  []string `json:"bondMembers,omitempty"`
+}
+
+func getHookContext(hookName string, params *VppManagerParams, conf []*LinuxInterfaceState) *HookContext {
+	hookContext := &HookContext{
+		Hook:     hookName,
+		NodeName: *NodeName,
+		Status:   Info.Status,
+		VppPid:   Info.VppPid,
+		Uplinks:  make([]HookUplinkContext, 0),
+	}
+	for idx, spec := range params.UplinksSpecs {
+		uplink := HookUplinkContext{Spec: spec, SwIfIndex: spec.SwIfIndex}
+		if idx < len(conf) && conf[idx] != nil {
+			ifState := conf[idx]
+			uplink.PciID = ifState.PciID
+			uplink.Driver = ifState.Driver
+			uplink.HardwareAddr = ifState.HardwareAddr.String()
+			uplink.Mtu = ifState.Mtu
+			for _, addr := range ifState.Addresses {
+				uplink.Addresses = append(uplink.Addresses, addr.IPNet.String())
+			}
+			for _, route := range ifState.Routes {
+				uplink.Routes = append(uplink.Routes, route.String())
+			}
+			for _, vlan := range ifState.Vlans {
+				uplink.Vlans = append(uplink.Vlans, vlan.Name)
+			}
+			if ifState.Bond != nil {
+				for _, member := range ifState.Bond.Members {
+					uplink.BondMembers = append(uplink.BondMembers, member.InterfaceName)
+				}
+			}
+		}
+		hookContext.Uplinks = append(hookContext.Uplinks, uplink)
+	}
+	return hookContext
+}
+
+func writeHookContext(hookName string, params *VppManagerParams, conf []*LinuxInterfaceState) (string, error) {
+	data, err := json.MarshalIndent(getHookContext(hookName, params, conf), "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "cannot encode hook context")
+	}
+	file, err := os.CreateTemp("", "calicovpp-hook-*.json")
+	if err != nil {
+		return "", errors.Wrap(err, "cannot create hook context file")
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	if err != nil {
+		os.Remove(file.Name())
+		return "", errors.Wrap(err, "cannot write hook context file")
+	}
+	return file.Name(), nil
+}
+
+// limitedBuffer keeps the first bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		b.truncated += len(p) - max(room, 0)
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated > 0 {
+		return fmt.Sprintf("%s\n[%d bytes truncated]", b.Buffer.String(), b.truncated)
+	}
+	return b.Buffer.String()
+}
+
+func runHook(hookScript string, hookName string, params *VppManagerParams, conf []*LinuxInterfaceState, log *logrus.Logger) error {
+	template, err := TemplateScriptReplace(hookScript, params, conf)
+	if err != nil {
+		return err
+	}
+	contextFile, err := writeHookContext(hookName, params, conf)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(contextFile)
+
+	timeout, _ := GetCalicoVppHooks().GetHookConfig(hookName)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", template, hookName)
+	cmd.Env = append(os.Environ(), "CALICOVPP_HOOK="+hookName, "CALICOVPP_HOOK_CONTEXT="+contextFile)
+	// Kill the whole process group on timeout, not only bash
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	stdout := &limitedBuffer{limit: GetCalicoVppHooks().MaxOutputBytes}
+	stderr := &limitedBuffer{limit: GetCalicoVppHooks().MaxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	if stdout.Len() > 0 {
+		log.Infof("Hook %s stdout:\n%s", hookName, stdout)
+	}
+	if stderr.Len() > 0 {
+		log.Infof("Hook %s stderr:\n%s", hookName, stderr)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Hook %s done in %s", hookName, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// RunHook runs a hook script if it is set. Failures are logged, and
+// returned when the failure policy of the hook is fail
+func RunHook(hookScript *string, hookName string, params *VppManagerParams, conf []*LinuxInterfaceState, log *logrus.Logger) error {
+	if *hookScript == "" {
+		return nil
+	}
+	err := runHook(*hookScript, hookName, params, conf, log)
+	if err == nil {
+		return nil
+	}
+	_, policy := GetCalicoVppHooks().GetHookConfig(hookName)
+	if policy == HookFailurePolicyFail {
+		return errors.Wrapf(err, "hook %s failed", hookName)
+	}
+	log.Warnf("Running hook %s errored with %s", hookName, err)
+	return nil
+}
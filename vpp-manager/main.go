@@ -16,18 +16,21 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/health"
 	"github.com/projectcalico/vpp-dataplane/v3/config"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/startup"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/uplink"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
-
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -42,6 +45,8 @@ var (
 	signals     chan os.Signal
 	/* Was VPP terminated by us ? */
 	internalKill bool
+	/* Were we asked to terminate ? */
+	externalTerminate bool
 	/* Increasing index for timeout */
 	currentVPPIndex int
 	/* Allow to stop sigchld handling for given VPP */
@@ -111,6 +116,9 @@ func handleSignals() {
 				log.Infof("Ignoring SIGCHLD for pid %d", pid)
 			}
 		} else if s != syscall.SIGPIPE {
+			if !internalKill && (s == syscall.SIGTERM || s == syscall.SIGINT || s == syscall.SIGQUIT) {
+				externalTerminate = true
+			}
 			/* special case
 			   for SIGTERM, which doesn't kill vpp quick enough */
 			if s == syscall.SIGTERM {
@@ -144,6 +152,20 @@ func makeNewVPPIndex() {
 	VPPgotTimeout[currentVPPIndex] = false
 }
 
+func serveHealth() {
+	healthServer := health.NewHealthServer("vpp-manager", config.GetCalicoVppHealth().VppManagerListenEndpoint,
+		log.WithFields(logrus.Fields{"component": "health"}))
+	health.NewVppChecker(health.GetReadyUplinks, log.WithFields(logrus.Fields{"component": "health-vpp"})).AddChecks(healthServer)
+	healthServer.AddReadinessCheck("vpp-manager", func() error {
+		_, err := health.GetReadyUplinks()
+		return err
+	})
+	var t tomb.Tomb
+	t.Go(func() error {
+		return healthServer.ServeHealth(&t)
+	})
+}
+
 func main() {
 	log = logrus.New()
 
@@ -158,7 +180,10 @@ func main() {
 
 	params := startup.NewVppManagerParams()
 
-	config.RunHook(config.HookScriptBeforeIfRead, "BEFORE_IF_READ", params, log)
+	err = config.RunHook(config.HookScriptBeforeIfRead, "BEFORE_IF_READ", params, nil, log)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
 	err = utils.ClearVppManagerFiles()
 	if err != nil {
@@ -182,6 +207,7 @@ func main() {
 
 	runningCond = sync.NewCond(&sync.Mutex{})
 	go handleSignals()
+	serveHealth()
 
 	startup.PrintVppManagerConfig(params, confs)
 
@@ -190,16 +216,37 @@ func main() {
 	makeNewVPPIndex()
 
 	if len(params.UplinksSpecs) == 1 && params.UplinksSpecs[0].VppDriver == "" {
-		for _, driver := range uplink.SupportedUplinkDrivers(params, confs[0], &params.UplinksSpecs[0]) {
+		driverState, err := uplink.LoadUplinkDriverState(config.UplinkDriverStateFile)
+		if err != nil {
+			log.Warnf("Error loading uplink driver state, ignoring it: %v", err)
+		}
+		drivers, reasons := driverState.OrderDrivers(
+			uplink.GetUplinkDriverKey(params, confs[0]),
+			uplink.SupportedUplinkDrivers(params, confs[0], &params.UplinksSpecs[0]),
+		)
+		var lastFailed uplink.UplinkDriver
+		for idx, driver := range drivers {
 			err := utils.CleanupCoreFiles(config.GetCalicoVppInitialConfig().CorePattern, maxCoreFiles)
 			if err != nil {
 				log.Errorf("CleanupCoreFiles errored %s", err)
 			}
 
+			reason := reasons[idx]
+			if lastFailed != nil {
+				reason = fmt.Sprintf("%s, after %s failed", reason, lastFailed.GetName())
+			}
+			log.Infof("Trying uplink driver %s: %s", driver.GetName(), reason)
+			runner.driverReasons[confs[0].InterfaceName] = reason
+
 			internalKill = false
-			err = runner.Run([]uplink.UplinkDriver{driver})
+			err = runner.RunWithRestarts([]uplink.UplinkDriver{driver})
 			if err != nil {
-				config.RunHook(config.HookScriptVppErrored, "VPP_ERRORED", params, log)
+				runner.recordDriverFailure(0, driver, err)
+				lastFailed = driver
+				hookErr := config.RunHook(config.HookScriptVppErrored, "VPP_ERRORED", params, confs, log)
+				if hookErr != nil {
+					log.Error(hookErr)
+				}
 				log.Errorf("VPP(%s) run failed with %s", driver.GetName(), err)
 			}
 			if vppProcess != nil && !internalKill {
@@ -219,11 +266,22 @@ func main() {
 		for idx := 0; idx < len(params.UplinksSpecs); idx++ {
 			drivers = append(drivers, uplink.NewUplinkDriver(params.UplinksSpecs[idx].VppDriver,
 				params, confs[idx], &params.UplinksSpecs[idx]))
+			if params.UplinksSpecs[idx].VppDriver == "" {
+				runner.driverReasons[confs[idx].InterfaceName] = "default driver"
+			} else {
+				runner.driverReasons[confs[idx].InterfaceName] = "configured with vppDriver"
+			}
 		}
 
-		err = runner.Run(drivers)
+		err = runner.RunWithRestarts(drivers)
 		if err != nil {
-			config.RunHook(config.HookScriptVppErrored, "VPP_ERRORED", params, log)
+			for idx, driver := range drivers {
+				runner.recordDriverFailure(idx, driver, err)
+			}
+			hookErr := config.RunHook(config.HookScriptVppErrored, "VPP_ERRORED", params, confs, log)
+			if hookErr != nil {
+				log.Error(hookErr)
+			}
 			log.Errorf("VPP run failed with %v", err)
 		}
 
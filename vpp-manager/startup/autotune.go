@@ -0,0 +1,200 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startup
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/vppconf"
+)
+
+const (
+	// defaultBufferDataSize is the data size of VPP buffers when
+	// the configuration does not set one
+	defaultBufferDataSize = 2048
+	// bufferOverhead is the metadata and headroom VPP adds to the
+	// data of each buffer
+	bufferOverhead = 384
+	// minBuffersPerNuma is the default VPP buffer pool, auto-sizing
+	// never shrinks it
+	minBuffersPerNuma = 16384
+)
+
+// NumaResources is what auto-sizing knows about the node
+type NumaResources struct {
+	// CpusetCPUs are the cpus the container can run on
+	CpusetCPUs []int
+	// NicNumaNode is the NUMA node of the main uplink NIC, -1 if unknown
+	NicNumaNode int
+	// NicNumaNodeCPUs are the cpus of NicNumaNode
+	NicNumaNodeCPUs []int
+	// HugepageSizeKB is the default hugepage size
+	HugepageSizeKB int
+	// FreeHugepages is the number of free default hugepages on NicNumaNode
+	FreeHugepages int
+}
+
+func getUplinkPciID(ifState *config.LinuxInterfaceState) string {
+	if ifState.PciID != "" {
+		return ifState.PciID
+	}
+	if ifState.Bond != nil {
+		for _, member := range ifState.Bond.Members {
+			if member.PciID != "" {
+				return member.PciID
+			}
+		}
+	}
+	return ""
+}
+
+// GetNumaResources reads the cpuset, NUMA and hugepage resources of the node
+// from sysfs, leaving unknown what cannot be read
+func GetNumaResources(conf []*config.LinuxInterfaceState, params *config.VppManagerParams) (res *NumaResources) {
+	res = &NumaResources{NicNumaNode: -1}
+	cpus, err := utils.GetCpusetCPUs()
+	if err != nil {
+		log.Warnf("autotune: could not read cpuset: %v", err)
+	} else {
+		res.CpusetCPUs = cpus
+	}
+
+	for idx, ifState := range conf {
+		if !params.UplinksSpecs[idx].IsMain {
+			continue
+		}
+		pciID := getUplinkPciID(ifState)
+		if pciID == "" {
+			log.Infof("autotune: uplink %s has no PCI address, NUMA node unknown", ifState.InterfaceName)
+			break
+		}
+		node, err := utils.GetPciNumaNode(pciID)
+		if err != nil {
+			log.Warnf("autotune: could not read NUMA node of %s: %v", pciID, err)
+			break
+		}
+		res.NicNumaNode = node
+	}
+
+	numaNode := res.NicNumaNode
+	if numaNode >= 0 {
+		res.NicNumaNodeCPUs, err = utils.GetNumaNodeCPUs(numaNode)
+		if err != nil {
+			log.Warnf("autotune: %v", err)
+		}
+	} else {
+		// Non NUMA systems report -1, hugepages are on node 0
+		numaNode = 0
+	}
+
+	res.HugepageSizeKB, err = utils.GetDefaultHugepageSize()
+	if err != nil {
+		log.Warnf("autotune: %v", err)
+		return res
+	}
+	res.FreeHugepages, err = utils.GetNumaFreeHugepages(numaNode, res.HugepageSizeKB)
+	if err != nil {
+		log.Warnf("autotune: %v", err)
+	}
+	return res
+}
+
+func intersectCPUs(cpus []int, others []int) []int {
+	inOthers := make(map[int]bool)
+	for _, cpu := range others {
+		inOthers[cpu] = true
+	}
+	ret := make([]int, 0)
+	for _, cpu := range cpus {
+		if inOthers[cpu] {
+			ret = append(ret, cpu)
+		}
+	}
+	return ret
+}
+
+// AutoTuneVppConfig chooses the VPP main and worker cores on the NIC NUMA node
+// and sizes the buffer pool to the free hugepages
+func AutoTuneVppConfig(vppConf *vppconf.VppConfig, res *NumaResources, autoTune *config.VppAutoTuneConfigType) {
+	autoTuneCPUs(vppConf, res, autoTune)
+	autoTuneBuffers(vppConf, res, autoTune)
+}
+
+func autoTuneCPUs(vppConf *vppconf.VppConfig, res *NumaResources, autoTune *config.VppAutoTuneConfigType) {
+	if vppConf.CPU.MainCore != nil || vppConf.CPU.CorelistWorkers != "" {
+		log.Infof("autotune: keeping the cores pinned by the configuration template")
+		return
+	}
+	if len(res.CpusetCPUs) == 0 {
+		log.Infof("autotune: cpuset unknown, not choosing cores")
+		return
+	}
+	cpus := res.CpusetCPUs
+	if res.NicNumaNode < 0 {
+		log.Infof("autotune: NIC NUMA node unknown, using cpuset %s", utils.FormatCPUList(cpus))
+	} else if numaCPUs := intersectCPUs(cpus, res.NicNumaNodeCPUs); len(numaCPUs) == 0 {
+		log.Warnf("autotune: cpuset %s has no cpu on NIC NUMA node %d, using it anyway",
+			utils.FormatCPUList(cpus), res.NicNumaNode)
+	} else {
+		log.Infof("autotune: using cpus %s of cpuset %s on NIC NUMA node %d",
+			utils.FormatCPUList(numaCPUs), utils.FormatCPUList(cpus), res.NicNumaNode)
+		cpus = numaCPUs
+	}
+
+	if vppConf.CPU.Workers != nil {
+		log.Infof("autotune: replacing 'workers %d' of the configuration template", *vppConf.CPU.Workers)
+		vppConf.CPU.Workers = nil
+	}
+	mainCore := cpus[0]
+	vppConf.CPU.MainCore = &mainCore
+	vppConf.CPU.Relative = false
+	workers := cpus[1:]
+	if autoTune.MaxWorkers > 0 && len(workers) > autoTune.MaxWorkers {
+		log.Infof("autotune: capping %d workers to maxWorkers %d", len(workers), autoTune.MaxWorkers)
+		workers = workers[:autoTune.MaxWorkers]
+	}
+	if len(workers) > 0 {
+		vppConf.CPU.CorelistWorkers = utils.FormatCPUList(workers)
+	}
+	log.Infof("autotune: main-core %d, %d workers on cpus %s", mainCore, len(workers), utils.FormatCPUList(workers))
+}
+
+func autoTuneBuffers(vppConf *vppconf.VppConfig, res *NumaResources, autoTune *config.VppAutoTuneConfigType) {
+	if vppConf.Buffers.NoHugetlb {
+		log.Infof("autotune: buffers do not use hugepages, keeping buffers-per-numa")
+		return
+	}
+	if res.FreeHugepages == 0 || res.HugepageSizeKB == 0 {
+		log.Infof("autotune: no free hugepages, keeping buffers-per-numa")
+		return
+	}
+	dataSize := vppConf.Buffers.DefaultDataSize
+	if dataSize == 0 {
+		dataSize = defaultBufferDataSize
+	}
+	available := res.FreeHugepages * res.HugepageSizeKB * 1024 * autoTune.HugepagesPercent / 100
+	buffers := available / (dataSize + bufferOverhead)
+	if buffers < minBuffersPerNuma {
+		log.Warnf("autotune: %d%% of %d free %dkB hugepages only fit %d buffers, keeping buffers-per-numa",
+			autoTune.HugepagesPercent, res.FreeHugepages, res.HugepageSizeKB, buffers)
+		return
+	}
+	log.Infof("autotune: %d%% of %d free %dkB hugepages fit %d buffers of %d bytes, replacing buffers-per-numa %d",
+		autoTune.HugepagesPercent, res.FreeHugepages, res.HugepageSizeKB, buffers, dataSize, vppConf.Buffers.BuffersPerNuma)
+	vppConf.Buffers.BuffersPerNuma = buffers
+}
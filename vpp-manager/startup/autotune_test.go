@@ -0,0 +1,87 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startup
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/vppconf"
+)
+
+func TestAutoTune(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "vpp-manager startup tests")
+}
+
+func parseConf(input string) *vppconf.VppConfig {
+	conf, err := vppconf.Parse(input)
+	Expect(err).ToNot(HaveOccurred())
+	return conf
+}
+
+var _ = Describe("Test AutoTuneVppConfig", func() {
+	autoTune := &config.VppAutoTuneConfigType{HugepagesPercent: 50}
+
+	It("Picks cores on the NIC NUMA node", func() {
+		conf := parseConf("cpu { workers 0 }")
+		AutoTuneVppConfig(conf, &NumaResources{
+			CpusetCPUs:      []int{2, 3, 4, 5, 10, 11, 12},
+			NicNumaNode:     1,
+			NicNumaNodeCPUs: []int{8, 9, 10, 11, 12, 13, 14, 15},
+		}, autoTune)
+		Expect(*conf.CPU.MainCore).To(Equal(10))
+		Expect(conf.CPU.Workers).To(BeNil())
+		Expect(conf.CPU.CorelistWorkers).To(Equal("11-12"))
+		Expect(conf.Validate()).To(Succeed())
+	})
+
+	It("Caps workers and falls back to the cpuset", func() {
+		conf := parseConf("")
+		AutoTuneVppConfig(conf, &NumaResources{
+			CpusetCPUs:      []int{0, 1, 2, 3},
+			NicNumaNode:     1,
+			NicNumaNodeCPUs: []int{4, 5, 6, 7},
+		}, &config.VppAutoTuneConfigType{MaxWorkers: 2, HugepagesPercent: 50})
+		Expect(*conf.CPU.MainCore).To(Equal(0))
+		Expect(conf.CPU.CorelistWorkers).To(Equal("1-2"))
+	})
+
+	It("Keeps pinned cores", func() {
+		conf := parseConf("cpu { main-core 1 corelist-workers 2-3 }")
+		AutoTuneVppConfig(conf, &NumaResources{CpusetCPUs: []int{4, 5}, NicNumaNode: -1}, autoTune)
+		Expect(*conf.CPU.MainCore).To(Equal(1))
+		Expect(conf.CPU.CorelistWorkers).To(Equal("2-3"))
+	})
+
+	It("Sizes buffers to the free hugepages", func() {
+		conf := parseConf("buffers { buffers-per-numa 131072 }")
+		// 512 free 2MB pages, half of them for 2432 byte buffers
+		AutoTuneVppConfig(conf, &NumaResources{HugepageSizeKB: 2048, FreeHugepages: 512}, autoTune)
+		Expect(conf.Buffers.BuffersPerNuma).To(Equal(512 * 2048 * 1024 / 2 / 2432))
+
+		conf = parseConf("buffers { buffers-per-numa 131072 }")
+		AutoTuneVppConfig(conf, &NumaResources{HugepageSizeKB: 2048, FreeHugepages: 16}, autoTune)
+		Expect(conf.Buffers.BuffersPerNuma).To(Equal(131072))
+
+		conf = parseConf("buffers { no-hugetlb }")
+		AutoTuneVppConfig(conf, &NumaResources{HugepageSizeKB: 2048, FreeHugepages: 512}, autoTune)
+		Expect(conf.Buffers.BuffersPerNuma).To(Equal(0))
+	})
+})
@@ -80,27 +80,145 @@ func GetInterfaceConfig(params *config.VppManagerParams) (conf []*config.LinuxIn
 	return conf, nil
 }
 
+// getLinuxVlans returns the VLAN devices of a VLAN uplink, outermost first
+func getLinuxVlans(parent netlink.Link, ifSpec config.UplinkInterfaceSpec) ([]config.LinuxVlan, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list interfaces")
+	}
+	findVlan := func(parentIndex int, vlanID int, protocol netlink.VlanProtocol) *netlink.Vlan {
+		for _, link := range links {
+			vlan, ok := link.(*netlink.Vlan)
+			if ok && vlan.ParentIndex == parentIndex && vlan.VlanId == vlanID && vlan.VlanProtocol == protocol {
+				return vlan
+			}
+		}
+		return nil
+	}
+	vlan := findVlan(parent.Attrs().Index, ifSpec.VlanID, ifSpec.GetVlanProtocol())
+	if vlan == nil {
+		return nil, errors.Errorf("cannot find vlan %d (%s) on %s", ifSpec.VlanID, ifSpec.GetVlanProtocol(), ifSpec.InterfaceName)
+	}
+	vlans := []config.LinuxVlan{{
+		Name:     vlan.Name,
+		VlanID:   vlan.VlanId,
+		Protocol: vlan.VlanProtocol,
+		Mtu:      vlan.MTU,
+	}}
+	if ifSpec.InnerVlanID != 0 {
+		inner := findVlan(vlan.Index, ifSpec.InnerVlanID, netlink.VLAN_PROTOCOL_8021Q)
+		if inner == nil {
+			return nil, errors.Errorf("cannot find inner vlan %d on %s", ifSpec.InnerVlanID, vlan.Name)
+		}
+		vlans = append(vlans, config.LinuxVlan{
+			Name:     inner.Name,
+			VlanID:   inner.VlanId,
+			Protocol: inner.VlanProtocol,
+			Mtu:      inner.MTU,
+		})
+	}
+	return vlans, nil
+}
+
+// getLinuxBond returns the configuration and members of a bond uplink
+func getLinuxBond(bond *netlink.Bond, ifSpec config.UplinkInterfaceSpec) (*config.LinuxBond, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list interfaces")
+	}
+	linuxBond := &config.LinuxBond{
+		HardwareAddr:   bond.HardwareAddr,
+		Mtu:            bond.MTU,
+		Mode:           bond.Mode,
+		XmitHashPolicy: bond.XmitHashPolicy,
+		LacpRate:       bond.LacpRate,
+		Miimon:         bond.Miimon,
+		UpDelay:        bond.UpDelay,
+		DownDelay:      bond.DownDelay,
+	}
+	for _, link := range links {
+		if link.Attrs().MasterIndex != bond.Index {
+			continue
+		}
+		member := &config.LinuxInterfaceState{
+			InterfaceName: link.Attrs().Name,
+			IsUp:          (link.Attrs().Flags & net.FlagUp) != 0,
+			HardwareAddr:  link.Attrs().HardwareAddr,
+			PromiscOn:     link.Attrs().Promisc == 1,
+			NumTxQueues:   link.Attrs().NumTxQueues,
+			NumRxQueues:   link.Attrs().NumRxQueues,
+			Mtu:           link.Attrs().MTU,
+		}
+		// Members share the bond address while enslaved
+		if slave, ok := link.Attrs().Slave.(*netlink.BondSlave); ok && slave.PermHardwareAddr != nil {
+			member.HardwareAddr = slave.PermHardwareAddr
+		}
+		_, member.IsTunTap = link.(*netlink.Tuntap)
+		_, member.IsVeth = link.(*netlink.Veth)
+		err = loadPciConfig(member, ifSpec)
+		if err != nil {
+			return nil, err
+		}
+		linuxBond.Members = append(linuxBond.Members, member)
+	}
+	if len(linuxBond.Members) == 0 {
+		return nil, errors.Errorf("bond %s has no members", bond.Name)
+	}
+	return linuxBond, nil
+}
+
+func loadPciConfig(conf *config.LinuxInterfaceState, ifSpec config.UplinkInterfaceSpec) error {
+	pciID, err := utils.GetInterfacePciID(conf.InterfaceName)
+	// We allow PCI not to be found e.g for AF_PACKET
+	if err != nil || pciID == "" {
+		log.Infof("No pci device for interface %s", conf.InterfaceName)
+		return nil
+	}
+	conf.PciID = pciID
+	driver, err := utils.GetDriverNameFromPci(pciID)
+	if err != nil {
+		return err
+	}
+	conf.Driver = driver
+	if ifSpec.NewDriverName != "" && ifSpec.NewDriverName != conf.Driver {
+		conf.DoSwapDriver = true
+	}
+	return nil
+}
+
 func loadInterfaceConfigFromLinux(ifSpec config.UplinkInterfaceSpec) (*config.LinuxInterfaceState, error) {
 	conf := config.LinuxInterfaceState{}
 	link, err := netlink.LinkByName(ifSpec.InterfaceName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot find interface named %s", ifSpec.InterfaceName)
 	}
-	conf.IsUp = (link.Attrs().Flags & net.FlagUp) != 0
+	// The addresses of VLAN uplinks are on the innermost VLAN device
+	addrLink := link
+	if ifSpec.IsVlan() {
+		conf.Vlans, err = getLinuxVlans(link, ifSpec)
+		if err != nil {
+			return nil, err
+		}
+		addrLink, err = netlink.LinkByName(conf.Vlans[len(conf.Vlans)-1].Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot find vlan interface")
+		}
+	}
+	conf.IsUp = (link.Attrs().Flags&net.FlagUp) != 0 && (addrLink.Attrs().Flags&net.FlagUp) != 0
 	if conf.IsUp {
 		// Grab addresses and routes
-		conf.Addresses, err = netlink.AddrList(link, netlink.FAMILY_ALL)
+		conf.Addresses, err = netlink.AddrList(addrLink, netlink.FAMILY_ALL)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot list %s addresses", ifSpec.InterfaceName)
+			return nil, errors.Wrapf(err, "cannot list %s addresses", addrLink.Attrs().Name)
 		}
 
-		conf.Routes, err = netlink.RouteList(link, netlink.FAMILY_ALL)
+		conf.Routes, err = netlink.RouteList(addrLink, netlink.FAMILY_ALL)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot list %s routes", ifSpec.InterfaceName)
+			return nil, errors.Wrapf(err, "cannot list %s routes", addrLink.Attrs().Name)
 		}
 		conf.SortRoutes()
 	}
-	conf.HardwareAddr = link.Attrs().HardwareAddr
+	conf.HardwareAddr = addrLink.Attrs().HardwareAddr
 	conf.NodeIP4 = getNodeAddress(&conf, false /* isV6 */)
 	conf.NodeIP6 = getNodeAddress(&conf, true /* isV6 */)
 	conf.Hasv4 = (conf.NodeIP4 != "")
@@ -113,26 +231,27 @@ func loadInterfaceConfigFromLinux(ifSpec config.UplinkInterfaceSpec) (*config.Li
 	conf.PromiscOn = link.Attrs().Promisc == 1
 	conf.NumTxQueues = link.Attrs().NumTxQueues
 	conf.NumRxQueues = link.Attrs().NumRxQueues
-	conf.Mtu = link.Attrs().MTU
+	conf.Mtu = addrLink.Attrs().MTU
 	_, conf.IsTunTap = link.(*netlink.Tuntap)
 	_, conf.IsVeth = link.(*netlink.Veth)
+	conf.InterfaceName = ifSpec.InterfaceName
 
-	pciID, err := utils.GetInterfacePciID(ifSpec.InterfaceName)
-	// We allow PCI not to be found e.g for AF_PACKET
-	if err != nil || pciID == "" {
-		log.Infof("No pci device for interface %s", ifSpec.InterfaceName)
-	} else {
-		conf.PciID = pciID
-		driver, err := utils.GetDriverNameFromPci(pciID)
+	if bond, ok := link.(*netlink.Bond); ok {
+		conf.Bond, err = getLinuxBond(bond, ifSpec)
 		if err != nil {
 			return nil, err
 		}
-		conf.Driver = driver
-		if ifSpec.NewDriverName != "" && ifSpec.NewDriverName != conf.Driver {
-			conf.DoSwapDriver = true
+		for _, member := range conf.Bond.Members {
+			conf.IsTunTap = conf.IsTunTap || member.IsTunTap
+			conf.IsVeth = conf.IsVeth || member.IsVeth
 		}
+		return &conf, nil
+	}
+
+	err = loadPciConfig(&conf, ifSpec)
+	if err != nil {
+		return nil, err
 	}
-	conf.InterfaceName = ifSpec.InterfaceName
 	return &conf, nil
 }
 
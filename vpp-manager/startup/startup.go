@@ -57,12 +57,22 @@ func NewVppManagerParams() *config.VppManagerParams {
 		log.Panicf("Too many interfaces tagged Main")
 	}
 
+	mainPhysicalNetworkName := ""
+	for _, uplink := range params.UplinksSpecs {
+		if uplink.IsMain {
+			mainPhysicalNetworkName = uplink.PhysicalNetworkName
+		}
+	}
 	for index, uplink := range params.UplinksSpecs {
 		uplink.SetUplinkInterfaceIndex(index)
 		err := uplink.Validate(nil)
 		if err != nil {
 			log.Panicf("error validating uplink %s %s", uplink.String(), err)
 		}
+		// Node addresses move to standby uplinks in the main uplink VRF
+		if uplink.IsStandby && uplink.PhysicalNetworkName != mainPhysicalNetworkName {
+			log.Panicf("standby uplink %s must be in the physical network of the main uplink", uplink.String())
+		}
 	}
 
 	/* Drivers */
@@ -116,6 +126,9 @@ func PrintVppManagerConfig(params *config.VppManagerParams, confs []*config.Linu
 		log.Infof("New Drive Name:      %s", ifSpec.NewDriverName)
 		log.Infof("PHY target #Queues   rx:%d tx:%d", ifSpec.NumRxQueues, ifSpec.NumTxQueues)
 		log.Infof("Tap MTU:             %d", ifSpec.Mtu)
+		if ifSpec.IsVlan() {
+			log.Infof("Vlan:                %d inner:%d %s", ifSpec.VlanID, ifSpec.InnerVlanID, ifSpec.GetVlanProtocol())
+		}
 
 	}
 	for _, conf := range confs {
@@ -134,5 +147,12 @@ func PrintVppManagerConfig(params *config.VppManagerParams, confs []*config.Linu
 		log.Infof("MTU                  %d", conf.Mtu)
 		log.Infof("isTunTap             %t", conf.IsTunTap)
 		log.Infof("isVeth               %t", conf.IsVeth)
+		log.Infof("Host interface       %s", conf.GetHostInterfaceName())
+		if conf.Bond != nil {
+			log.Infof("Bond mode            %s hash:%s lacp-rate:%s", conf.Bond.Mode, conf.Bond.XmitHashPolicy, conf.Bond.LacpRate)
+			for _, member := range conf.Bond.Members {
+				log.Infof("Bond member          %s pci:%s driver:%s up:%t", member.InterfaceName, member.PciID, member.Driver, member.IsUp)
+			}
+		}
 	}
 }
@@ -41,6 +41,8 @@ func (d *AFPacketDriver) IsSupported(warn bool) bool {
 func (d *AFPacketDriver) GetDefaultRxMode() types.RxMode { return types.InterruptRxMode }
 
 func (d *AFPacketDriver) PreconfigureLinux() error {
+	// The VPP tap replaces the VLAN devices holding the uplink addresses
+	d.deleteLinuxVlans()
 	link, err := netlink.LinkByName(d.spec.InterfaceName)
 	if err != nil {
 		return errors.Wrapf(err, "Error finding link %s", d.spec.InterfaceName)
@@ -16,23 +16,166 @@
 package uplink
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 
 	"github.com/projectcalico/vpp-dataplane/v3/config"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/af_xdp"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
 )
 
 const (
-	minAfXDPKernelVersion = "5.4.0-0"
-	maxAfXDPMTU           = 3072
+	minAfXDPKernelVersion         = "5.4.0-0"
+	minAfXDPBusyPollKernelVersion = "5.11.0-0"
+	// Most NIC drivers only support zero-copy AF_XDP from 5.10
+	minAfXDPZeroCopyKernelVersion = "5.10.0-0"
+	maxAfXDPMTU                   = 3072
 )
 
 type AFXDPDriver struct {
 	UplinkDriverData
+	// combinedChannels is the number of channels set on the NIC
+	combinedChannels int
+	// busyPollConf is the linux busy poll configuration
+	// replaced, nil if untouched
+	busyPollConf *config.AfXdpBusyPollConfig
+	// programAttached is whether we attached a pinned XDP program
+	programAttached bool
+}
+
+func (d *AFXDPDriver) getAfXdpConfig() *config.AfXdpConfig {
+	if d.spec.AfXdp == nil {
+		return &config.AfXdpConfig{Mode: config.AfXdpModeAuto}
+	}
+	return d.spec.AfXdp
+}
+
+func (d *AFXDPDriver) checkKernelVersion(feature string, version string) error {
+	minVersion, err := utils.ParseKernelVersion(version)
+	if err != nil {
+		return errors.Wrapf(err, "Error parsing kernel version %s", version)
+	}
+	if d.params.KernelVersion == nil {
+		return errors.Errorf("unknown kernel version, %s needs %s", feature, version)
+	}
+	if !d.params.KernelVersion.IsAtLeast(minVersion) {
+		return errors.Errorf("kernel %s does not support %s, it needs %s", d.params.KernelVersion, feature, version)
+	}
+	return nil
+}
+
+func (d *AFXDPDriver) sysfsPath(file string) string {
+	return fmt.Sprintf("/sys/class/net/%s/%s", d.spec.InterfaceName, file)
+}
+
+// configureBusyPoll defers the NIC interrupts while VPP polls its queues
+func (d *AFXDPDriver) configureBusyPoll(busyPoll *config.AfXdpBusyPollConfig) (err error) {
+	err = d.checkKernelVersion("AF_XDP busy poll", minAfXDPBusyPollKernelVersion)
+	if err != nil {
+		return err
+	}
+	oldConf := &config.AfXdpBusyPollConfig{}
+	oldConf.NapiDeferHardIrqs, err = utils.ReadSysfsInt(d.sysfsPath("napi_defer_hard_irqs"))
+	if err != nil {
+		return err
+	}
+	oldConf.GroFlushTimeout, err = utils.ReadSysfsInt(d.sysfsPath("gro_flush_timeout"))
+	if err != nil {
+		return err
+	}
+	d.busyPollConf = oldConf
+	log.Infof("Setting napi_defer_hard_irqs %d and gro_flush_timeout %d on %s",
+		busyPoll.NapiDeferHardIrqs, busyPoll.GroFlushTimeout, d.spec.InterfaceName)
+	err = utils.WriteSysfsInt(d.sysfsPath("napi_defer_hard_irqs"), busyPoll.NapiDeferHardIrqs)
+	if err != nil {
+		return err
+	}
+	return utils.WriteSysfsInt(d.sysfsPath("gro_flush_timeout"), busyPoll.GroFlushTimeout)
+}
+
+func (d *AFXDPDriver) restoreBusyPoll() {
+	if d.busyPollConf == nil {
+		return
+	}
+	log.Infof("Setting back napi_defer_hard_irqs %d and gro_flush_timeout %d",
+		d.busyPollConf.NapiDeferHardIrqs, d.busyPollConf.GroFlushTimeout)
+	err := utils.WriteSysfsInt(d.sysfsPath("napi_defer_hard_irqs"), d.busyPollConf.NapiDeferHardIrqs)
+	if err != nil {
+		log.Errorf("Error restoring busy poll on %s: %v", d.spec.InterfaceName, err)
+	}
+	err = utils.WriteSysfsInt(d.sysfsPath("gro_flush_timeout"), d.busyPollConf.GroFlushTimeout)
+	if err != nil {
+		log.Errorf("Error restoring busy poll on %s: %v", d.spec.InterfaceName, err)
+	}
+	d.busyPollConf = nil
+}
+
+// attachProgram attaches an XDP program pinned in bpffs to the NIC. VPP then
+// uses it instead of loading its default program, and registers its sockets
+// in the xsks_map of the program
+func (d *AFXDPDriver) attachProgram(link netlink.Link, program string) error {
+	fd, err := utils.GetPinnedBpfObjectFd(program)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	err = netlink.LinkSetXdpFd(link, fd)
+	if err != nil {
+		return errors.Wrapf(err, "Error attaching XDP program %s to %s", program, d.spec.InterfaceName)
+	}
+	log.Infof("Attached XDP program %s to %s", program, d.spec.InterfaceName)
+	d.programAttached = true
+	return nil
+}
+
+func (d *AFXDPDriver) detachProgram() {
+	if !d.programAttached {
+		return
+	}
+	log.Infof("Detaching XDP program from %s", d.spec.InterfaceName)
+	link, err := netlink.LinkByName(d.spec.InterfaceName)
+	if err == nil {
+		err = netlink.LinkSetXdpFd(link, -1)
+	}
+	if err != nil {
+		log.Errorf("Error detaching XDP program from %s: %v", d.spec.InterfaceName, err)
+	}
+	d.programAttached = false
+}
+
+func (d *AFXDPDriver) configureProgram(link netlink.Link, program string) error {
+	if utils.IsBpffsPath(program) {
+		return d.attachProgram(link, program)
+	}
+	_, err := os.Stat(program)
+	if err != nil {
+		return errors.Wrapf(err, "XDP program %s not found", program)
+	}
+	log.Infof("VPP will load XDP program %s on %s", program, d.spec.InterfaceName)
+	return nil
+}
+
+func (d *AFXDPDriver) getVppMode() af_xdp.AfXdpMode {
+	switch d.getAfXdpConfig().Mode {
+	case config.AfXdpModeCopy:
+		return af_xdp.AF_XDP_API_MODE_COPY
+	case config.AfXdpModeZeroCopy:
+		err := d.checkKernelVersion("zero-copy AF_XDP", minAfXDPZeroCopyKernelVersion)
+		if err != nil {
+			log.Warnf("Using copy mode on %s: %v", d.spec.InterfaceName, err)
+			return af_xdp.AF_XDP_API_MODE_COPY
+		}
+		return af_xdp.AF_XDP_API_MODE_ZERO_COPY
+	default:
+		return af_xdp.AF_XDP_API_MODE_AUTO
+	}
 }
 
 func (d *AFXDPDriver) IsSupported(warn bool) bool {
@@ -62,6 +205,8 @@ func (d *AFXDPDriver) IsSupported(warn bool) bool {
 }
 
 func (d *AFXDPDriver) PreconfigureLinux() error {
+	// The VPP tap replaces the VLAN devices holding the uplink addresses
+	d.deleteLinuxVlans()
 	link, err := netlink.LinkByName(d.spec.InterfaceName)
 	if err != nil {
 		return errors.Wrapf(err, "Error finding link %s", d.spec.InterfaceName)
@@ -70,11 +215,29 @@ func (d *AFXDPDriver) PreconfigureLinux() error {
 	if err != nil {
 		return errors.Wrapf(err, "Error setting link %s promisc on", d.spec.InterfaceName)
 	}
-	err = utils.SetInterfaceRxQueues(d.spec.InterfaceName, d.spec.NumRxQueues)
+	afXdpConf := d.getAfXdpConfig()
+	d.combinedChannels = d.spec.NumRxQueues
+	if afXdpConf.CombinedChannels != 0 {
+		d.combinedChannels = afXdpConf.CombinedChannels
+	}
+	err = utils.SetInterfaceRxQueues(d.spec.InterfaceName, d.combinedChannels)
 	if err != nil {
-		log.Errorf("Error setting link %s NumQueues to %d, using %d queues: %v", d.spec.InterfaceName, d.spec.NumRxQueues, d.conf.NumRxQueues, err)
+		log.Errorf("Error setting link %s combined channels to %d, using %d queues: %v", d.spec.InterfaceName, d.combinedChannels, d.conf.NumRxQueues, err)
 		/* Try with linux NumRxQueues on error, otherwise af_xdp wont start */
 		d.spec.NumRxQueues = d.conf.NumRxQueues
+		d.combinedChannels = d.conf.NumRxQueues
+	}
+	if afXdpConf.BusyPoll != nil {
+		err = d.configureBusyPoll(afXdpConf.BusyPoll)
+		if err != nil {
+			return errors.Wrapf(err, "Error configuring busy poll on %s", d.spec.InterfaceName)
+		}
+	}
+	if afXdpConf.Program != "" {
+		err = d.configureProgram(link, afXdpConf.Program)
+		if err != nil {
+			return err
+		}
 	}
 	if d.conf.Mtu > maxAfXDPMTU {
 		log.Infof("Reducing interface MTU to %d for AF_XDP", maxAfXDPMTU)
@@ -99,6 +262,9 @@ func (d *AFXDPDriver) RestoreLinux(allInterfacesPhysical bool) {
 		}
 	}
 
+	d.restoreBusyPoll()
+	d.detachProgram()
+
 	if !d.conf.IsUp {
 		return
 	}
@@ -118,7 +284,7 @@ func (d *AFXDPDriver) RestoreLinux(allInterfacesPhysical bool) {
 			log.Errorf("Error setting link %s promisc off %v", d.spec.InterfaceName, err)
 		}
 	}
-	if d.conf.NumRxQueues != d.spec.NumRxQueues {
+	if d.conf.NumRxQueues != d.combinedChannels {
 		log.Infof("Setting back %d queues", d.conf.NumRxQueues)
 		err = utils.SetInterfaceRxQueues(d.spec.InterfaceName, d.conf.NumRxQueues)
 		if err != nil {
@@ -138,12 +304,22 @@ func (d *AFXDPDriver) CreateMainVppInterface(vpp *vpplink.VppLink, vppPid int, u
 
 	intf := types.VppXDPInterface{
 		GenericVppInterface: d.getGenericVppInterface(),
+		Mode:                d.getVppMode(),
+	}
+	if program := d.getAfXdpConfig().Program; program != "" && !d.programAttached {
+		intf.Prog = program
 	}
 	err = vpp.CreateAfXDP(&intf)
+	// The driver of the NIC may still not support zero-copy
+	if err != nil && intf.Mode == af_xdp.AF_XDP_API_MODE_ZERO_COPY {
+		log.Warnf("Zero-copy AF_XDP not supported on %s, falling back to copy mode: %v", d.spec.InterfaceName, err)
+		intf.Mode = af_xdp.AF_XDP_API_MODE_COPY
+		err = vpp.CreateAfXDP(&intf)
+	}
 	if err != nil {
 		return errors.Wrapf(err, "Error creating AF_XDP interface")
 	}
-	log.Infof("Created AF_XDP interface %d", intf.SwIfIndex)
+	log.Infof("Created AF_XDP interface %d (%s)", intf.SwIfIndex, intf.Mode)
 
 	err = vpp.SetInterfaceMacAddress(intf.SwIfIndex, d.conf.HardwareAddr)
 	if err != nil {
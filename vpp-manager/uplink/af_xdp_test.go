@@ -0,0 +1,47 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uplink
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/af_xdp"
+)
+
+var _ = Describe("AF_XDP uplink driver", func() {
+	newDriver := func(kernelVersion string, mode config.AfXdpMode) *AFXDPDriver {
+		params := &config.VppManagerParams{}
+		if kernelVersion != "" {
+			version, err := utils.ParseKernelVersion(kernelVersion)
+			Expect(err).ToNot(HaveOccurred())
+			params.KernelVersion = version
+		}
+		conf, spec := newTestUplink("eth0")
+		spec.AfXdp = &config.AfXdpConfig{Mode: mode}
+		return NewAFXDPDriver(params, conf, spec)
+	}
+
+	It("Only requests zero-copy on kernels supporting it", func() {
+		Expect(newDriver("5.15.0-91", config.AfXdpModeZeroCopy).getVppMode()).To(Equal(af_xdp.AF_XDP_API_MODE_ZERO_COPY))
+		Expect(newDriver("5.4.0-100", config.AfXdpModeZeroCopy).getVppMode()).To(Equal(af_xdp.AF_XDP_API_MODE_COPY))
+		Expect(newDriver("", config.AfXdpModeZeroCopy).getVppMode()).To(Equal(af_xdp.AF_XDP_API_MODE_COPY))
+		Expect(newDriver("5.4.0-100", config.AfXdpModeAuto).getVppMode()).To(Equal(af_xdp.AF_XDP_API_MODE_AUTO))
+		Expect(newDriver("5.4.0-100", config.AfXdpModeCopy).getVppMode()).To(Equal(af_xdp.AF_XDP_API_MODE_COPY))
+	})
+})
@@ -66,8 +66,9 @@ func (d *AVFDriver) PreconfigureLinux() (err error) {
 		/* This is a PF */
 		d.pfPCI = pciID
 		if numVFs == 0 {
-			log.Infof("Creating a VF for %s", d.spec.InterfaceName)
-			err := utils.CreateInterfaceVF(pciID)
+			numVFs = max(d.spec.NumVFs, 1)
+			log.Infof("Creating %d VFs for %s", numVFs, d.spec.InterfaceName)
+			err := utils.CreateInterfaceVFs(pciID, numVFs)
 			if err != nil {
 				return errors.Wrapf(err, "Couldnt create VF for %s", d.spec.InterfaceName)
 			}
@@ -0,0 +1,282 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uplink
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/vppconf"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+// BondDriver takes over the members of a linux bond uplink with a native
+// driver, and bonds them in VPP
+type BondDriver struct {
+	UplinkDriverData
+	members []UplinkDriver
+	// memberSpecs are the uplink specs of the members, derived from
+	// the bond one
+	memberSpecs []*config.UplinkInterfaceSpec
+}
+
+func (d *BondDriver) getVppBondMode() (types.BondMode, error) {
+	switch d.conf.Bond.Mode {
+	case netlink.BOND_MODE_802_3AD:
+		return types.BondModeLacp, nil
+	case netlink.BOND_MODE_ACTIVE_BACKUP:
+		return types.BondModeActiveBackup, nil
+	case netlink.BOND_MODE_BALANCE_XOR:
+		return types.BondModeXor, nil
+	case netlink.BOND_MODE_BALANCE_RR:
+		return types.BondModeRoundRobin, nil
+	case netlink.BOND_MODE_BROADCAST:
+		return types.BondModeBroadcast, nil
+	default:
+		return 0, errors.Errorf("bond mode %s not supported", d.conf.Bond.Mode)
+	}
+}
+
+func (d *BondDriver) getVppBondLoadBalance() types.BondLoadBalance {
+	switch d.conf.Bond.XmitHashPolicy {
+	case netlink.BOND_XMIT_HASH_POLICY_LAYER3_4, netlink.BOND_XMIT_HASH_POLICY_ENCAP3_4:
+		return types.BondLoadBalanceL34
+	case netlink.BOND_XMIT_HASH_POLICY_LAYER2_3, netlink.BOND_XMIT_HASH_POLICY_ENCAP2_3:
+		return types.BondLoadBalanceL23
+	default:
+		return types.BondLoadBalanceL2
+	}
+}
+
+func (d *BondDriver) IsSupported(warn bool) (supported bool) {
+	_, err := d.getVppBondMode()
+	if err != nil {
+		if warn {
+			log.Warnf("%v", err)
+		}
+		return false
+	}
+	supported = true
+	for _, member := range d.members {
+		// These drivers find their interface with its tag, which bond members do not have
+		if member.GetName() == NativeDriverDpdk || member.GetName() == NativeDriverNone {
+			if warn {
+				log.Warnf("%s driver not supported for bond members", member.GetName())
+			}
+			return false
+		}
+		supported = member.IsSupported(warn) && supported
+	}
+	return supported
+}
+
+func (d *BondDriver) GetDefaultRxMode() types.RxMode {
+	return d.members[0].GetDefaultRxMode()
+}
+
+func (d *BondDriver) UpdateVppConfig(vppConf *vppconf.VppConfig) {
+	for _, member := range d.members {
+		member.UpdateVppConfig(vppConf)
+	}
+}
+
+func (d *BondDriver) PreconfigureLinux() error {
+	d.removeLinuxIfConf(true /* down */)
+	link, err := netlink.LinkByName(d.spec.InterfaceName)
+	if err != nil {
+		return errors.Wrapf(err, "Error finding bond %s", d.spec.InterfaceName)
+	}
+	// Deleting the bond releases its members
+	err = netlink.LinkDel(link)
+	if err != nil {
+		return errors.Wrapf(err, "Error deleting bond %s", d.spec.InterfaceName)
+	}
+	for _, member := range d.members {
+		err = member.PreconfigureLinux()
+		if err != nil {
+			return errors.Wrapf(err, "Error pre-configuring bond member")
+		}
+	}
+	return nil
+}
+
+// restoreLinuxBond recreates the linux bond and enslaves its members
+func (d *BondDriver) restoreLinuxBond() (netlink.Link, error) {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = d.spec.InterfaceName
+	attrs.MTU = d.conf.Bond.Mtu
+	attrs.HardwareAddr = d.conf.Bond.HardwareAddr
+	bond := netlink.NewLinkBond(attrs)
+	bond.Mode = d.conf.Bond.Mode
+	bond.XmitHashPolicy = d.conf.Bond.XmitHashPolicy
+	bond.Miimon = d.conf.Bond.Miimon
+	bond.UpDelay = d.conf.Bond.UpDelay
+	bond.DownDelay = d.conf.Bond.DownDelay
+	if d.conf.Bond.Mode == netlink.BOND_MODE_802_3AD {
+		bond.LacpRate = d.conf.Bond.LacpRate
+	}
+	log.Infof("restoring bond %s", d.spec.InterfaceName)
+	err := netlink.LinkAdd(bond)
+	if err != nil && err != syscall.EEXIST {
+		return nil, errors.Wrapf(err, "cannot create bond %s", d.spec.InterfaceName)
+	}
+	link, err := netlink.LinkByName(d.spec.InterfaceName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot find bond %s", d.spec.InterfaceName)
+	}
+	for _, member := range d.conf.Bond.Members {
+		memberLink, err := netlink.LinkByName(member.InterfaceName)
+		if err != nil {
+			log.Errorf("Cannot find bond member %s: %v", member.InterfaceName, err)
+			continue
+		}
+		// Members can only be enslaved while down
+		err = netlink.LinkSetDown(memberLink)
+		if err != nil {
+			log.Errorf("Error setting bond member %s down: %v", member.InterfaceName, err)
+		}
+		err = netlink.LinkSetMasterByIndex(memberLink, link.Attrs().Index)
+		if err != nil {
+			log.Errorf("Cannot add member %s to bond %s: %v", member.InterfaceName, d.spec.InterfaceName, err)
+			continue
+		}
+		if member.IsUp {
+			err = netlink.LinkSetUp(memberLink)
+			if err != nil {
+				log.Errorf("Error setting bond member %s up: %v", member.InterfaceName, err)
+			}
+		}
+	}
+	return link, nil
+}
+
+func (d *BondDriver) RestoreLinux(allInterfacesPhysical bool) {
+	for _, member := range d.members {
+		member.RestoreLinux(allInterfacesPhysical)
+	}
+	link, err := d.restoreLinuxBond()
+	if err != nil {
+		log.Errorf("Error restoring bond: %v", err)
+		return
+	}
+	if !d.conf.IsUp {
+		return
+	}
+	err = netlink.LinkSetUp(link)
+	if err != nil {
+		log.Warnf("Error setting %s up: %v", d.spec.InterfaceName, err)
+		return
+	}
+
+	// Re-add all adresses and routes
+	d.restoreLinuxIfConf(link)
+}
+
+func (d *BondDriver) CreateMainVppInterface(vpp *vpplink.VppLink, vppPid int, uplinkSpec *config.UplinkInterfaceSpec) (err error) {
+	mode, err := d.getVppBondMode()
+	if err != nil {
+		return err
+	}
+	bond := &types.Bond{
+		Mode:         mode,
+		LoadBalance:  d.getVppBondLoadBalance(),
+		HardwareAddr: d.conf.Bond.HardwareAddr,
+		EnableGSO:    *config.GetCalicoVppDebug().GSOEnabled,
+	}
+	swIfIndex, err := vpp.CreateBond(bond)
+	if err != nil {
+		return errors.Wrapf(err, "Error creating bond")
+	}
+	log.Infof("Created bond interface %d", swIfIndex)
+
+	for i, member := range d.members {
+		memberSpec := d.memberSpecs[i]
+		err = member.CreateMainVppInterface(vpp, vppPid, memberSpec)
+		if err != nil {
+			return errors.Wrapf(err, "Error creating bond member %s", memberSpec.InterfaceName)
+		}
+		// Only the bond is paired with the tap
+		err = vpp.SetInterfaceTag(memberSpec.SwIfIndex, "member-"+memberSpec.InterfaceName)
+		if err != nil {
+			return errors.Wrapf(err, "Error tagging bond member %s", memberSpec.InterfaceName)
+		}
+		err = vpp.SetInterfaceRxMode(memberSpec.SwIfIndex, types.AllQueues, memberSpec.GetRxModeWithDefault(member.GetDefaultRxMode()))
+		if err != nil {
+			log.Warnf("%v", err)
+		}
+		err = vpp.AddBondMember(swIfIndex, &types.BondMember{
+			SwIfIndex:     memberSpec.SwIfIndex,
+			IsLongTimeout: d.conf.Bond.LacpRate != netlink.BOND_LACP_RATE_FAST,
+		})
+		if err != nil {
+			return err
+		}
+		err = vpp.InterfaceAdminUp(memberSpec.SwIfIndex)
+		if err != nil {
+			return errors.Wrapf(err, "Error setting bond member %s up", memberSpec.InterfaceName)
+		}
+	}
+
+	d.spec.SwIfIndex = swIfIndex
+	err = d.TagMainInterface(vpp, swIfIndex, d.spec.InterfaceName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetMemberStatuses returns the VPP interfaces of the bond members and
+// their link state
+func (d *BondDriver) GetMemberStatuses(vpp *vpplink.VppLink) []config.BondMemberStatus {
+	statuses := make([]config.BondMemberStatus, 0, len(d.memberSpecs))
+	for _, memberSpec := range d.memberSpecs {
+		status := config.BondMemberStatus{
+			Name:      memberSpec.InterfaceName,
+			SwIfIndex: memberSpec.SwIfIndex,
+		}
+		details, err := vpp.GetInterfaceDetails(memberSpec.SwIfIndex)
+		if err != nil {
+			log.Warnf("Error getting bond member %s state: %v", memberSpec.InterfaceName, err)
+		} else {
+			status.IsUp = details.IsLinkUp
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func NewBondDriver(name string, params *config.VppManagerParams, conf *config.LinuxInterfaceState, spec *config.UplinkInterfaceSpec) *BondDriver {
+	d := &BondDriver{}
+	d.name = name
+	d.conf = conf
+	d.params = params
+	d.spec = spec
+	for _, memberConf := range conf.Bond.Members {
+		memberSpec := *spec
+		memberSpec.InterfaceName = memberConf.InterfaceName
+		memberSpec.VlanID = 0
+		memberSpec.InnerVlanID = 0
+		memberSpec.VlanProtocol = ""
+		d.memberSpecs = append(d.memberSpecs, &memberSpec)
+		d.members = append(d.members, newUplinkDriver(name, params, memberConf, &memberSpec))
+	}
+	return d
+}
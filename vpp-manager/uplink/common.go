@@ -26,6 +26,7 @@ import (
 
 	"github.com/projectcalico/vpp-dataplane/v3/config"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/vppconf"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
 )
@@ -54,7 +55,7 @@ type UplinkDriver interface {
 	RestoreLinux(allInterfacesPhysical bool)
 	IsSupported(warn bool) bool
 	GetName() string
-	UpdateVppConfigFile(template string) string
+	UpdateVppConfig(vppConf *vppconf.VppConfig)
 	GetDefaultRxMode() types.RxMode
 }
 
@@ -113,10 +114,14 @@ func (d *UplinkDriverData) moveInterfaceToNS(ifName string, pid int) error {
 }
 
 func (d *UplinkDriverData) removeLinuxIfConf(setIfDown bool) {
+	// Deleting the VLAN devices also removes their addresses and routes
+	d.deleteLinuxVlans()
 	link, err := netlink.LinkByName(d.spec.InterfaceName)
 	if err != nil {
 		log.Errorf("Error finding link %s: %s", d.spec.InterfaceName, err)
-	} else {
+		return
+	}
+	if len(d.conf.Vlans) == 0 {
 		// Remove routes to not have them conflict with vpptap0
 		for _, route := range d.conf.Routes {
 			log.Infof("deleting Route %s", route.String())
@@ -132,19 +137,28 @@ func (d *UplinkDriverData) removeLinuxIfConf(setIfDown bool) {
 				log.Errorf("Error removing address %s from tap interface : %+v", addr, err)
 			}
 		}
+	}
 
-		if d.conf.IsUp && setIfDown {
-			err = netlink.LinkSetDown(link)
-			if err != nil {
-				// In case it still succeeded
-				err2 := netlink.LinkSetUp(link)
-				log.Errorf("Error setting link %s down: %s (err2 %s)", d.spec.InterfaceName, err, err2)
-			}
+	if d.conf.IsUp && setIfDown {
+		err = netlink.LinkSetDown(link)
+		if err != nil {
+			// In case it still succeeded
+			err2 := netlink.LinkSetUp(link)
+			log.Errorf("Error setting link %s down: %s (err2 %s)", d.spec.InterfaceName, err, err2)
 		}
 	}
 }
 
 func (d *UplinkDriverData) restoreLinuxIfConf(link netlink.Link) {
+	// The configuration of VLAN uplinks goes on their VLAN devices
+	if len(d.conf.Vlans) != 0 {
+		var err error
+		link, err = d.restoreLinuxVlans(link)
+		if err != nil {
+			log.Errorf("Cannot restore vlans on %s: %v", d.spec.InterfaceName, err)
+			return
+		}
+	}
 	err := netlink.LinkSetMTU(link, d.conf.Mtu)
 	if err != nil {
 		log.Errorf("Cannot restore mtu to %d: %v", d.conf.Mtu, err)
@@ -171,9 +185,7 @@ func (d *UplinkDriverData) restoreLinuxIfConf(link netlink.Link) {
 
 }
 
-func (d *UplinkDriverData) UpdateVppConfigFile(template string) string {
-	return template
-}
+func (d *UplinkDriverData) UpdateVppConfig(vppConf *vppconf.VppConfig) {}
 
 func (d *UplinkDriverData) getGenericVppInterface() types.GenericVppInterface {
 	return types.GenericVppInterface{
@@ -189,6 +201,15 @@ func (d *UplinkDriverData) getGenericVppInterface() types.GenericVppInterface {
 func SupportedUplinkDrivers(params *config.VppManagerParams, conf *config.LinuxInterfaceState, spec *config.UplinkInterfaceSpec) []UplinkDriver {
 	lst := make([]UplinkDriver, 0)
 
+	if conf.Bond != nil {
+		for _, name := range []string{NativeDriverVirtio, NativeDriverAvf, NativeDriverRdma, NativeDriverVmxnet3, NativeDriverAfXdp, NativeDriverAfPacket} {
+			if d := NewBondDriver(name, params, conf, spec); d.IsSupported(false /* warn */) {
+				lst = append(lst, d)
+			}
+		}
+		return lst
+	}
+
 	if d := NewVirtioDriver(params, conf, spec); d.IsSupported(false /* warn */) {
 		lst = append(lst, d)
 	}
@@ -211,6 +232,16 @@ func SupportedUplinkDrivers(params *config.VppManagerParams, conf *config.LinuxI
 }
 
 func NewUplinkDriver(name string, params *config.VppManagerParams, conf *config.LinuxInterfaceState, spec *config.UplinkInterfaceSpec) (d UplinkDriver) {
+	if conf.Bond != nil {
+		d = NewBondDriver(name, params, conf, spec)
+	} else {
+		d = newUplinkDriver(name, params, conf, spec)
+	}
+	d.IsSupported(true /* warn */)
+	return d
+}
+
+func newUplinkDriver(name string, params *config.VppManagerParams, conf *config.LinuxInterfaceState, spec *config.UplinkInterfaceSpec) (d UplinkDriver) {
 	switch name {
 	case NativeDriverRdma:
 		d = NewRDMADriver(params, conf, spec)
@@ -232,6 +263,5 @@ func NewUplinkDriver(name string, params *config.VppManagerParams, conf *config.
 		log.Warnf("Using default driver")
 		d = NewDefaultDriver(params, conf, spec)
 	}
-	d.IsSupported(true /* warn */)
 	return d
 }
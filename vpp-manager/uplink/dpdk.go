@@ -18,7 +18,6 @@ package uplink
 import (
 	gerrors "errors"
 	"fmt"
-	"regexp"
 	"time"
 
 	"github.com/pkg/errors"
@@ -27,6 +26,7 @@ import (
 
 	"github.com/projectcalico/vpp-dataplane/v3/config"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/vppconf"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
 )
@@ -72,45 +72,28 @@ func (d *DPDKDriver) PreconfigureLinux() (err error) {
 	return nil
 }
 
-func (d *DPDKDriver) UpdateVppConfigFile(template string) string {
-	dpdkPluginRegex := regexp.MustCompile(`plugin\s+dpdk_plugin\.so\s*{\s*disable\s*}`)
-	template = dpdkPluginRegex.ReplaceAllString(template, "plugin dpdk_plugin.so { enable }")
-
-	dpdkStanzaRegex := regexp.MustCompile(`dpdk\s*{[^}]+}`)
-	if dpdkStanzaRegex.MatchString(template) {
-		goto write
-	}
-
-	if d.params.AvailableHugePages > 0 {
-		template = fmt.Sprintf(
-			"%s\ndpdk {\ndev %s { num-rx-queues %d num-tx-queues %d num-rx-desc %d num-tx-desc %d tag %s } \n}\n",
-			template, d.conf.PciID, d.spec.NumRxQueues, d.spec.NumTxQueues,
-			d.spec.RxQueueSize, d.spec.TxQueueSize, "main-"+d.spec.InterfaceName,
-		)
-
-	} else {
-		template = fmt.Sprintf(
-			"%s\ndpdk {\niova-mode va\nno-hugetlb\ndev %s { num-rx-queues %d num-tx-queues %d num-rx-desc %d num-tx-desc %d tag %s } \n}\n",
-			template, d.conf.PciID, d.spec.NumRxQueues, d.spec.NumTxQueues,
-			d.spec.RxQueueSize, d.spec.TxQueueSize, "main-"+d.spec.InterfaceName,
-		)
-
-		// If no hugepages, also edit `buffers {}`
-		buffersHeadRegex := regexp.MustCompile(`buffers\s*{`)
-		buffersStanzaRegex := regexp.MustCompile(`buffers\s*{[^}]+}`)
-		buffersNoHugeStanzaRegex := regexp.MustCompile(`buffers\s*{[^}]*no-hugetlb[^}]*}`)
-		if buffersStanzaRegex.MatchString(template) {
-			if !buffersNoHugeStanzaRegex.MatchString(template) {
-				template = buffersHeadRegex.ReplaceAllString(template, "buffers {\nno-hugetlb")
-				log.Infof("Found buffers configuration in template")
-			}
-		} else {
-			template = fmt.Sprintf("%s\nbuffers {\nno-hugetlb\n}", template)
-		}
+func (d *DPDKDriver) UpdateVppConfig(vppConf *vppconf.VppConfig) {
+	vppConf.Plugins.SetEnabled(vppconf.DpdkPlugin, true)
+
+	// Devices configured in the template are left as is
+	if vppConf.Dpdk.GetDevice(d.conf.PciID) == nil {
+		vppConf.Dpdk.Devices = append(vppConf.Dpdk.Devices, vppconf.DpdkDevice{
+			PciID:       d.conf.PciID,
+			NumRxQueues: d.spec.NumRxQueues,
+			NumTxQueues: d.spec.NumTxQueues,
+			NumRxDesc:   d.spec.RxQueueSize,
+			NumTxDesc:   d.spec.TxQueueSize,
+			Tag:         "main-" + d.spec.InterfaceName,
+		})
 	}
 
-write:
-	return template
+	if d.params.AvailableHugePages == 0 {
+		if vppConf.Dpdk.IovaMode == "" {
+			vppConf.Dpdk.IovaMode = "va"
+		}
+		vppConf.Dpdk.NoHugetlb = true
+		vppConf.Buffers.NoHugetlb = true
+	}
 }
 
 func (d *DPDKDriver) restoreInterfaceName() error {
@@ -0,0 +1,223 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uplink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+)
+
+const (
+	// maxDriverFailures is the number of failures kept per driver
+	maxDriverFailures = 5
+)
+
+// UplinkDriverKey identifies an uplink NIC and the software driving it.
+// A driver that worked is only reused while the key is unchanged
+type UplinkDriverKey struct {
+	PciID              string `json:"pciId"`
+	LinuxDriver        string `json:"linuxDriver"`
+	LinuxDriverVersion string `json:"linuxDriverVersion,omitempty"`
+	KernelVersion      string `json:"kernelVersion,omitempty"`
+	ImageVersion       string `json:"imageVersion,omitempty"`
+}
+
+func (k UplinkDriverKey) String() string {
+	return strings.Join([]string{k.PciID, k.LinuxDriver, k.LinuxDriverVersion, k.KernelVersion, k.ImageVersion}, "/")
+}
+
+type DriverFailure struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+}
+
+type DriverHistory struct {
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+	// Failures are the last failures of the driver, oldest first
+	Failures []DriverFailure `json:"failures,omitempty"`
+}
+
+// lastFailedAfterSuccess tells whether the last attempt with the driver failed
+func (h *DriverHistory) lastFailedAfterSuccess() bool {
+	return len(h.Failures) > 0 && h.Failures[len(h.Failures)-1].Time.After(h.LastSuccess)
+}
+
+type UplinkDriverRecord struct {
+	Key UplinkDriverKey `json:"key"`
+	// WorkingDriver is the last driver VPP became ready with
+	WorkingDriver string                    `json:"workingDriver,omitempty"`
+	Drivers       map[string]*DriverHistory `json:"drivers"`
+}
+
+// UplinkDriverState is persisted on the node, so that vpp-manager
+// tries the driver that worked for an uplink first
+type UplinkDriverState struct {
+	Uplinks map[string]*UplinkDriverRecord `json:"uplinks"`
+}
+
+// GetUplinkDriverKey builds the key of an uplink from its linux state
+func GetUplinkDriverKey(params *config.VppManagerParams, conf *config.LinuxInterfaceState) UplinkDriverKey {
+	key := UplinkDriverKey{
+		PciID:        conf.PciID,
+		LinuxDriver:  conf.Driver,
+		ImageVersion: utils.GetImageVersion(),
+	}
+	if conf.Bond != nil {
+		pciIDs := make([]string, 0, len(conf.Bond.Members))
+		for _, member := range conf.Bond.Members {
+			pciIDs = append(pciIDs, member.PciID)
+			key.LinuxDriver = member.Driver
+		}
+		key.PciID = strings.Join(pciIDs, ",")
+	}
+	if key.PciID == "" {
+		key.PciID = conf.InterfaceName
+	}
+	if key.LinuxDriver != "" {
+		key.LinuxDriverVersion = utils.GetKernelModuleVersion(key.LinuxDriver)
+	}
+	if params.KernelVersion != nil {
+		key.KernelVersion = params.KernelVersion.String()
+	}
+	return key
+}
+
+// LoadUplinkDriverState reads the state file, returning an empty
+// state when it does not exist
+func LoadUplinkDriverState(path string) (*UplinkDriverState, error) {
+	state := &UplinkDriverState{Uplinks: make(map[string]*UplinkDriverRecord)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, errors.Wrapf(err, "cannot read %s", path)
+	}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return &UplinkDriverState{Uplinks: make(map[string]*UplinkDriverRecord)}, errors.Wrapf(err, "cannot decode %s", path)
+	}
+	if state.Uplinks == nil {
+		state.Uplinks = make(map[string]*UplinkDriverRecord)
+	}
+	return state, nil
+}
+
+// Save writes the state file atomically
+func (s *UplinkDriverState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot encode uplink driver state")
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create %s", filepath.Dir(path))
+	}
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "cannot write %s", tmpPath)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (s *UplinkDriverState) getRecord(key UplinkDriverKey) *UplinkDriverRecord {
+	record, ok := s.Uplinks[key.String()]
+	if !ok {
+		record = &UplinkDriverRecord{Key: key, Drivers: make(map[string]*DriverHistory)}
+		s.Uplinks[key.String()] = record
+	}
+	if record.Drivers == nil {
+		record.Drivers = make(map[string]*DriverHistory)
+	}
+	return record
+}
+
+func (r *UplinkDriverRecord) getHistory(driverName string) *DriverHistory {
+	history, ok := r.Drivers[driverName]
+	if !ok {
+		history = &DriverHistory{}
+		r.Drivers[driverName] = history
+	}
+	return history
+}
+
+// OrderDrivers returns the drivers to try for an uplink, with the reason each
+// is tried. The driver that last worked comes first, and drivers whose last
+// attempt failed come last, the others keeping their default order
+func (s *UplinkDriverState) OrderDrivers(key UplinkDriverKey, drivers []UplinkDriver) (ordered []UplinkDriver, reasons []string) {
+	record, ok := s.Uplinks[key.String()]
+	if !ok {
+		for idx, driver := range drivers {
+			ordered = append(ordered, driver)
+			if idx == 0 {
+				reasons = append(reasons, "first supported driver")
+			} else {
+				reasons = append(reasons, "next supported driver")
+			}
+		}
+		return ordered, reasons
+	}
+	failed := make([]UplinkDriver, 0)
+	for _, driver := range drivers {
+		if driver.GetName() == record.WorkingDriver {
+			ordered = append([]UplinkDriver{driver}, ordered...)
+			reasons = append([]string{"worked on a previous run"}, reasons...)
+		} else if history, ok := record.Drivers[driver.GetName()]; ok && history.lastFailedAfterSuccess() {
+			failed = append(failed, driver)
+		} else {
+			ordered = append(ordered, driver)
+			reasons = append(reasons, "next supported driver")
+		}
+	}
+	for _, driver := range failed {
+		history := record.Drivers[driver.GetName()]
+		lastFailure := history.Failures[len(history.Failures)-1]
+		ordered = append(ordered, driver)
+		reasons = append(reasons, fmt.Sprintf("failed on %s: %s", lastFailure.Time.Format(time.RFC3339), lastFailure.Error))
+	}
+	return ordered, reasons
+}
+
+// RecordSuccess marks a driver as working for an uplink
+func (s *UplinkDriverState) RecordSuccess(key UplinkDriverKey, driverName string) {
+	record := s.getRecord(key)
+	record.WorkingDriver = driverName
+	record.getHistory(driverName).LastSuccess = time.Now()
+}
+
+// RecordFailure adds a failure to the history of a driver, and forgets it
+// as the working driver of the uplink
+func (s *UplinkDriverState) RecordFailure(key UplinkDriverKey, driverName string, err error) {
+	record := s.getRecord(key)
+	if record.WorkingDriver == driverName {
+		record.WorkingDriver = ""
+	}
+	history := record.getHistory(driverName)
+	history.Failures = append(history.Failures, DriverFailure{Time: time.Now(), Error: err.Error()})
+	if len(history.Failures) > maxDriverFailures {
+		history.Failures = history.Failures[len(history.Failures)-maxDriverFailures:]
+	}
+}
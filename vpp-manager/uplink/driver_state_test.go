@@ -0,0 +1,101 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uplink
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+func driverNames(drivers []UplinkDriver) []string {
+	names := make([]string, 0, len(drivers))
+	for _, driver := range drivers {
+		names = append(names, driver.GetName())
+	}
+	return names
+}
+
+var _ = Describe("Uplink driver state", func() {
+	var (
+		drivers []UplinkDriver
+		key     UplinkDriverKey
+	)
+
+	BeforeEach(func() {
+		params := &config.VppManagerParams{}
+		conf, spec := newTestUplink("eth0")
+		drivers = []UplinkDriver{
+			NewAVFDriver(params, conf, spec),
+			NewAFXDPDriver(params, conf, spec),
+			NewAFPacketDriver(params, conf, spec),
+		}
+		key = GetUplinkDriverKey(params, conf)
+	})
+
+	It("Keeps the default order without history", func() {
+		state := &UplinkDriverState{Uplinks: make(map[string]*UplinkDriverRecord)}
+		ordered, reasons := state.OrderDrivers(key, drivers)
+		Expect(driverNames(ordered)).To(Equal([]string{NativeDriverAvf, NativeDriverAfXdp, NativeDriverAfPacket}))
+		Expect(reasons[0]).To(Equal("first supported driver"))
+	})
+
+	It("Tries the working driver first and failed drivers last", func() {
+		dir, err := os.MkdirTemp("", "uplink-driver-state")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "state.json")
+		state, err := LoadUplinkDriverState(path)
+		Expect(err).ToNot(HaveOccurred())
+		state.RecordFailure(key, NativeDriverAvf, errors.New("cannot create avf"))
+		state.RecordSuccess(key, NativeDriverAfPacket)
+		Expect(state.Save(path)).To(Succeed())
+
+		state, err = LoadUplinkDriverState(path)
+		Expect(err).ToNot(HaveOccurred())
+		ordered, reasons := state.OrderDrivers(key, drivers)
+		Expect(driverNames(ordered)).To(Equal([]string{NativeDriverAfPacket, NativeDriverAfXdp, NativeDriverAvf}))
+		Expect(reasons[0]).To(Equal("worked on a previous run"))
+		Expect(reasons[2]).To(ContainSubstring("cannot create avf"))
+
+		state.RecordFailure(key, NativeDriverAfPacket, errors.New("timeout"))
+		ordered, _ = state.OrderDrivers(key, drivers)
+		Expect(driverNames(ordered)).To(Equal([]string{NativeDriverAfXdp, NativeDriverAvf, NativeDriverAfPacket}))
+	})
+
+	It("Ignores the history of other keys", func() {
+		state := &UplinkDriverState{Uplinks: make(map[string]*UplinkDriverRecord)}
+		state.RecordSuccess(key, NativeDriverAfPacket)
+		key.ImageVersion = "other"
+		ordered, _ := state.OrderDrivers(key, drivers)
+		Expect(ordered[0].GetName()).To(Equal(NativeDriverAvf))
+	})
+
+	It("Keeps the last failures only", func() {
+		state := &UplinkDriverState{Uplinks: make(map[string]*UplinkDriverRecord)}
+		for i := 0; i < 2*maxDriverFailures; i++ {
+			state.RecordFailure(key, NativeDriverAvf, errors.Errorf("failure %d", i))
+		}
+		failures := state.Uplinks[key.String()].Drivers[NativeDriverAvf].Failures
+		Expect(failures).To(HaveLen(maxDriverFailures))
+		Expect(failures[maxDriverFailures-1].Error).To(Equal("failure 9"))
+	})
+})
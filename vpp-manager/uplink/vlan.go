@@ -0,0 +1,69 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uplink
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// deleteLinuxVlans deletes the linux VLAN devices of a VLAN uplink, VPP
+// replaces the innermost one with its tap
+func (d *UplinkDriverData) deleteLinuxVlans() {
+	for i := len(d.conf.Vlans) - 1; i >= 0; i-- {
+		link, err := netlink.LinkByName(d.conf.Vlans[i].Name)
+		if err != nil {
+			log.Warnf("Error finding vlan %s: %v", d.conf.Vlans[i].Name, err)
+			continue
+		}
+		log.Infof("deleting vlan %s", d.conf.Vlans[i].Name)
+		err = netlink.LinkDel(link)
+		if err != nil {
+			log.Errorf("Error deleting vlan %s: %v", d.conf.Vlans[i].Name, err)
+		}
+	}
+}
+
+// restoreLinuxVlans recreates the linux VLAN devices of a VLAN uplink on
+// top of its parent, and returns the innermost one
+func (d *UplinkDriverData) restoreLinuxVlans(parent netlink.Link) (netlink.Link, error) {
+	link := parent
+	for _, vlan := range d.conf.Vlans {
+		log.Infof("restoring vlan %s", vlan.Name)
+		attrs := netlink.NewLinkAttrs()
+		attrs.Name = vlan.Name
+		attrs.ParentIndex = link.Attrs().Index
+		attrs.MTU = vlan.Mtu
+		err := netlink.LinkAdd(&netlink.Vlan{
+			LinkAttrs:    attrs,
+			VlanId:       vlan.VlanID,
+			VlanProtocol: vlan.Protocol,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot create vlan %s", vlan.Name)
+		}
+		link, err = netlink.LinkByName(vlan.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot find vlan %s", vlan.Name)
+		}
+		err = netlink.LinkSetUp(link)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot set vlan %s up", vlan.Name)
+		}
+	}
+	return link, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uplink
+
+import (
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/vppconf"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden files in testdata/")
+
+func TestUplink(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Uplink driver tests")
+}
+
+func newTestUplink(name string) (*config.LinuxInterfaceState, *config.UplinkInterfaceSpec) {
+	hwAddr, _ := net.ParseMAC("02:00:00:00:00:01")
+	conf := &config.LinuxInterfaceState{
+		InterfaceName: name,
+		PciID:         "0000:00:08.0",
+		Driver:        "ixgbe",
+		HardwareAddr:  hwAddr,
+		IsUp:          true,
+		Mtu:           1500,
+	}
+	spec := &config.UplinkInterfaceSpec{
+		InterfaceSpec: config.InterfaceSpec{NumRxQueues: 2, NumTxQueues: 2, RxQueueSize: 1024, TxQueueSize: 1024},
+		IsMain:        true,
+		InterfaceName: name,
+	}
+	return conf, spec
+}
+
+var _ = Describe("VPP configuration of uplink drivers", func() {
+	var template string
+
+	BeforeEach(func() {
+		input, err := os.ReadFile(filepath.Join("testdata", "startup.conf.template"))
+		Expect(err).ToNot(HaveOccurred())
+		template = string(input)
+	})
+
+	expectGolden := func(name string, driver UplinkDriver) {
+		vppConf, err := vppconf.Parse(template)
+		Expect(err).ToNot(HaveOccurred())
+		driver.UpdateVppConfig(vppConf)
+		Expect(vppConf.Validate()).To(Succeed())
+
+		golden := filepath.Join("testdata", name+".conf.golden")
+		if *update {
+			Expect(os.WriteFile(golden, []byte(vppConf.String()), 0644)).To(Succeed())
+		}
+		expected, err := os.ReadFile(golden)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vppConf.String()).To(Equal(string(expected)))
+	}
+
+	for _, name := range []string{
+		NativeDriverAfPacket,
+		NativeDriverAfXdp,
+		NativeDriverVirtio,
+		NativeDriverAvf,
+		NativeDriverRdma,
+		NativeDriverVmxnet3,
+		NativeDriverNone,
+	} {
+		name := name
+		It("configures VPP for the "+name+" driver", func() {
+			conf, spec := newTestUplink("eth1")
+			params := &config.VppManagerParams{AvailableHugePages: 1024}
+			expectGolden(name, newUplinkDriver(name, params, conf, spec))
+		})
+	}
+
+	It("configures VPP for the dpdk driver", func() {
+		conf, spec := newTestUplink("eth1")
+		params := &config.VppManagerParams{AvailableHugePages: 1024}
+		expectGolden(NativeDriverDpdk, newUplinkDriver(NativeDriverDpdk, params, conf, spec))
+	})
+
+	It("configures VPP for the dpdk driver without hugepages", func() {
+		conf, spec := newTestUplink("eth1")
+		params := &config.VppManagerParams{AvailableHugePages: 0}
+		expectGolden(NativeDriverDpdk+"-nohuge", newUplinkDriver(NativeDriverDpdk, params, conf, spec))
+	})
+
+	It("keeps the dpdk devices of the template", func() {
+		template += "dpdk {\n  dev 0000:00:08.0 { num-rx-queues 4 }\n}\n"
+		conf, spec := newTestUplink("eth1")
+		params := &config.VppManagerParams{AvailableHugePages: 1024}
+		expectGolden(NativeDriverDpdk+"-template", newUplinkDriver(NativeDriverDpdk, params, conf, spec))
+	})
+
+	It("configures VPP for bond members", func() {
+		conf, spec := newTestUplink("bond0")
+		member0, _ := newTestUplink("eth1")
+		member1, _ := newTestUplink("eth2")
+		member1.PciID = "0000:00:09.0"
+		conf.Bond = &config.LinuxBond{Members: []*config.LinuxInterfaceState{member0, member1}}
+		params := &config.VppManagerParams{AvailableHugePages: 1024}
+		expectGolden("bond-"+NativeDriverAvf, NewBondDriver(NativeDriverAvf, params, conf, spec))
+	})
+})
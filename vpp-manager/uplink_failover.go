@@ -0,0 +1,291 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+type uplinkLinkEvent struct {
+	idx  int
+	isUp bool
+}
+
+// uplinkFailover moves the node addresses and routes of the main uplink
+// to a standby uplink while its link is down in VPP
+type uplinkFailover struct {
+	runner *VppRunner
+	vpp    *vpplink.VppLink
+	conf   *config.UplinkFailoverConfigType
+
+	mainIdx   int
+	standbys  []int
+	activeIdx int
+	linkUp    map[int]bool
+	events    chan uplinkLinkEvent
+
+	// after and writeInfo are replaced in tests
+	after     func(time.Duration) <-chan time.Time
+	writeInfo func() error
+}
+
+func (f *uplinkFailover) name(idx int) string {
+	return f.runner.params.UplinksSpecs[idx].InterfaceName
+}
+
+func (f *uplinkFailover) swIfIndex(idx int) uint32 {
+	return f.runner.params.UplinksSpecs[idx].SwIfIndex
+}
+
+// desiredIdx returns the uplink that should hold the node addresses: the main
+// uplink if it is up, otherwise the first standby that is up
+func (f *uplinkFailover) desiredIdx() (idx int, reason string) {
+	if f.linkUp[f.mainIdx] {
+		return f.mainIdx, fmt.Sprintf("main uplink %s up for %s", f.name(f.mainIdx), *f.conf.RecoverHoldDown)
+	}
+	if f.activeIdx != f.mainIdx && f.linkUp[f.activeIdx] {
+		return f.activeIdx, ""
+	}
+	for _, idx := range f.standbys {
+		if f.linkUp[idx] {
+			return idx, fmt.Sprintf("uplink %s down for %s", f.name(f.activeIdx), *f.conf.HoldDown)
+		}
+	}
+	return f.activeIdx, ""
+}
+
+// getUplinkStatus returns the key of an uplink in the vpp manager info
+func (f *uplinkFailover) getUplinkStatus(idx int) (string, bool) {
+	for name, status := range config.Info.UplinkStatuses {
+		if status.SwIfIndex == f.swIfIndex(idx) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (f *uplinkFailover) updateInfo(update func()) {
+	f.runner.infoLock.Lock()
+	defer f.runner.infoLock.Unlock()
+	update()
+	err := f.writeInfo()
+	if err != nil {
+		log.Errorf("Error writing vpp manager file: %v", err)
+	}
+}
+
+func (f *uplinkFailover) setLinkUp(idx int, isUp bool) {
+	f.linkUp[idx] = isUp
+	f.updateInfo(func() {
+		if name, ok := f.getUplinkStatus(idx); ok {
+			status := config.Info.UplinkStatuses[name]
+			status.IsUp = isUp
+			config.Info.UplinkStatuses[name] = status
+		}
+	})
+}
+
+// getNodeRoutes returns the routes following the node addresses, through
+// the uplink with swIfIndex
+func (f *uplinkFailover) getNodeRoutes(swIfIndex uint32) (routes []*types.Route) {
+	for _, route := range f.runner.conf[f.mainIdx].Routes {
+		routes = append(routes, &types.Route{
+			Dst:   route.Dst,
+			Paths: []types.RoutePath{{Gw: route.Gw, SwIfIndex: swIfIndex}},
+		})
+	}
+	gws, err := config.GetCalicoVppInitialConfig().GetDefaultGWs()
+	if err != nil {
+		log.Errorf("Error getting default gateways: %v", err)
+	}
+	for _, defaultGW := range gws {
+		routes = append(routes, &types.Route{
+			Paths: []types.RoutePath{{Gw: defaultGW, SwIfIndex: swIfIndex}},
+		})
+	}
+	return routes
+}
+
+// move moves the node addresses and routes between two uplinks
+func (f *uplinkFailover) move(fromIdx, toIdx int, reason string) {
+	log.Warnf("Moving node addresses from uplink %s to %s: %s", f.name(fromIdx), f.name(toIdx), reason)
+	from, to := f.swIfIndex(fromIdx), f.swIfIndex(toIdx)
+	for _, route := range f.getNodeRoutes(from) {
+		err := f.vpp.RouteDel(route)
+		if err != nil {
+			log.Errorf("Error deleting route %s: %v", route, err)
+		}
+	}
+	for _, addr := range f.runner.conf[f.mainIdx].Addresses {
+		if addr.IP.IsLinkLocalUnicast() && !common.IsFullyQualified(addr.IPNet) && common.IsV6Cidr(addr.IPNet) {
+			continue
+		}
+		err := f.vpp.DelInterfaceAddress(from, addr.IPNet)
+		if err != nil {
+			log.Errorf("Error deleting address %s from uplink %s: %v", addr.IPNet, f.name(fromIdx), err)
+		}
+		err = f.vpp.AddInterfaceAddress(to, addr.IPNet)
+		if err != nil {
+			log.Errorf("Error adding address %s to uplink %s: %v", addr.IPNet, f.name(toIdx), err)
+		}
+	}
+	for _, route := range f.getNodeRoutes(to) {
+		err := f.vpp.RouteAdd(route)
+		if err != nil {
+			log.Errorf("Error adding route %s: %v", route, err)
+		}
+	}
+	f.activeIdx = toIdx
+
+	transition := config.UplinkTransition{
+		Time:   time.Now(),
+		From:   f.name(fromIdx),
+		To:     f.name(toIdx),
+		Reason: reason,
+	}
+	f.updateInfo(func() {
+		for _, idx := range []int{fromIdx, toIdx} {
+			if name, ok := f.getUplinkStatus(idx); ok {
+				status := config.Info.UplinkStatuses[name]
+				status.HasNodeAddresses = idx == toIdx
+				status.AddTransition(transition)
+				config.Info.UplinkStatuses[name] = status
+			}
+		}
+		config.Info.UplinkFailovers++
+	})
+}
+
+func (f *uplinkFailover) run(t *tomb.Tomb) error {
+	var holdDown <-chan time.Time
+	pendingIdx := f.activeIdx
+	for {
+		idx, reason := f.desiredIdx()
+		if idx == f.activeIdx {
+			holdDown = nil
+		} else if holdDown == nil || idx != pendingIdx {
+			hold := *f.conf.HoldDown
+			if idx == f.mainIdx {
+				hold = *f.conf.RecoverHoldDown
+			}
+			log.Infof("Moving node addresses to uplink %s in %s unless links change", f.name(idx), hold)
+			holdDown = f.after(hold)
+			pendingIdx = idx
+		}
+		select {
+		case <-t.Dying():
+			return nil
+		case event := <-f.events:
+			if f.linkUp[event.idx] != event.isUp {
+				log.Infof("Uplink %s is now up:%t", f.name(event.idx), event.isUp)
+				f.setLinkUp(event.idx, event.isUp)
+			}
+		case <-holdDown:
+			holdDown = nil
+			f.move(f.activeIdx, pendingIdx, reason)
+		}
+	}
+}
+
+// watchUplinkFailover watches the link state of the uplinks in VPP, and
+// moves the node addresses to a standby uplink while the main uplink is
+// down. It returns false when failover is not configured
+func (v *VppRunner) watchUplinkFailover(t *tomb.Tomb) bool {
+	conf := config.GetCalicoVppInterfaces().UplinkFailover
+	if conf == nil {
+		return false
+	}
+	f := &uplinkFailover{
+		runner:    v,
+		conf:      conf,
+		linkUp:    make(map[int]bool),
+		events:    make(chan uplinkLinkEvent, 10),
+		after:     time.After,
+		writeInfo: utils.WriteInfoFile,
+	}
+	for idx, spec := range v.params.UplinksSpecs {
+		if spec.IsMain {
+			f.mainIdx = idx
+			f.activeIdx = idx
+		} else if spec.IsStandby {
+			f.standbys = append(f.standbys, idx)
+		}
+	}
+	if len(f.standbys) == 0 {
+		log.Warnf("uplinkFailover is configured without standby uplinks")
+		return false
+	}
+	// The main API connection is closed once VPP is configured
+	vpp, err := utils.CreateVppLink()
+	if err != nil {
+		log.Errorf("Error connecting to VPP, not watching uplinks: %v", err)
+		return false
+	}
+	f.vpp = vpp
+	watchers := make([]vpplink.InterfaceEventWatcher, 0)
+	for _, idx := range append([]int{f.mainIdx}, f.standbys...) {
+		watcher, err := vpp.WatchInterfaceEvents(f.swIfIndex(idx))
+		if err != nil {
+			log.Errorf("Error watching uplink %s: %v", f.name(idx), err)
+			continue
+		}
+		watchers = append(watchers, watcher)
+		details, err := vpp.GetInterfaceDetails(f.swIfIndex(idx))
+		if err != nil || details == nil {
+			log.Errorf("Error getting uplink %s link state: %v", f.name(idx), err)
+		} else {
+			f.setLinkUp(idx, details.IsLinkUp)
+		}
+		t.Go(func() error {
+			for {
+				select {
+				case <-t.Dying():
+					return nil
+				case event, ok := <-watcher.Events():
+					if !ok {
+						return nil
+					}
+					if event.Type == types.InterfaceEventDeleted {
+						continue
+					}
+					select {
+					case f.events <- uplinkLinkEvent{idx: idx, isUp: event.Type == types.InterfaceEventLinkUp}:
+					case <-t.Dying():
+						return nil
+					}
+				}
+			}
+		})
+	}
+	t.Go(func() error {
+		err := f.run(t)
+		for _, watcher := range watchers {
+			watcher.Stop()
+		}
+		vpp.Close()
+		return err
+	})
+	return true
+}
@@ -0,0 +1,203 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+
+	"github.com/projectcalico/vpp-dataplane/v3/config"
+)
+
+type testHoldDown struct {
+	hold time.Duration
+	c    chan time.Time
+}
+
+// testFailoverInfo is what the failover wrote to the info file
+type testFailoverInfo struct {
+	failovers        int
+	hasNodeAddresses string
+}
+
+var _ = Describe("Uplink failover", func() {
+	var (
+		f         *uplinkFailover
+		holdDowns chan testHoldDown
+		infos     chan testFailoverInfo
+	)
+
+	BeforeEach(func() {
+		log = logrus.New()
+		log.SetOutput(GinkgoWriter)
+		holdDown, recoverHoldDown := 3*time.Second, 10*time.Second
+		runner := &VppRunner{
+			params: &config.VppManagerParams{UplinksSpecs: []config.UplinkInterfaceSpec{
+				{InterfaceName: "eth0", IsMain: true, SwIfIndex: 1},
+				{InterfaceName: "eth1", IsStandby: true, SwIfIndex: 2},
+				{InterfaceName: "eth2", IsStandby: true, SwIfIndex: 3},
+			}},
+			conf: []*config.LinuxInterfaceState{{}, {}, {}},
+		}
+		config.Info = &config.VppManagerInfo{UplinkStatuses: map[string]config.UplinkStatus{
+			"eth0": {SwIfIndex: 1, IsMain: true, HasNodeAddresses: true},
+			"eth1": {SwIfIndex: 2, IsStandby: true},
+			"eth2": {SwIfIndex: 3, IsStandby: true},
+		}}
+		holdDowns = make(chan testHoldDown, 10)
+		infos = make(chan testFailoverInfo, 100)
+		f = &uplinkFailover{
+			runner:    runner,
+			conf:      &config.UplinkFailoverConfigType{HoldDown: &holdDown, RecoverHoldDown: &recoverHoldDown},
+			mainIdx:   0,
+			standbys:  []int{1, 2},
+			activeIdx: 0,
+			linkUp:    map[int]bool{0: true, 1: true, 2: true},
+			events:    make(chan uplinkLinkEvent, 10),
+			after: func(hold time.Duration) <-chan time.Time {
+				h := testHoldDown{hold: hold, c: make(chan time.Time, 1)}
+				holdDowns <- h
+				return h.c
+			},
+			// Called with the info lock held
+			writeInfo: func() error {
+				info := testFailoverInfo{failovers: config.Info.UplinkFailovers}
+				for name, status := range config.Info.UplinkStatuses {
+					if status.HasNodeAddresses {
+						info.hasNodeAddresses = name
+					}
+				}
+				infos <- info
+				return nil
+			},
+		}
+	})
+
+	DescribeTable("Chooses the uplink holding the node addresses",
+		func(activeIdx int, linkUp map[int]bool, expectedIdx int) {
+			f.activeIdx = activeIdx
+			f.linkUp = linkUp
+			idx, _ := f.desiredIdx()
+			Expect(idx).To(Equal(expectedIdx))
+		},
+		Entry("main up", 0, map[int]bool{0: true, 1: true, 2: true}, 0),
+		Entry("main down", 0, map[int]bool{1: true, 2: true}, 1),
+		Entry("main and first standby down", 0, map[int]bool{2: true}, 2),
+		Entry("all down", 0, map[int]bool{}, 0),
+		Entry("active standby up", 2, map[int]bool{1: true, 2: true}, 2),
+		Entry("active standby down", 2, map[int]bool{1: true}, 1),
+		Entry("main back up", 2, map[int]bool{0: true, 2: true}, 0),
+		Entry("everything down on a standby", 1, map[int]bool{}, 1),
+	)
+
+	Context("With the failover running", func() {
+		var t tomb.Tomb
+
+		expectHoldDown := func(hold time.Duration) testHoldDown {
+			var h testHoldDown
+			Eventually(holdDowns).Should(Receive(&h))
+			Expect(h.hold).To(Equal(hold))
+			return h
+		}
+
+		expectInfo := func(failovers int, hasNodeAddresses string) {
+			Eventually(infos).Should(Receive(Equal(testFailoverInfo{failovers, hasNodeAddresses})))
+		}
+
+		BeforeEach(func() {
+			t = tomb.Tomb{}
+			t.Go(func() error { return f.run(&t) })
+		})
+
+		AfterEach(func() {
+			t.Kill(nil)
+			Expect(t.Wait()).To(Succeed())
+		})
+
+		It("Moves the node addresses after the hold-down", func() {
+			f.events <- uplinkLinkEvent{idx: 0, isUp: false}
+			expectInfo(0, "eth0")
+			h := expectHoldDown(3 * time.Second)
+			h.c <- time.Now()
+			expectInfo(1, "eth1")
+
+			f.events <- uplinkLinkEvent{idx: 0, isUp: true}
+			expectInfo(1, "eth1")
+			h = expectHoldDown(10 * time.Second)
+			h.c <- time.Now()
+			expectInfo(2, "eth0")
+			Consistently(holdDowns).ShouldNot(Receive())
+		})
+
+		It("Does not move the node addresses when the link flaps", func() {
+			f.events <- uplinkLinkEvent{idx: 0, isUp: false}
+			expectInfo(0, "eth0")
+			flap := expectHoldDown(3 * time.Second)
+			f.events <- uplinkLinkEvent{idx: 0, isUp: true}
+			expectInfo(0, "eth0")
+			// The cancelled hold-down is not watched anymore
+			flap.c <- time.Now()
+			Consistently(infos).ShouldNot(Receive())
+
+			f.events <- uplinkLinkEvent{idx: 0, isUp: false}
+			expectInfo(0, "eth0")
+			h := expectHoldDown(3 * time.Second)
+			h.c <- time.Now()
+			expectInfo(1, "eth1")
+		})
+
+		It("Restarts the hold-down when the target uplink changes", func() {
+			f.events <- uplinkLinkEvent{idx: 0, isUp: false}
+			expectInfo(0, "eth0")
+			first := expectHoldDown(3 * time.Second)
+			f.events <- uplinkLinkEvent{idx: 1, isUp: false}
+			expectInfo(0, "eth0")
+			h := expectHoldDown(3 * time.Second)
+			first.c <- time.Now()
+			Consistently(infos).ShouldNot(Receive())
+			h.c <- time.Now()
+			expectInfo(1, "eth2")
+		})
+
+		It("Recovers the main uplink after the recover hold-down", func() {
+			f.events <- uplinkLinkEvent{idx: 0, isUp: false}
+			expectInfo(0, "eth0")
+			h := expectHoldDown(3 * time.Second)
+			h.c <- time.Now()
+			expectInfo(1, "eth1")
+
+			// The main uplink flaps while recovering
+			f.events <- uplinkLinkEvent{idx: 0, isUp: true}
+			expectInfo(1, "eth1")
+			flap := expectHoldDown(10 * time.Second)
+			f.events <- uplinkLinkEvent{idx: 0, isUp: false}
+			expectInfo(1, "eth1")
+			flap.c <- time.Now()
+			Consistently(infos).ShouldNot(Receive())
+
+			f.events <- uplinkLinkEvent{idx: 0, isUp: true}
+			expectInfo(1, "eth1")
+			h = expectHoldDown(10 * time.Second)
+			h.c <- time.Now()
+			expectInfo(2, "eth0")
+		})
+	})
+})
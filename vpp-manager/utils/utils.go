@@ -17,6 +17,8 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -32,6 +34,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/pkg/errors"
@@ -40,6 +43,7 @@ import (
 	"github.com/yookoala/realpath"
 	"golang.org/x/sys/unix"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -79,12 +83,34 @@ func WriteFile(state string, path string) error {
 	return nil
 }
 
+// WriteInfoFile writes the info file atomically, as the agent and the
+// health checks read it while vpp-manager updates it
 func WriteInfoFile() error {
 	file, err := json.MarshalIndent(config.Info, "", " ")
 	if err != nil {
 		return errors.Errorf("Failed to encode json for info file: %s", err)
 	}
-	return os.WriteFile(config.VppManagerInfoFile, file, 0644)
+	tmpFile, err := os.CreateTemp(filepath.Dir(config.VppManagerInfoFile), filepath.Base(config.VppManagerInfoFile)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "cannot create temporary info file")
+	}
+	_, err = tmpFile.Write(file)
+	if err == nil {
+		// CreateTemp creates files only readable by their owner
+		err = tmpFile.Chmod(0644)
+	}
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), config.VppManagerInfoFile)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return errors.Wrapf(err, "cannot write info file %s", config.VppManagerInfoFile)
+	}
+	return nil
 }
 
 func RouteIsIP6(r *netlink.Route) bool {
@@ -118,6 +144,59 @@ func SetInterfaceRxQueues(ifname string, queues int) error {
 	return cmd.Run()
 }
 
+// ReadSysfsInt reads an integer from a sysfs file
+func ReadSysfsInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot read %s", path)
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot parse %s", path)
+	}
+	return value, nil
+}
+
+// WriteSysfsInt writes an integer to a sysfs file
+func WriteSysfsInt(path string, value int) error {
+	err := os.WriteFile(path, []byte(strconv.Itoa(value)), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "cannot write %d to %s", value, path)
+	}
+	return nil
+}
+
+// IsBpffsPath returns whether path is in a bpf filesystem
+func IsBpffsPath(path string) bool {
+	var statfs unix.Statfs_t
+	err := unix.Statfs(path, &statfs)
+	if err != nil {
+		return false
+	}
+	return statfs.Type == unix.BPF_FS_MAGIC
+}
+
+// GetPinnedBpfObjectFd opens a bpf object pinned in bpffs, the
+// caller closes the returned fd
+func GetPinnedBpfObjectFd(path string) (int, error) {
+	pathname, err := unix.BytePtrFromString(path)
+	if err != nil {
+		return -1, errors.Wrapf(err, "invalid path %s", path)
+	}
+	// union bpf_attr for BPF_OBJ_GET
+	attr := struct {
+		pathname  uint64
+		bpfFd     uint32
+		fileFlags uint32
+	}{pathname: uint64(uintptr(unsafe.Pointer(pathname)))}
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_OBJ_GET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr))
+	runtime.KeepAlive(pathname)
+	if errno != 0 {
+		return -1, errors.Wrapf(errno, "cannot get bpf object pinned at %s", path)
+	}
+	return int(fd), nil
+}
+
 func SwapDriver(pciDevice, newDriver string, addID bool) error {
 	if pciDevice == "" {
 		log.Warnf("PCI ID not found, not swapping drivers")
@@ -412,24 +491,32 @@ func GetnetnsPath(nsName string) string {
 }
 
 func GetInterfaceVFPciID(pciID string) (vfPciID string, err error) {
-	virtfn0Path := fmt.Sprintf("/sys/bus/pci/devices/%s/virtfn0", pciID)
-	vfPciID, err = getPciIDFromLink(virtfn0Path)
+	return GetInterfaceNthVFPciID(pciID, 0)
+}
+
+func GetInterfaceNthVFPciID(pciID string, vf int) (vfPciID string, err error) {
+	virtfnPath := fmt.Sprintf("/sys/bus/pci/devices/%s/virtfn%d", pciID, vf)
+	vfPciID, err = getPciIDFromLink(virtfnPath)
 	if err != nil {
-		return "", errors.Wrapf(err, "Couldn't find VF pciID in %s", virtfn0Path)
+		return "", errors.Wrapf(err, "Couldn't find VF pciID in %s", virtfnPath)
 	}
 	return vfPciID, nil
 }
 
 func CreateInterfaceVF(pciID string) error {
+	return CreateInterfaceVFs(pciID, 1)
+}
+
+func CreateInterfaceVFs(pciID string, n int) error {
 	numVfs, err := GetInterfaceNumVFs(pciID)
 	if err != nil {
 		return errors.Wrapf(err, "cannot get num VFs for %s", pciID)
 	}
 
 	if numVfs == 0 {
-		/* Create a VF only if none is available */
+		/* Create VFs only if none is available */
 		sriovNumvfsPath := fmt.Sprintf("/sys/bus/pci/devices/%s/sriov_numvfs", pciID)
-		err = WriteFile("1", sriovNumvfsPath)
+		err = WriteFile(strconv.Itoa(n), sriovNumvfsPath)
 		if err != nil {
 			return errors.Wrapf(err, "cannot add VFs for %s", pciID)
 		}
@@ -525,6 +612,135 @@ func GetNrHugepages() (int, error) {
 	return int(nrHugepages), nil
 }
 
+// ParseCPUList parses a list of cpus like `0-3,8`, as found in sysfs
+func ParseCPUList(cpuList string) ([]int, error) {
+	cpus := make([]int, 0)
+	cpuList = strings.TrimSpace(cpuList)
+	if cpuList == "" {
+		return cpus, nil
+	}
+	for _, part := range strings.Split(cpuList, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cpu list %s", cpuList)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				return nil, errors.Errorf("invalid cpu list %s", cpuList)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// FormatCPUList formats sorted cpus as a list like `0-3,8`
+func FormatCPUList(cpus []int) string {
+	parts := make([]string, 0)
+	for i := 0; i < len(cpus); i++ {
+		first := cpus[i]
+		for i+1 < len(cpus) && cpus[i+1] == cpus[i]+1 {
+			i++
+		}
+		if cpus[i] == first {
+			parts = append(parts, strconv.Itoa(first))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", first, cpus[i]))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func readCPUListFile(path string) ([]int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCPUList(string(content))
+}
+
+// GetCpusetCPUs returns the cpus our cgroup can run on, or all the online
+// cpus when the cpuset is not available
+func GetCpusetCPUs() ([]int, error) {
+	for _, path := range []string{
+		"/sys/fs/cgroup/cpuset.cpus.effective",
+		"/sys/fs/cgroup/cpuset.cpus",
+		"/sys/fs/cgroup/cpuset/cpuset.effective_cpus",
+		"/sys/devices/system/cpu/online",
+	} {
+		cpus, err := readCPUListFile(path)
+		if err == nil && len(cpus) != 0 {
+			return cpus, nil
+		} else if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "Couldnt read %s", path)
+		}
+	}
+	return nil, errors.Errorf("Couldnt find the cpus we can run on")
+}
+
+// GetNumaNodeCPUs returns the cpus of a NUMA node
+func GetNumaNodeCPUs(node int) ([]int, error) {
+	path := fmt.Sprintf("/sys/devices/system/node/node%d/cpulist", node)
+	cpus, err := readCPUListFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Couldnt read %s", path)
+	}
+	return cpus, nil
+}
+
+// GetPciNumaNode returns the NUMA node of a PCI device, -1 if unknown
+func GetPciNumaNode(pciID string) (int, error) {
+	path := fmt.Sprintf("/sys/bus/pci/devices/%s/numa_node", pciID)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return -1, errors.Wrapf(err, "Couldnt read %s", path)
+	}
+	node, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return -1, errors.Wrapf(err, "Couldnt parse %s", path)
+	}
+	return node, nil
+}
+
+// GetDefaultHugepageSize returns the default hugepage size in kB
+func GetDefaultHugepageSize() (int, error) {
+	content, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, errors.Wrapf(err, "Couldnt read /proc/meminfo")
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "Hugepagesize:" {
+			size, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return 0, errors.Wrapf(err, "Couldnt parse hugepage size %s", line)
+			}
+			return size, nil
+		}
+	}
+	return 0, errors.Errorf("Couldnt find Hugepagesize in /proc/meminfo")
+}
+
+// GetNumaFreeHugepages returns the free hugepages of the given size in kB
+// on a NUMA node
+func GetNumaFreeHugepages(node int, sizeKB int) (int, error) {
+	path := fmt.Sprintf("/sys/devices/system/node/node%d/hugepages/hugepages-%dkB/free_hugepages", node, sizeKB)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Couldnt read %s", path)
+	}
+	free, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "Couldnt parse %s", path)
+	}
+	return free, nil
+}
+
 func ParseKernelVersion(versionStr string) (ver *config.KernelVersion, err error) {
 	re := regexp.MustCompile(`([0-9]+)\.([0-9]+)\.([0-9]+)\-([0-9]+)`)
 	match := re.FindStringSubmatch(versionStr)
@@ -717,6 +933,52 @@ func FetchNodeAnnotations(nodeName string) map[string]string {
 	return node.Annotations
 }
 
+// AnnotateNode sets annotations on the kubernetes node
+func AnnotateNode(nodeName string, annotations map[string]string) error {
+	clusterConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return errors.Wrap(err, "cannot get clusterConfig")
+	}
+	k8sclient, err := kubernetes.NewForConfig(clusterConfig)
+	if err != nil {
+		return errors.Wrap(err, "cannot create k8s client")
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot encode node annotations")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = k8sclient.CoreV1().Nodes().Patch(ctx, nodeName, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cannot annotate node %s", nodeName)
+	}
+	return nil
+}
+
+// GetKernelModuleVersion returns the version of a kernel module, empty
+// for in-tree modules which do not report one
+func GetKernelModuleVersion(module string) string {
+	version, err := os.ReadFile(fmt.Sprintf("/sys/module/%s/version", module))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(version))
+}
+
+// GetImageVersion returns a short hash of the calico-vpp version file,
+// which changes with the VPP and vpp-manager builds
+func GetImageVersion() string {
+	version, err := os.ReadFile(config.CalicoVppVersionFile)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(version)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
 type timeAndPath struct {
 	path    string
 	modTime time.Time
@@ -32,6 +32,25 @@ func TestCleanupCoreFiles(t *testing.T) {
 	RunSpecs(t, "vpp-manager utils tests")
 }
 
+var _ = Describe("Test CPU lists", func() {
+	It("Parses and formats cpu lists", func() {
+		cpus, err := ParseCPUList("0-3,8,10-11\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cpus).To(Equal([]int{0, 1, 2, 3, 8, 10, 11}))
+		Expect(FormatCPUList(cpus)).To(Equal("0-3,8,10-11"))
+
+		cpus, err = ParseCPUList("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cpus).To(BeEmpty())
+		Expect(FormatCPUList(cpus)).To(Equal(""))
+
+		_, err = ParseCPUList("3-1")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseCPUList("a")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 var _ = Describe("Test CleanupCoreFiles", func() {
 	It("TestIncrementDecrement", func() {
 		var incDecPairs = []struct{ low, high net.IP }{
@@ -17,12 +17,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -37,8 +39,10 @@ import (
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/cni/podinterface"
 	"github.com/projectcalico/vpp-dataplane/v3/calico-vpp-agent/common"
 	"github.com/projectcalico/vpp-dataplane/v3/config"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/startup"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/uplink"
 	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/utils"
+	"github.com/projectcalico/vpp-dataplane/v3/vpp-manager/vppconf"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
 )
@@ -48,12 +52,24 @@ type VppRunner struct {
 	conf         []*config.LinuxInterfaceState
 	vpp          *vpplink.VppLink
 	uplinkDriver []uplink.UplinkDriver
+	// vppExitedAt is when the last VPP exited, to measure
+	// the recovery time of restarts
+	vppExitedAt time.Time
+	restarting  bool
+	// driverReasons is why each uplink driver was chosen,
+	// by interface name
+	driverReasons map[string]string
+	// ready is whether the last VPP run became ready
+	ready bool
+	// infoLock protects the vpp manager info updated by watchers
+	infoLock sync.Mutex
 }
 
 func NewVPPRunner(params *config.VppManagerParams, confs []*config.LinuxInterfaceState) *VppRunner {
 	return &VppRunner{
-		params: params,
-		conf:   confs,
+		params:        params,
+		conf:          confs,
+		driverReasons: make(map[string]string),
 	}
 }
 
@@ -75,11 +91,22 @@ func (v *VppRunner) GenerateVppConfigFile(drivers []uplink.UplinkDriver) error {
 	if err != nil {
 		return err
 	}
+	vppConf, err := vppconf.Parse(template)
+	if err != nil {
+		return errors.Wrap(err, "Error parsing VPP configuration template")
+	}
 	for _, driver := range drivers {
-		template = driver.UpdateVppConfigFile(template)
+		driver.UpdateVppConfig(vppConf)
+	}
+	if autoTune := config.GetCalicoVppInitialConfig().AutoTune; autoTune != nil {
+		startup.AutoTuneVppConfig(vppConf, startup.GetNumaResources(v.conf, v.params), autoTune)
+	}
+	err = vppConf.Validate()
+	if err != nil {
+		return errors.Wrap(err, "Invalid VPP configuration")
 	}
 	err = errors.Wrapf(
-		os.WriteFile(config.VppConfigFile, []byte(template+"\n"), 0644),
+		os.WriteFile(config.VppConfigFile, []byte(vppConf.String()), 0644),
 		"Error writing VPP configuration to %s",
 		config.VppConfigFile,
 	)
@@ -88,6 +115,7 @@ func (v *VppRunner) GenerateVppConfigFile(drivers []uplink.UplinkDriver) error {
 
 func (v *VppRunner) Run(drivers []uplink.UplinkDriver) error {
 	v.uplinkDriver = drivers
+	v.ready = false
 	for idx := range v.conf {
 		log.Infof("Running with uplink %s", drivers[idx].GetName())
 	}
@@ -108,15 +136,68 @@ func (v *VppRunner) Run(drivers []uplink.UplinkDriver) error {
 		}
 	}
 
-	config.RunHook(config.HookScriptBeforeVppRun, "BEFORE_VPP_RUN", v.params, log)
+	err = config.RunHook(config.HookScriptBeforeVppRun, "BEFORE_VPP_RUN", v.params, v.conf, log)
+	if err != nil {
+		v.restoreConfiguration(v.allInterfacesPhysical())
+		return err
+	}
 	err = v.runVpp()
 	if err != nil {
 		return errors.Wrapf(err, "Error running VPP")
 	}
-	config.RunHook(config.HookScriptVppDoneOk, "VPP_DONE_OK", v.params, log)
+	err = config.RunHook(config.HookScriptVppDoneOk, "VPP_DONE_OK", v.params, v.conf, log)
+	if err != nil {
+		log.Error(err)
+	}
 	return nil
 }
 
+// vppCrashed tells whether the last VPP exited without us or
+// the kubelet asking it to
+func vppCrashed() bool {
+	return vppProcess != nil && !internalKill && !externalTerminate
+}
+
+// addRestart adds a restart at now to the restarts within window before it
+func addRestart(restarts []time.Time, now time.Time, window time.Duration) []time.Time {
+	recent := make([]time.Time, 0, len(restarts)+1)
+	for _, restart := range restarts {
+		if now.Sub(restart) < window {
+			recent = append(recent, restart)
+		}
+	}
+	return append(recent, now)
+}
+
+// RunWithRestarts runs VPP like Run and, when vppRestart is configured,
+// relaunches it with the same uplinks each time it exits unexpectedly.
+// It gives up after maxRestarts restarts within restartWindow
+func (v *VppRunner) RunWithRestarts(drivers []uplink.UplinkDriver) error {
+	restartConf := config.GetCalicoVppInitialConfig().VppRestart
+	restarts := make([]time.Time, 0)
+	for {
+		err := v.Run(drivers)
+		if err != nil || restartConf == nil || !vppCrashed() {
+			return err
+		}
+		restarts = addRestart(restarts, time.Now(), *restartConf.RestartWindow)
+		if len(restarts) > restartConf.MaxRestarts {
+			log.Errorf("VPP exited %d times within %s, not restarting it", len(restarts), *restartConf.RestartWindow)
+			return nil
+		}
+		log.Warnf("VPP exited unexpectedly, restarting it in %s (%d/%d)",
+			*restartConf.Backoff, len(restarts), restartConf.MaxRestarts)
+		time.Sleep(*restartConf.Backoff)
+		if externalTerminate {
+			log.Infof("Asked to terminate, not restarting VPP")
+			return nil
+		}
+		makeNewVPPIndex()
+		config.Info.VppRestarts++
+		v.restarting = true
+	}
+}
+
 func (v *VppRunner) configureGlobalPunt() (err error) {
 	for _, ipFamily := range vpplink.IPFamilies {
 		err = v.vpp.PuntRedirect(types.IPPuntRedirect{
@@ -415,6 +496,37 @@ func (v *VppRunner) setupTapVRF(ifSpec *config.UplinkInterfaceSpec, ifState *con
 	return vrfs, nil
 }
 
+// createVlanUplink creates the VLAN sub-interface used as uplink on top of
+// the interface created by the driver. The main- tag moves to the
+// sub-interface, as the tap replacing the linux VLAN device pairs with it.
+func (v *VppRunner) createVlanUplink(ifSpec *config.UplinkInterfaceSpec, ifState *config.LinuxInterfaceState) (err error) {
+	subID := uint32(ifSpec.VlanID)
+	if ifSpec.InnerVlanID != 0 {
+		subID = uint32(ifSpec.VlanID)<<12 | uint32(ifSpec.InnerVlanID)
+	}
+	log.Infof("Creating vlan %d inner:%d sub-interface of uplink %d", ifSpec.VlanID, ifSpec.InnerVlanID, ifSpec.SwIfIndex)
+	swIfIndex, err := v.vpp.CreateSubif(ifSpec.SwIfIndex, subID, uint16(ifSpec.VlanID), uint16(ifSpec.InnerVlanID),
+		ifSpec.GetVlanProtocol() == netlink.VLAN_PROTOCOL_8021AD)
+	if err != nil {
+		return err
+	}
+	err = v.vpp.SetInterfaceTag(ifSpec.SwIfIndex, "parent-"+ifSpec.InterfaceName)
+	if err != nil {
+		return errors.Wrap(err, "Error tagging vlan parent interface")
+	}
+	err = v.vpp.SetInterfaceTag(swIfIndex, "main-"+ifState.GetHostInterfaceName())
+	if err != nil {
+		return errors.Wrap(err, "Error tagging vlan interface")
+	}
+	err = v.vpp.InterfaceAdminUp(swIfIndex)
+	if err != nil {
+		return errors.Wrap(err, "Error setting vlan interface up")
+	}
+	ifSpec.ParentSwIfIndex = ifSpec.SwIfIndex
+	ifSpec.SwIfIndex = swIfIndex
+	return nil
+}
+
 // configureVppUplinkInterface configures one uplink interface in VPP
 // and creates the corresponding tap in Linux
 func (v *VppRunner) configureVppUplinkInterface(
@@ -445,9 +557,17 @@ func (v *VppRunner) configureVppUplinkInterface(
 		return errors.Wrapf(err, "Error setting %d MTU on uplink interface", uplinkMtu)
 	}
 
-	err = v.vpp.SetInterfaceRxMode(ifSpec.SwIfIndex, types.AllQueues, ifSpec.GetRxModeWithDefault(uplinkDriver.GetDefaultRxMode()))
-	if err != nil {
-		log.Warnf("%v", err)
+	// Sub-interfaces have no queues of their own, and the bond driver
+	// configures those of the bond members
+	rxSwIfIndex := ifSpec.SwIfIndex
+	if ifSpec.IsVlan() {
+		rxSwIfIndex = ifSpec.ParentSwIfIndex
+	}
+	if ifState.Bond == nil {
+		err = v.vpp.SetInterfaceRxMode(rxSwIfIndex, types.AllQueues, ifSpec.GetRxModeWithDefault(uplinkDriver.GetDefaultRxMode()))
+		if err != nil {
+			log.Warnf("%v", err)
+		}
 	}
 
 	err = v.vpp.EnableInterfaceIP6(ifSpec.SwIfIndex)
@@ -538,13 +658,13 @@ func (v *VppRunner) configureVppUplinkInterface(
 
 	tapSwIfIndex, err := v.vpp.CreateTapV2(&types.TapV2{
 		GenericVppInterface: types.GenericVppInterface{
-			HostInterfaceName: ifSpec.InterfaceName,
+			HostInterfaceName: ifState.GetHostInterfaceName(),
 			RxQueueSize:       config.GetCalicoVppInterfaces().VppHostTapSpec.RxQueueSize,
 			TxQueueSize:       config.GetCalicoVppInterfaces().VppHostTapSpec.TxQueueSize,
 			HardwareAddr:      ifSpec.GetVppSideHardwareAddress(),
 		},
 		HostNamespace:  "pid:1", // create tap in root netns
-		Tag:            "host-" + ifSpec.InterfaceName,
+		Tag:            "host-" + ifState.GetHostInterfaceName(),
 		Flags:          vpptap0Flags,
 		HostMtu:        uplinkMtu,
 		HostMacAddress: ifState.HardwareAddr,
@@ -622,9 +742,9 @@ func (v *VppRunner) configureVppUplinkInterface(
 	}
 
 	// Linux side tap setup
-	link, err := netlink.LinkByName(ifSpec.InterfaceName)
+	link, err := netlink.LinkByName(ifState.GetHostInterfaceName())
 	if err != nil {
-		return errors.Wrapf(err, "cannot find interface named %s", ifSpec.InterfaceName)
+		return errors.Wrapf(err, "cannot find interface named %s", ifState.GetHostInterfaceName())
 	}
 
 	fakeNextHopIP4, fakeNextHopIP6, err := v.configureLinuxTap(link, *ifState)
@@ -646,13 +766,89 @@ func (v *VppRunner) configureVppUplinkInterface(
 			LinkIndex:           link.Attrs().Index,
 			Name:                link.Attrs().Name,
 			IsMain:              ifSpec.IsMain,
+			IsStandby:           ifSpec.IsStandby,
+			HasNodeAddresses:    ifSpec.IsMain,
+			PciID:               ifState.PciID,
+			Driver:              uplinkDriver.GetName(),
+			DriverReason:        v.driverReasons[ifState.InterfaceName],
 			FakeNextHopIP4:      fakeNextHopIP4,
 			FakeNextHopIP6:      fakeNextHopIP6,
 		}
+		if bondDriver, ok := uplinkDriver.(*uplink.BondDriver); ok {
+			uplinkStatus := config.Info.UplinkStatuses[link.Attrs().Name]
+			uplinkStatus.BondMembers = bondDriver.GetMemberStatuses(v.vpp)
+			config.Info.UplinkStatuses[link.Attrs().Name] = uplinkStatus
+		}
 	}
 	return nil
 }
 
+// watchBondMembers keeps the link state of bond members up to date in the
+// vpp manager info file. It returns false when there is no bond to watch.
+func (v *VppRunner) watchBondMembers(t *tomb.Tomb) bool {
+	type bondMember struct {
+		uplink string
+		index  int
+	}
+	members := make(map[uint32]bondMember)
+	for name, uplinkStatus := range config.Info.UplinkStatuses {
+		for i, member := range uplinkStatus.BondMembers {
+			members[member.SwIfIndex] = bondMember{uplink: name, index: i}
+		}
+	}
+	if len(members) == 0 {
+		return false
+	}
+	// The main API connection is closed once VPP is configured
+	vpp, err := utils.CreateVppLink()
+	if err != nil {
+		log.Errorf("Error connecting to VPP, not watching bond members: %v", err)
+		return false
+	}
+	var wg sync.WaitGroup
+	for swIfIndex, member := range members {
+		watcher, err := vpp.WatchInterfaceEvents(swIfIndex)
+		if err != nil {
+			log.Errorf("Error watching bond member %d: %v", swIfIndex, err)
+			continue
+		}
+		wg.Add(1)
+		t.Go(func() error {
+			defer wg.Done()
+			defer watcher.Stop()
+			for {
+				select {
+				case <-t.Dying():
+					return nil
+				case event, ok := <-watcher.Events():
+					if !ok {
+						return nil
+					}
+					isUp := event.Type == types.InterfaceEventLinkUp
+					v.infoLock.Lock()
+					status := &config.Info.UplinkStatuses[member.uplink].BondMembers[member.index]
+					if status.IsUp != isUp {
+						log.Infof("Bond member %s of %s is now up:%t", status.Name, member.uplink, isUp)
+						status.IsUp = isUp
+						err := utils.WriteInfoFile()
+						if err != nil {
+							log.Errorf("Error writing vpp manager file: %v", err)
+						}
+					}
+					v.infoLock.Unlock()
+				}
+			}
+		})
+	}
+	t.Go(func() error {
+		<-t.Dying()
+		wg.Wait()
+		vpp.Close()
+		return nil
+	})
+	return true
+}
+
 func (v *VppRunner) doVppGlobalConfiguration() (err error) {
 	err = v.allocateStaticVRFs()
 	if err != nil {
@@ -884,6 +1080,13 @@ func (v *VppRunner) runVpp() (err error) {
 	if err != nil {
 		return errors.Wrap(err, "Error adding redirect to tap")
 	}
+	err = config.RunHook(config.HookScriptBeforeUplinkCreate, "BEFORE_UPLINK_CREATE", v.params, v.conf, log)
+	if err != nil {
+		terminateVpp("%v", err)
+		v.vpp.Close()
+		<-vppDeadChan
+		return err
+	}
 	for idx := 0; idx < len(v.params.UplinksSpecs); idx++ {
 		err := v.uplinkDriver[idx].CreateMainVppInterface(vpp, vppProcess.Pid, &v.params.UplinksSpecs[idx])
 		if err != nil {
@@ -902,6 +1105,16 @@ func (v *VppRunner) runVpp() (err error) {
 			return errors.Wrap(err, "Error setting uplink interface up")
 		}
 
+		if v.params.UplinksSpecs[idx].IsVlan() {
+			err = v.createVlanUplink(&v.params.UplinksSpecs[idx], v.conf[idx])
+			if err != nil {
+				terminateVpp("Error creating vlan uplink: %v", err)
+				v.vpp.Close()
+				<-vppDeadChan
+				return errors.Wrap(err, "Error creating vlan uplink")
+			}
+		}
+
 		err = v.configureVppUplinkInterface(v.uplinkDriver[idx], v.conf[idx], v.params.UplinksSpecs[idx])
 
 		if err != nil {
@@ -910,6 +1123,13 @@ func (v *VppRunner) runVpp() (err error) {
 			return errors.Wrap(err, "Error configuring VPP")
 		}
 	}
+	err = config.RunHook(config.HookScriptAfterUplinkCreate, "AFTER_UPLINK_CREATE", v.params, v.conf, log)
+	if err != nil {
+		terminateVpp("%v", err)
+		v.vpp.Close()
+		<-vppDeadChan
+		return err
+	}
 	// Update the Calico node with the IP address actually configured on VPP
 	err = v.updateCalicoNode(v.conf[0])
 	if err != nil {
@@ -918,30 +1138,100 @@ func (v *VppRunner) runVpp() (err error) {
 		return errors.Wrap(err, "Error updating Calico node: please check inter-node connectivity and service prefix")
 	}
 
+	config.Info.VppPid = vppProcess.Pid
 	config.Info.Status = config.Ready
+	v.ready = true
+	if v.restarting {
+		config.Info.LastVppRecovery = time.Since(v.vppExitedAt)
+		v.restarting = false
+		log.Infof("Restarted VPP ready %s after the previous one exited", config.Info.LastVppRecovery.Round(time.Millisecond))
+	}
 	err = utils.WriteInfoFile()
 	if err != nil {
 		log.Errorf("Error writing vpp manager file: %v", err)
 	}
+	v.recordWorkingDrivers()
 	var t tomb.Tomb
+	watching := v.watchBondMembers(&t)
+	if v.watchUplinkFailover(&t) {
+		watching = true
+	}
 
 	// close vpp as we do not program
 	v.vpp.Close()
-	config.RunHook(config.HookScriptVppRunning, "VPP_RUNNING", v.params, log)
+	hookErr := config.RunHook(config.HookScriptVppRunning, "VPP_RUNNING", v.params, v.conf, log)
+	if hookErr != nil {
+		terminateVpp("%v", hookErr)
+	}
 
 	<-vppDeadChan
+	v.vppExitedAt = time.Now()
 	log.Infof("VPP Exited: status %v", err)
 
 	err = t.Killf("Vpp exited, stopping watchers")
 	if err != nil {
 		log.Errorf("Error Killf vpp: %v", err)
 	}
-	return nil
+	if watching {
+		_ = t.Wait()
+	}
+	return hookErr
+}
+
+// recordWorkingDrivers persists the drivers VPP became ready with, and
+// annotates the node with them
+func (v *VppRunner) recordWorkingDrivers() {
+	state, err := uplink.LoadUplinkDriverState(config.UplinkDriverStateFile)
+	if err != nil {
+		log.Warnf("Error loading uplink driver state, overwriting it: %v", err)
+	}
+	annotation := make(map[string]map[string]string)
+	for idx, ifState := range v.conf {
+		state.RecordSuccess(uplink.GetUplinkDriverKey(v.params, ifState), v.uplinkDriver[idx].GetName())
+		annotation[ifState.InterfaceName] = map[string]string{
+			"driver": v.uplinkDriver[idx].GetName(),
+			"reason": v.driverReasons[ifState.InterfaceName],
+		}
+	}
+	err = state.Save(config.UplinkDriverStateFile)
+	if err != nil {
+		log.Warnf("Error saving uplink driver state: %v", err)
+	}
+	value, err := json.Marshal(annotation)
+	if err != nil {
+		log.Warnf("Error encoding uplink drivers annotation: %v", err)
+		return
+	}
+	err = utils.AnnotateNode(*config.NodeName, map[string]string{config.UplinkDriversAnnotation: string(value)})
+	if err != nil {
+		log.Warnf("Error annotating node with uplink drivers: %v", err)
+	}
+}
+
+// recordDriverFailure persists a driver not bringing VPP up for an uplink
+func (v *VppRunner) recordDriverFailure(idx int, driver uplink.UplinkDriver, driverErr error) {
+	if v.ready {
+		// VPP was up, the failure is not the driver's
+		return
+	}
+	state, err := uplink.LoadUplinkDriverState(config.UplinkDriverStateFile)
+	if err != nil {
+		log.Warnf("Error loading uplink driver state, overwriting it: %v", err)
+	}
+	state.RecordFailure(uplink.GetUplinkDriverKey(v.params, v.conf[idx]), driver.GetName(), driverErr)
+	err = state.Save(config.UplinkDriverStateFile)
+	if err != nil {
+		log.Warnf("Error saving uplink driver state: %v", err)
+	}
 }
 
 func (v *VppRunner) restoreConfiguration(allInterfacesPhysical bool) {
 	log.Infof("Restoring configuration")
-	err := utils.ClearVppManagerFiles()
+	err := config.RunHook(config.HookScriptBeforeRestore, "BEFORE_RESTORE", v.params, v.conf, log)
+	if err != nil {
+		log.Error(err)
+	}
+	err = utils.ClearVppManagerFiles()
 	if err != nil {
 		log.Errorf("Error clearing vpp manager files: %v", err)
 	}
@@ -0,0 +1,63 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVppManager(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "vpp-manager tests")
+}
+
+var _ = Describe("VPP restarts", func() {
+	It("Only counts restarts within the window", func() {
+		now := time.Now()
+		window := 10 * time.Minute
+		restarts := addRestart(nil, now.Add(-15*time.Minute), window)
+		Expect(restarts).To(HaveLen(1))
+		restarts = addRestart(restarts, now.Add(-5*time.Minute), window)
+		Expect(restarts).To(HaveLen(1))
+		restarts = addRestart(restarts, now.Add(-time.Minute), window)
+		Expect(restarts).To(HaveLen(2))
+		restarts = addRestart(restarts, now, window)
+		Expect(restarts).To(Equal([]time.Time{now.Add(-5 * time.Minute), now.Add(-time.Minute), now}))
+		restarts = addRestart(restarts, now.Add(window), window)
+		Expect(restarts).To(Equal([]time.Time{now.Add(window)}))
+	})
+
+	It("Only restarts VPP when it crashed", func() {
+		defer func() {
+			vppProcess = nil
+			internalKill = false
+			externalTerminate = false
+		}()
+		Expect(vppCrashed()).To(BeFalse())
+		vppProcess = &os.Process{}
+		Expect(vppCrashed()).To(BeTrue())
+		internalKill = true
+		Expect(vppCrashed()).To(BeFalse())
+		internalKill = false
+		externalTerminate = true
+		Expect(vppCrashed()).To(BeFalse())
+	})
+})
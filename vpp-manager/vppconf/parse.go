@@ -0,0 +1,533 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vppconf
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// node is a word of the configuration, or a block between braces
+type node struct {
+	word    string
+	line    int
+	isBlock bool
+	block   []node
+}
+
+func tokenize(input string) (tokens []node) {
+	for i, line := range strings.Split(input, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.ReplaceAll(line, "{", " { ")
+		line = strings.ReplaceAll(line, "}", " } ")
+		for _, word := range strings.Fields(line) {
+			tokens = append(tokens, node{word: word, line: i + 1})
+		}
+	}
+	return tokens
+}
+
+// parseBlocks groups the tokens between braces, recursively
+func parseBlocks(tokens []node, pos int, nested bool) ([]node, int, error) {
+	nodes := make([]node, 0)
+	for pos < len(tokens) {
+		token := tokens[pos]
+		pos++
+		switch token.word {
+		case "{":
+			block, next, err := parseBlocks(tokens, pos, true)
+			if err != nil {
+				return nil, 0, err
+			}
+			nodes = append(nodes, node{isBlock: true, block: block, line: token.line})
+			pos = next
+		case "}":
+			if !nested {
+				return nil, 0, errors.Errorf("line %d: unexpected '}'", token.line)
+			}
+			return nodes, pos, nil
+		default:
+			nodes = append(nodes, token)
+		}
+	}
+	if nested {
+		return nil, 0, errors.Errorf("missing '}' at end of configuration")
+	}
+	return nodes, pos, nil
+}
+
+func formatNodes(nodes []node) string {
+	words := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if !n.isBlock {
+			words = append(words, n.word)
+		} else if len(n.block) == 0 {
+			words = append(words, "{ }")
+		} else {
+			words = append(words, "{ "+formatNodes(n.block)+" }")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// optionSpec describes an option of a section: its number of arguments,
+// and whether a block can follow them
+type optionSpec struct {
+	nargs    int
+	block    bool
+	required bool
+}
+
+// parsedOption is an option read from a section
+type parsedOption struct {
+	name  string
+	args  []string
+	block []node
+	line  int
+}
+
+func parseOptions(section string, nodes []node, specs map[string]optionSpec) ([]parsedOption, error) {
+	options := make([]parsedOption, 0)
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		if n.isBlock {
+			return nil, errors.Errorf("line %d: unexpected block in %s", n.line, section)
+		}
+		spec, ok := specs[n.word]
+		if !ok {
+			return nil, errors.Errorf("line %d: unknown option '%s' in %s", n.line, n.word, section)
+		}
+		option := parsedOption{name: n.word, line: n.line}
+		for j := 0; j < spec.nargs; j++ {
+			i++
+			if i >= len(nodes) || nodes[i].isBlock {
+				return nil, errors.Errorf("line %d: option '%s' in %s expects %d argument(s)", n.line, n.word, section, spec.nargs)
+			}
+			option.args = append(option.args, nodes[i].word)
+		}
+		if spec.block && i+1 < len(nodes) && nodes[i+1].isBlock {
+			i++
+			option.block = nodes[i].block
+		} else if spec.required {
+			return nil, errors.Errorf("line %d: option '%s' in %s expects a block", n.line, n.word, section)
+		}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+func (o *parsedOption) toOption() Option {
+	return Option{Name: o.name, Args: o.args}
+}
+
+func (o *parsedOption) intArg(section string) (int, error) {
+	value, err := strconv.Atoi(o.args[0])
+	if err != nil {
+		return 0, errors.Errorf("line %d: invalid '%s %s' in %s, expected an integer", o.line, o.name, o.args[0], section)
+	}
+	return value, nil
+}
+
+var (
+	unixOptions = map[string]optionSpec{
+		"nodaemon":               {},
+		"interactive":            {},
+		"full-coredump":          {},
+		"coredump-size":          {nargs: 1},
+		"cli-listen":             {nargs: 1},
+		"cli-line-mode":          {},
+		"cli-no-banner":          {},
+		"cli-no-pager":           {},
+		"cli-pager-buffer-limit": {nargs: 1},
+		"cli-history-limit":      {nargs: 1},
+		"cli-prompt":             {nargs: 1},
+		"log":                    {nargs: 1},
+		"pidfile":                {nargs: 1},
+		"exec":                   {nargs: 1},
+		"startup-config":         {nargs: 1},
+		"runtime-dir":            {nargs: 1},
+		"gid":                    {nargs: 1},
+		"poll-sleep-usec":        {nargs: 1},
+		"nosyslog":               {},
+		"nocolor":                {},
+	}
+	apiTraceOptions = map[string]optionSpec{
+		"on":             {},
+		"enable":         {},
+		"nitems":         {nargs: 1},
+		"save-api-table": {nargs: 1},
+	}
+	cpuOptions = map[string]optionSpec{
+		"main-core":          {nargs: 1},
+		"workers":            {nargs: 1},
+		"corelist-workers":   {nargs: 1},
+		"coremask-workers":   {nargs: 1},
+		"skip-cores":         {nargs: 1},
+		"relative":           {},
+		"thread-prefix":      {nargs: 1},
+		"scheduler-policy":   {nargs: 1},
+		"scheduler-priority": {nargs: 1},
+		"use-pthreads":       {},
+	}
+	socksvrOptions = map[string]optionSpec{
+		"socket-name": {nargs: 1},
+		"default":     {},
+	}
+	statsegOptions = map[string]optionSpec{
+		"size":              {nargs: 1},
+		"page-size":         {nargs: 1},
+		"socket-name":       {nargs: 1},
+		"per-node-counters": {nargs: 1},
+		"update-interval":   {nargs: 1},
+	}
+	pluginsOptions = map[string]optionSpec{
+		"path":     {nargs: 1},
+		"add-path": {nargs: 1},
+		"plugin":   {nargs: 1, block: true, required: true},
+	}
+	pluginOptions = map[string]optionSpec{
+		"enable":             {},
+		"disable":            {},
+		"skip-version-check": {},
+	}
+	buffersOptions = map[string]optionSpec{
+		"buffers-per-numa": {nargs: 1},
+		"page-size":        {nargs: 1},
+		"default":          {nargs: 2},
+		"no-hugetlb":       {},
+	}
+	dpdkOptions = map[string]optionSpec{
+		"dev":                           {nargs: 1, block: true},
+		"uio-driver":                    {nargs: 1},
+		"iova-mode":                     {nargs: 1},
+		"no-hugetlb":                    {},
+		"socket-mem":                    {nargs: 1},
+		"huge-dir":                      {nargs: 1},
+		"vdev":                          {nargs: 1},
+		"blocklist":                     {nargs: 1},
+		"blacklist":                     {nargs: 1},
+		"log-level":                     {nargs: 1},
+		"max-simd-bitwidth":             {nargs: 1},
+		"no-multi-seg":                  {},
+		"no-tx-checksum-offload":        {},
+		"enable-tcp-udp-checksum":       {},
+		"enable-outer-checksum-offload": {},
+		"decimal-interface-names":       {},
+		"telemetry":                     {},
+		"no-pci":                        {},
+	}
+	dpdkDeviceOptions = map[string]optionSpec{
+		"num-rx-queues":      {nargs: 1},
+		"num-tx-queues":      {nargs: 1},
+		"num-rx-desc":        {nargs: 1},
+		"num-tx-desc":        {nargs: 1},
+		"tag":                {nargs: 1},
+		"name":               {nargs: 1},
+		"workers":            {nargs: 1},
+		"rss-queues":         {nargs: 1},
+		"devargs":            {nargs: 1},
+		"vlan-strip-offload": {nargs: 1},
+		"tso":                {nargs: 1},
+		"no-rx-interrupts":   {},
+	}
+)
+
+func (c *VppConfig) parseUnix(nodes []node) error {
+	options, err := parseOptions("unix", nodes, unixOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "nodaemon":
+			c.Unix.Nodaemon = true
+		case "full-coredump":
+			c.Unix.FullCoredump = true
+		case "cli-listen":
+			c.Unix.CliListen = o.args[0]
+		case "pidfile":
+			c.Unix.Pidfile = o.args[0]
+		case "exec":
+			c.Unix.Exec = o.args[0]
+		default:
+			c.Unix.Options = append(c.Unix.Options, o.toOption())
+		}
+	}
+	return nil
+}
+
+func (c *VppConfig) parseAPITrace(nodes []node) (err error) {
+	options, err := parseOptions("api-trace", nodes, apiTraceOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "on", "enable":
+			c.APITrace.On = true
+		case "nitems":
+			c.APITrace.Nitems, err = o.intArg("api-trace")
+			if err != nil {
+				return err
+			}
+		default:
+			c.APITrace.Options = append(c.APITrace.Options, o.toOption())
+		}
+	}
+	return nil
+}
+
+func (c *VppConfig) parseCPU(nodes []node) error {
+	options, err := parseOptions("cpu", nodes, cpuOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "main-core", "workers":
+			value, err := o.intArg("cpu")
+			if err != nil {
+				return err
+			}
+			if o.name == "main-core" {
+				c.CPU.MainCore = &value
+			} else {
+				c.CPU.Workers = &value
+			}
+		case "corelist-workers":
+			c.CPU.CorelistWorkers = o.args[0]
+		case "relative":
+			c.CPU.Relative = true
+		default:
+			c.CPU.Options = append(c.CPU.Options, o.toOption())
+		}
+	}
+	return nil
+}
+
+func (c *VppConfig) parseSocksvr(nodes []node) error {
+	options, err := parseOptions("socksvr", nodes, socksvrOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "socket-name":
+			c.Socksvr.SocketName = o.args[0]
+		case "default":
+			c.Socksvr.Default = true
+		}
+	}
+	return nil
+}
+
+func (c *VppConfig) parseStatseg(nodes []node) error {
+	options, err := parseOptions("statseg", nodes, statsegOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "size":
+			c.Statseg.Size = o.args[0]
+		case "page-size":
+			c.Statseg.PageSize = o.args[0]
+		default:
+			c.Statseg.Options = append(c.Statseg.Options, o.toOption())
+		}
+	}
+	return nil
+}
+
+func (c *VppConfig) parsePlugins(nodes []node) error {
+	options, err := parseOptions("plugins", nodes, pluginsOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "path":
+			c.Plugins.Path = o.args[0]
+		case "plugin":
+			pluginOpts, err := parseOptions("plugin "+o.args[0], o.block, pluginOptions)
+			if err != nil {
+				return err
+			}
+			plugin := Plugin{Name: o.args[0]}
+			for _, po := range pluginOpts {
+				switch po.name {
+				case "enable":
+					plugin.Enable = true
+				case "disable":
+					plugin.Enable = false
+				case "skip-version-check":
+					plugin.SkipVersionCheck = true
+				}
+			}
+			c.Plugins.Plugins = append(c.Plugins.Plugins, plugin)
+		default:
+			c.Plugins.Options = append(c.Plugins.Options, o.toOption())
+		}
+	}
+	return nil
+}
+
+func (c *VppConfig) parseBuffers(nodes []node) (err error) {
+	options, err := parseOptions("buffers", nodes, buffersOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "buffers-per-numa":
+			c.Buffers.BuffersPerNuma, err = o.intArg("buffers")
+			if err != nil {
+				return err
+			}
+		case "page-size":
+			c.Buffers.PageSize = o.args[0]
+		case "default":
+			if o.args[0] != "data-size" {
+				return errors.Errorf("line %d: unknown option 'default %s' in buffers", o.line, o.args[0])
+			}
+			o.args = o.args[1:]
+			c.Buffers.DefaultDataSize, err = o.intArg("buffers")
+			if err != nil {
+				return err
+			}
+		case "no-hugetlb":
+			c.Buffers.NoHugetlb = true
+		}
+	}
+	return nil
+}
+
+func parseDpdkDevice(o parsedOption) (device DpdkDevice, err error) {
+	device.PciID = o.args[0]
+	section := "dev " + device.PciID
+	options, err := parseOptions(section, o.block, dpdkDeviceOptions)
+	if err != nil {
+		return device, err
+	}
+	for _, do := range options {
+		switch do.name {
+		case "num-rx-queues":
+			device.NumRxQueues, err = do.intArg(section)
+		case "num-tx-queues":
+			device.NumTxQueues, err = do.intArg(section)
+		case "num-rx-desc":
+			device.NumRxDesc, err = do.intArg(section)
+		case "num-tx-desc":
+			device.NumTxDesc, err = do.intArg(section)
+		case "tag":
+			device.Tag = do.args[0]
+		case "name":
+			device.Name = do.args[0]
+		default:
+			device.Options = append(device.Options, do.toOption())
+		}
+		if err != nil {
+			return device, err
+		}
+	}
+	return device, nil
+}
+
+func (c *VppConfig) parseDpdk(nodes []node) error {
+	options, err := parseOptions("dpdk", nodes, dpdkOptions)
+	if err != nil {
+		return err
+	}
+	for _, o := range options {
+		switch o.name {
+		case "dev":
+			device, err := parseDpdkDevice(o)
+			if err != nil {
+				return err
+			}
+			c.Dpdk.Devices = append(c.Dpdk.Devices, device)
+		case "uio-driver":
+			c.Dpdk.UioDriver = o.args[0]
+		case "iova-mode":
+			c.Dpdk.IovaMode = o.args[0]
+		case "no-hugetlb":
+			c.Dpdk.NoHugetlb = true
+		default:
+			c.Dpdk.Options = append(c.Dpdk.Options, o.toOption())
+		}
+	}
+	return nil
+}
+
+// Parse reads a startup.conf. Options of the modelled sections are checked
+// against those VPP accepts, other sections are kept as is.
+func Parse(input string) (*VppConfig, error) {
+	nodes, _, err := parseBlocks(tokenize(input), 0, false)
+	if err != nil {
+		return nil, err
+	}
+	c := &VppConfig{}
+	parsers := map[string]func([]node) error{
+		"unix":      c.parseUnix,
+		"api-trace": c.parseAPITrace,
+		"cpu":       c.parseCPU,
+		"socksvr":   c.parseSocksvr,
+		"statseg":   c.parseStatseg,
+		"plugins":   c.parsePlugins,
+		"buffers":   c.parseBuffers,
+		"dpdk":      c.parseDpdk,
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		if n.isBlock {
+			return nil, errors.Errorf("line %d: block without a section name", n.line)
+		}
+		parser, modelled := parsers[n.word]
+		if modelled {
+			if seen[n.word] {
+				return nil, errors.Errorf("line %d: duplicate %s section", n.line, n.word)
+			}
+			seen[n.word] = true
+			if i+1 >= len(nodes) || !nodes[i+1].isBlock {
+				return nil, errors.Errorf("line %d: section %s expects a block", n.line, n.word)
+			}
+			i++
+			err = parser(nodes[i].block)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// Other directives take the words on the same line, and a block
+		section := Section{Name: n.word}
+		for i+1 < len(nodes) && !nodes[i+1].isBlock && nodes[i+1].line == n.line {
+			i++
+			section.Args = append(section.Args, nodes[i].word)
+		}
+		if i+1 < len(nodes) && nodes[i+1].isBlock {
+			i++
+			section.HasBlock = true
+			section.Content = formatNodes(nodes[i].block)
+		}
+		c.Sections = append(c.Sections, section)
+	}
+	return c, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vppconf
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DpdkPlugin = "dpdk_plugin.so"
+	// maxDescriptors is the maximum size of dpdk rings
+	maxDescriptors = 1 << 15
+	maxDataSize    = 65535
+)
+
+func isValidPageSize(pageSize string) bool {
+	switch strings.ToLower(pageSize) {
+	case "", "4k", "2m", "1g", "default", "default-hugepage":
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidCoreList checks a list of cores like `1-3,5`
+func isValidCoreList(coreList string) bool {
+	for _, part := range strings.Split(coreList, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.ParseUint(bounds[0], 10, 32)
+		if err != nil {
+			return false
+		}
+		if len(bounds) == 2 {
+			last, err := strconv.ParseUint(bounds[1], 10, 32)
+			if err != nil || last < first {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (d *DpdkDevice) Validate() error {
+	if d.NumRxQueues < 0 || d.NumRxQueues > 0xffff {
+		return errors.Errorf("dpdk dev %s: invalid num-rx-queues %d", d.PciID, d.NumRxQueues)
+	}
+	if d.NumTxQueues < 0 || d.NumTxQueues > 0xffff {
+		return errors.Errorf("dpdk dev %s: invalid num-tx-queues %d", d.PciID, d.NumTxQueues)
+	}
+	if d.NumRxDesc < 0 || d.NumRxDesc > maxDescriptors {
+		return errors.Errorf("dpdk dev %s: invalid num-rx-desc %d, should be at most %d", d.PciID, d.NumRxDesc, maxDescriptors)
+	}
+	if d.NumTxDesc < 0 || d.NumTxDesc > maxDescriptors {
+		return errors.Errorf("dpdk dev %s: invalid num-tx-desc %d, should be at most %d", d.PciID, d.NumTxDesc, maxDescriptors)
+	}
+	return nil
+}
+
+// Validate checks the ranges of the values of the configuration, and the
+// options that conflict with one another
+func (c *VppConfig) Validate() error {
+	if c.APITrace.Nitems < 0 {
+		return errors.Errorf("api-trace: invalid nitems %d", c.APITrace.Nitems)
+	}
+
+	if c.CPU.MainCore != nil && *c.CPU.MainCore < 0 {
+		return errors.Errorf("cpu: invalid main-core %d", *c.CPU.MainCore)
+	}
+	if c.CPU.Workers != nil && *c.CPU.Workers < 0 {
+		return errors.Errorf("cpu: invalid workers %d", *c.CPU.Workers)
+	}
+	if c.CPU.CorelistWorkers != "" {
+		if c.CPU.Workers != nil {
+			return errors.Errorf("cpu: workers and corelist-workers are mutually exclusive")
+		}
+		if !isValidCoreList(c.CPU.CorelistWorkers) {
+			return errors.Errorf("cpu: invalid corelist-workers %s", c.CPU.CorelistWorkers)
+		}
+	}
+
+	if !isValidPageSize(c.Statseg.PageSize) {
+		return errors.Errorf("statseg: invalid page-size %s", c.Statseg.PageSize)
+	}
+
+	plugins := make(map[string]bool)
+	for _, plugin := range c.Plugins.Plugins {
+		enable, found := plugins[plugin.Name]
+		if found && enable != plugin.Enable {
+			return errors.Errorf("plugins: %s is both enabled and disabled", plugin.Name)
+		}
+		plugins[plugin.Name] = plugin.Enable
+	}
+
+	if c.Buffers.BuffersPerNuma < 0 {
+		return errors.Errorf("buffers: invalid buffers-per-numa %d", c.Buffers.BuffersPerNuma)
+	}
+	if !isValidPageSize(c.Buffers.PageSize) {
+		return errors.Errorf("buffers: invalid page-size %s", c.Buffers.PageSize)
+	}
+	if c.Buffers.DefaultDataSize < 0 || c.Buffers.DefaultDataSize > maxDataSize {
+		return errors.Errorf("buffers: invalid data-size %d", c.Buffers.DefaultDataSize)
+	}
+
+	if !c.Dpdk.IsEmpty() && !c.Plugins.IsEnabled(DpdkPlugin) {
+		return errors.Errorf("dpdk: section is set but %s is disabled", DpdkPlugin)
+	}
+	switch c.Dpdk.IovaMode {
+	case "", "pa", "va":
+	default:
+		return errors.Errorf("dpdk: invalid iova-mode %s, should be pa or va", c.Dpdk.IovaMode)
+	}
+	if c.Dpdk.NoHugetlb && !c.Buffers.NoHugetlb {
+		return errors.Errorf("dpdk: no-hugetlb requires no-hugetlb in buffers")
+	}
+	devices := make(map[string]bool)
+	for i := range c.Dpdk.Devices {
+		device := &c.Dpdk.Devices[i]
+		if devices[device.PciID] {
+			return errors.Errorf("dpdk: duplicate dev %s", device.PciID)
+		}
+		devices[device.PciID] = true
+		err := device.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
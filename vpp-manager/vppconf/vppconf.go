@@ -0,0 +1,323 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vppconf models the sections of the VPP startup.conf used by
+// Calico/VPP, so that they can be checked and changed before running VPP.
+package vppconf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Option is a directive of a section that is not modelled with a typed
+// field, e.g. `cli-no-pager` or `coredump-size unlimited`
+type Option struct {
+	Name string
+	Args []string
+}
+
+func (o Option) String() string {
+	return strings.Join(append([]string{o.Name}, o.Args...), " ")
+}
+
+type Unix struct {
+	Nodaemon     bool
+	FullCoredump bool
+	CliListen    string
+	Pidfile      string
+	Exec         string
+	Options      []Option
+}
+
+type APITrace struct {
+	On      bool
+	Nitems  int
+	Options []Option
+}
+
+type CPU struct {
+	MainCore        *int
+	Workers         *int
+	CorelistWorkers string
+	Relative        bool
+	Options         []Option
+}
+
+type Socksvr struct {
+	SocketName string
+	Default    bool
+}
+
+type Statseg struct {
+	Size     string
+	PageSize string
+	Options  []Option
+}
+
+type Plugin struct {
+	Name             string
+	Enable           bool
+	SkipVersionCheck bool
+}
+
+type Plugins struct {
+	Path    string
+	Plugins []Plugin
+	Options []Option
+}
+
+type Buffers struct {
+	BuffersPerNuma int
+	PageSize       string
+	// DefaultDataSize is the `default data-size` of buffers
+	DefaultDataSize int
+	NoHugetlb       bool
+}
+
+type DpdkDevice struct {
+	// PciID is the PCI address of the device, or `default`
+	PciID       string
+	NumRxQueues int
+	NumTxQueues int
+	NumRxDesc   int
+	NumTxDesc   int
+	Tag         string
+	Name        string
+	Options     []Option
+}
+
+type Dpdk struct {
+	Devices   []DpdkDevice
+	IovaMode  string
+	NoHugetlb bool
+	UioDriver string
+	Options   []Option
+}
+
+// Section is a section of the startup.conf that is not modelled,
+// kept as found in the template
+type Section struct {
+	Name string
+	// Content is the normalized content of the section, without braces.
+	// It is empty for top-level directives without braces.
+	Content  string
+	HasBlock bool
+	Args     []string
+}
+
+type VppConfig struct {
+	Unix     Unix
+	APITrace APITrace
+	CPU      CPU
+	Socksvr  Socksvr
+	Statseg  Statseg
+	Plugins  Plugins
+	Buffers  Buffers
+	Dpdk     Dpdk
+	// Sections are the sections not modelled above, in template order
+	Sections []Section
+}
+
+// GetPlugin returns the explicit configuration of a plugin, if any
+func (p *Plugins) GetPlugin(name string) *Plugin {
+	for i := range p.Plugins {
+		if p.Plugins[i].Name == name {
+			return &p.Plugins[i]
+		}
+	}
+	return nil
+}
+
+// IsEnabled tells whether VPP loads a plugin, falling back to the
+// `default` plugin entry, itself enabled by default
+func (p *Plugins) IsEnabled(name string) bool {
+	if plugin := p.GetPlugin(name); plugin != nil {
+		return plugin.Enable
+	}
+	if plugin := p.GetPlugin("default"); plugin != nil {
+		return plugin.Enable
+	}
+	return true
+}
+
+// SetEnabled enables or disables a plugin
+func (p *Plugins) SetEnabled(name string, enable bool) {
+	if plugin := p.GetPlugin(name); plugin != nil {
+		plugin.Enable = enable
+		return
+	}
+	p.Plugins = append(p.Plugins, Plugin{Name: name, Enable: enable})
+}
+
+// GetDevice returns the dpdk configuration of a device, if any
+func (d *Dpdk) GetDevice(pciID string) *DpdkDevice {
+	for i := range d.Devices {
+		if d.Devices[i].PciID == pciID {
+			return &d.Devices[i]
+		}
+	}
+	return nil
+}
+
+func (d *Dpdk) IsEmpty() bool {
+	return len(d.Devices) == 0 && d.IovaMode == "" && !d.NoHugetlb && d.UioDriver == "" && len(d.Options) == 0
+}
+
+type sectionWriter struct {
+	lines []string
+}
+
+func (w *sectionWriter) flag(name string, set bool) {
+	if set {
+		w.lines = append(w.lines, name)
+	}
+}
+
+func (w *sectionWriter) str(name string, value string) {
+	if value != "" {
+		w.lines = append(w.lines, name+" "+value)
+	}
+}
+
+func (w *sectionWriter) int(name string, value int) {
+	if value != 0 {
+		w.lines = append(w.lines, name+" "+strconv.Itoa(value))
+	}
+}
+
+func (w *sectionWriter) intPtr(name string, value *int) {
+	if value != nil {
+		w.lines = append(w.lines, name+" "+strconv.Itoa(*value))
+	}
+}
+
+func (w *sectionWriter) options(options []Option) {
+	for _, option := range options {
+		w.lines = append(w.lines, option.String())
+	}
+}
+
+func (w *sectionWriter) write(b *strings.Builder, name string) {
+	if len(w.lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s {\n", name)
+	for _, line := range w.lines {
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+	b.WriteString("}\n")
+}
+
+func (d *DpdkDevice) String() string {
+	w := &sectionWriter{}
+	w.int("num-rx-queues", d.NumRxQueues)
+	w.int("num-tx-queues", d.NumTxQueues)
+	w.int("num-rx-desc", d.NumRxDesc)
+	w.int("num-tx-desc", d.NumTxDesc)
+	w.str("tag", d.Tag)
+	w.str("name", d.Name)
+	w.options(d.Options)
+	if len(w.lines) == 0 {
+		return "dev " + d.PciID
+	}
+	return fmt.Sprintf("dev %s { %s }", d.PciID, strings.Join(w.lines, " "))
+}
+
+// String prints the startup.conf. Sections are always printed in the same
+// order, and typed fields before the other options of their section.
+func (c *VppConfig) String() string {
+	b := &strings.Builder{}
+
+	w := &sectionWriter{}
+	w.flag("nodaemon", c.Unix.Nodaemon)
+	w.flag("full-coredump", c.Unix.FullCoredump)
+	w.str("cli-listen", c.Unix.CliListen)
+	w.str("pidfile", c.Unix.Pidfile)
+	w.str("exec", c.Unix.Exec)
+	w.options(c.Unix.Options)
+	w.write(b, "unix")
+
+	w = &sectionWriter{}
+	w.flag("on", c.APITrace.On)
+	w.int("nitems", c.APITrace.Nitems)
+	w.options(c.APITrace.Options)
+	w.write(b, "api-trace")
+
+	w = &sectionWriter{}
+	w.intPtr("main-core", c.CPU.MainCore)
+	w.intPtr("workers", c.CPU.Workers)
+	w.str("corelist-workers", c.CPU.CorelistWorkers)
+	w.flag("relative", c.CPU.Relative)
+	w.options(c.CPU.Options)
+	w.write(b, "cpu")
+
+	w = &sectionWriter{}
+	w.str("socket-name", c.Socksvr.SocketName)
+	w.flag("default", c.Socksvr.Default)
+	w.write(b, "socksvr")
+
+	w = &sectionWriter{}
+	w.str("size", c.Statseg.Size)
+	w.str("page-size", c.Statseg.PageSize)
+	w.options(c.Statseg.Options)
+	w.write(b, "statseg")
+
+	w = &sectionWriter{}
+	w.str("path", c.Plugins.Path)
+	for _, plugin := range c.Plugins.Plugins {
+		state := "disable"
+		if plugin.Enable {
+			state = "enable"
+		}
+		if plugin.SkipVersionCheck {
+			state += " skip-version-check"
+		}
+		w.lines = append(w.lines, fmt.Sprintf("plugin %s { %s }", plugin.Name, state))
+	}
+	w.options(c.Plugins.Options)
+	w.write(b, "plugins")
+
+	w = &sectionWriter{}
+	w.int("buffers-per-numa", c.Buffers.BuffersPerNuma)
+	w.str("page-size", c.Buffers.PageSize)
+	if c.Buffers.DefaultDataSize != 0 {
+		w.lines = append(w.lines, "default data-size "+strconv.Itoa(c.Buffers.DefaultDataSize))
+	}
+	w.flag("no-hugetlb", c.Buffers.NoHugetlb)
+	w.write(b, "buffers")
+
+	w = &sectionWriter{}
+	w.str("uio-driver", c.Dpdk.UioDriver)
+	w.str("iova-mode", c.Dpdk.IovaMode)
+	w.flag("no-hugetlb", c.Dpdk.NoHugetlb)
+	w.options(c.Dpdk.Options)
+	for i := range c.Dpdk.Devices {
+		w.lines = append(w.lines, c.Dpdk.Devices[i].String())
+	}
+	w.write(b, "dpdk")
+
+	for _, section := range c.Sections {
+		line := strings.Join(append([]string{section.Name}, section.Args...), " ")
+		if section.HasBlock && section.Content == "" {
+			line += " { }"
+		} else if section.HasBlock {
+			line += " { " + section.Content + " }"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vppconf
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden files in testdata/")
+
+func TestVppConf(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VPP startup.conf tests")
+}
+
+func expectGolden(name string, conf *VppConfig) {
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		Expect(os.WriteFile(golden, []byte(conf.String()), 0644)).To(Succeed())
+	}
+	expected, err := os.ReadFile(golden)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(conf.String()).To(Equal(string(expected)))
+}
+
+func mustParse(input string) *VppConfig {
+	conf, err := Parse(input)
+	Expect(err).ToNot(HaveOccurred())
+	return conf
+}
+
+var _ = Describe("VPP startup.conf", func() {
+	It("prints a parsed configuration deterministically", func() {
+		input, err := os.ReadFile(filepath.Join("testdata", "full.conf"))
+		Expect(err).ToNot(HaveOccurred())
+		conf := mustParse(string(input))
+		Expect(conf.Validate()).To(Succeed())
+		Expect(*conf.CPU.MainCore).To(Equal(1))
+		Expect(conf.Buffers.DefaultDataSize).To(Equal(2048))
+		Expect(conf.Dpdk.GetDevice("0000:00:08.0").NumRxQueues).To(Equal(2))
+		Expect(conf.Plugins.IsEnabled("ping_plugin.so")).To(BeFalse())
+		Expect(conf.Plugins.IsEnabled("other_plugin.so")).To(BeTrue())
+		Expect(conf.Sections).To(HaveLen(2))
+		expectGolden("full.conf", conf)
+
+		// Printing the printed configuration gives the same result
+		Expect(mustParse(conf.String()).String()).To(Equal(conf.String()))
+	})
+
+	It("rejects malformed configurations", func() {
+		for _, input := range []string{
+			"unix { nodaemon",
+			"unix { nodaemon } }",
+			"unix { nodaemon }\nunix { interactive }",
+			"unix { no-daemon }",
+			"cpu { workers }",
+			"cpu { workers two }",
+			"buffers { default size 2048 }",
+			"plugins { plugin dpdk_plugin.so }",
+			"plugins { plugin dpdk_plugin.so { enabled } }",
+			"dpdk { dev 0000:00:08.0 { num-rx-queue 2 } }",
+			"{ nodaemon }",
+		} {
+			_, err := Parse(input)
+			Expect(err).To(HaveOccurred(), input)
+		}
+	})
+
+	It("detects invalid values and conflicts", func() {
+		for _, input := range []string{
+			"cpu { workers 2 corelist-workers 1-2 }",
+			"cpu { corelist-workers 3-1 }",
+			"buffers { page-size 3M }",
+			"buffers { default data-size 70000 }",
+			"plugins { plugin default { disable } }\ndpdk { dev 0000:00:08.0 }",
+			"plugins { plugin dpdk_plugin.so { disable } plugin dpdk_plugin.so { enable } }",
+			"dpdk { dev 0000:00:08.0 dev 0000:00:08.0 }",
+			"dpdk { dev 0000:00:08.0 { num-rx-desc 65536 } }",
+			"dpdk { iova-mode xa }",
+			"dpdk { no-hugetlb }",
+		} {
+			conf := mustParse(input)
+			Expect(conf.Validate()).ToNot(Succeed(), input)
+		}
+	})
+
+	It("updates typed fields", func() {
+		conf := mustParse("plugins { plugin dpdk_plugin.so { disable } }")
+		conf.Plugins.SetEnabled(DpdkPlugin, true)
+		conf.Plugins.SetEnabled("calico_plugin.so", true)
+		conf.Dpdk.Devices = append(conf.Dpdk.Devices, DpdkDevice{PciID: "0000:00:08.0", Tag: "main-eth1"})
+		Expect(conf.Validate()).To(Succeed())
+		Expect(conf.String()).To(Equal("plugins {\n" +
+			"  plugin dpdk_plugin.so { enable }\n" +
+			"  plugin calico_plugin.so { enable }\n" +
+			"}\n" +
+			"dpdk {\n" +
+			"  dev 0000:00:08.0 { tag main-eth1 }\n" +
+			"}\n"))
+	})
+})
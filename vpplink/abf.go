@@ -64,7 +64,7 @@ func (v *VppLink) addDelAbfPolicy(policy *types.AbfPolicy, isAdd bool) error {
 
 	fibPaths := make([]fib_types.FibPath, 0, len(policy.Paths))
 	for _, path := range policy.Paths {
-		fibPaths = append(fibPaths, path.ToFibPath(false))
+		fibPaths = append(fibPaths, path.ToFibPath(policy.IsIPv6))
 	}
 
 	_, err := client.AbfPolicyAddDel(v.GetContext(), &abf.AbfPolicyAddDel{
@@ -89,6 +89,12 @@ func (v *VppLink) AddAbfPolicy(policy *types.AbfPolicy) error {
 	return nil
 }
 
+// ReserveAbfPolicyID marks a policy ID created by a previous run
+// of the agent as used, so that it is not allocated again
+func (v *VppLink) ReserveAbfPolicyID(policyID uint32) error {
+	return policyIndexAllocator.TakeIndex(policyID)
+}
+
 func (v *VppLink) DelAbfPolicy(policy *types.AbfPolicy) error {
 	if err := v.addDelAbfPolicy(policy, false); err != nil {
 		return fmt.Errorf("failed to delete ABF Policy: %w", err)
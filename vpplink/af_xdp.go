@@ -32,7 +32,8 @@ func (v *VppLink) CreateAfXDP(intf *types.VppXDPInterface) error {
 		RxqNum:  uint16(DefaultIntTo(intf.NumRxQueues, 1)),
 		RxqSize: uint16(DefaultIntTo(intf.RxQueueSize, 1024)),
 		TxqSize: uint16(DefaultIntTo(intf.TxQueueSize, 1024)),
-		Mode:    af_xdp.AF_XDP_API_MODE_AUTO,
+		Mode:    intf.Mode,
+		Prog:    intf.Prog,
 	}
 	response, err := client.AfXdpCreateV3(v.GetContext(), request)
 	if err != nil {
@@ -50,3 +50,29 @@ func (v *VppLink) EnableArpProxy(swIfIndex, tableID uint32) error {
 	}
 	return nil
 }
+
+func (v *VppLink) DisableArpProxy(swIfIndex, tableID uint32) error {
+	client := arp.NewServiceClient(v.GetConnection())
+
+	_, err := client.ProxyArpIntfcEnableDisable(v.GetContext(), &arp.ProxyArpIntfcEnableDisable{
+		Enable:    false,
+		SwIfIndex: interface_types.InterfaceIndex(swIfIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable ProxyARP (swifidx %d): %w", swIfIndex, err)
+	}
+
+	request := &arp.ProxyArpAddDel{
+		IsAdd: false,
+		Proxy: arp.ProxyArp{
+			TableID: tableID,
+			Low:     ip_types.IP4Address{0, 0, 0, 0},
+			Hi:      ip_types.IP4Address{255, 255, 255, 255},
+		},
+	}
+	_, err = client.ProxyArpAddDel(v.GetContext(), request)
+	if err != nil {
+		return fmt.Errorf("failed to delete ProxyARP (%+v): %w", request, err)
+	}
+	return nil
+}
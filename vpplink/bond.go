@@ -0,0 +1,84 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpplink
+
+import (
+	"fmt"
+
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/bond"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface_types"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+func (v *VppLink) CreateBond(b *types.Bond) (uint32, error) {
+	client := bond.NewServiceClient(v.GetConnection())
+
+	request := &bond.BondCreate2{
+		Mode:      bond.BondMode(b.Mode),
+		Lb:        bond.BondLbAlgo(b.LoadBalance),
+		EnableGso: b.EnableGSO,
+		ID:        ^uint32(0),
+	}
+	if b.HardwareAddr != nil {
+		request.UseCustomMac = true
+		request.MacAddress = types.MacAddress(b.HardwareAddr)
+	}
+	response, err := client.BondCreate2(v.GetContext(), request)
+	if err != nil {
+		return InvalidSwIfIndex, fmt.Errorf("failed to create bond (%s): %w", b, err)
+	}
+	b.SwIfIndex = uint32(response.SwIfIndex)
+	return b.SwIfIndex, nil
+}
+
+func (v *VppLink) DeleteBond(swIfIndex uint32) error {
+	client := bond.NewServiceClient(v.GetConnection())
+
+	_, err := client.BondDelete(v.GetContext(), &bond.BondDelete{
+		SwIfIndex: interface_types.InterfaceIndex(swIfIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete bond %d: %w", swIfIndex, err)
+	}
+	return nil
+}
+
+func (v *VppLink) AddBondMember(bondSwIfIndex uint32, member *types.BondMember) error {
+	client := bond.NewServiceClient(v.GetConnection())
+
+	_, err := client.BondAddMember(v.GetContext(), &bond.BondAddMember{
+		SwIfIndex:     interface_types.InterfaceIndex(member.SwIfIndex),
+		BondSwIfIndex: interface_types.InterfaceIndex(bondSwIfIndex),
+		IsPassive:     member.IsPassive,
+		IsLongTimeout: member.IsLongTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add member %d to bond %d: %w", member.SwIfIndex, bondSwIfIndex, err)
+	}
+	return nil
+}
+
+func (v *VppLink) DetachBondMember(swIfIndex uint32) error {
+	client := bond.NewServiceClient(v.GetConnection())
+
+	_, err := client.BondDetachMember(v.GetContext(), &bond.BondDetachMember{
+		SwIfIndex: interface_types.InterfaceIndex(swIfIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to detach bond member %d: %w", swIfIndex, err)
+	}
+	return nil
+}
@@ -136,3 +136,21 @@ func (v *VppLink) SetClassifyOutputInterfaceTables(swIfIndex uint32, ip4TableInd
 	}
 	return nil
 }
+
+func (v *VppLink) AddClassifySession(tableIndex uint32, match []byte) error {
+	client := classify.NewServiceClient(v.GetConnection())
+
+	match = ExtendToVector(match)
+	_, err := client.ClassifyAddDelSession(v.GetContext(), &classify.ClassifyAddDelSession{
+		IsAdd:        true,
+		TableIndex:   tableIndex,
+		HitNextIndex: types.InvalidID,
+		OpaqueIndex:  types.InvalidID,
+		MatchLen:     uint32(len(match)),
+		Match:        match,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add classify session to table %d: %w", tableIndex, err)
+	}
+	return nil
+}
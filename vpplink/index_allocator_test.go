@@ -0,0 +1,53 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpplink
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVpplink(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "vpplink tests")
+}
+
+var _ = Describe("IndexAllocator", func() {
+	It("Does not allocate a taken index", func() {
+		allocator := NewIndexAllocator(10)
+		Expect(allocator.TakeIndex(12)).To(Succeed())
+		allocated := map[uint32]bool{}
+		for i := 0; i < 3; i++ {
+			allocated[allocator.AllocateIndex()] = true
+		}
+		Expect(allocated).To(Equal(map[uint32]bool{10: true, 11: true, 13: true}))
+	})
+
+	It("Takes indexes below the highest allocated one", func() {
+		allocator := NewIndexAllocator(0)
+		Expect(allocator.TakeIndex(3)).To(Succeed())
+		Expect(allocator.TakeIndex(1)).To(Succeed())
+		Expect(allocator.TakeIndex(3)).ToNot(Succeed())
+		Expect(allocator.TakeIndex(1)).ToNot(Succeed())
+		Expect(allocator.AllocateIndex()).To(BeElementOf(uint32(0), uint32(2)))
+	})
+
+	It("Rejects indexes below the start index", func() {
+		Expect(NewIndexAllocator(10).TakeIndex(5)).ToNot(Succeed())
+	})
+})
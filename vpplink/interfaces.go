@@ -388,6 +388,7 @@ func (v *VppLink) GetInterfaceDetails(swIfIndex uint32) (i *types.VppInterfaceDe
 		i = &types.VppInterfaceDetails{
 			SwIfIndex: uint32(response.SwIfIndex),
 			IsUp:      response.Flags&interface_types.IF_STATUS_API_FLAG_ADMIN_UP > 0,
+			IsLinkUp:  response.Flags&interface_types.IF_STATUS_API_FLAG_LINK_UP > 0,
 			Name:      response.InterfaceName,
 			Tag:       response.Tag,
 			Type:      response.InterfaceDevType,
@@ -638,6 +639,59 @@ func (v *VppLink) DeleteLoopback(swIfIndex uint32) error {
 	return nil
 }
 
+// CreateVlanSubif creates a sub-interface of swIfIndex matching the dot1q
+// frames with the given VLAN id
+func (v *VppLink) CreateVlanSubif(swIfIndex uint32, vlanID uint32) (uint32, error) {
+	client := interfaces.NewServiceClient(v.GetConnection())
+
+	response, err := client.CreateVlanSubif(v.GetContext(), &interfaces.CreateVlanSubif{
+		SwIfIndex: interface_types.InterfaceIndex(swIfIndex),
+		VlanID:    vlanID,
+	})
+	if err != nil {
+		return InvalidSwIfIndex, fmt.Errorf("failed to create vlan %d sub-interface of %d: %w", vlanID, swIfIndex, err)
+	}
+	return uint32(response.SwIfIndex), nil
+}
+
+// CreateSubif creates a sub-interface of swIfIndex matching exactly the
+// frames tagged with outerVlanID, and innerVlanID when it is not zero.
+// The outer tag is 802.1ad if dot1ad is set, 802.1q otherwise.
+func (v *VppLink) CreateSubif(swIfIndex uint32, subID uint32, outerVlanID uint16, innerVlanID uint16, dot1ad bool) (uint32, error) {
+	client := interfaces.NewServiceClient(v.GetConnection())
+
+	flags := interface_types.SUB_IF_API_FLAG_ONE_TAG | interface_types.SUB_IF_API_FLAG_EXACT_MATCH
+	if innerVlanID != 0 {
+		flags = interface_types.SUB_IF_API_FLAG_TWO_TAGS | interface_types.SUB_IF_API_FLAG_EXACT_MATCH
+	}
+	if dot1ad {
+		flags |= interface_types.SUB_IF_API_FLAG_DOT1AD
+	}
+	response, err := client.CreateSubif(v.GetContext(), &interfaces.CreateSubif{
+		SwIfIndex:   interface_types.InterfaceIndex(swIfIndex),
+		SubID:       subID,
+		SubIfFlags:  flags,
+		OuterVlanID: outerVlanID,
+		InnerVlanID: innerVlanID,
+	})
+	if err != nil {
+		return InvalidSwIfIndex, fmt.Errorf("failed to create sub-interface %d of %d: %w", subID, swIfIndex, err)
+	}
+	return uint32(response.SwIfIndex), nil
+}
+
+func (v *VppLink) DeleteSubif(swIfIndex uint32) error {
+	client := interfaces.NewServiceClient(v.GetConnection())
+
+	_, err := client.DeleteSubif(v.GetContext(), &interfaces.DeleteSubif{
+		SwIfIndex: interface_types.InterfaceIndex(swIfIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete sub-interface %d: %w", swIfIndex, err)
+	}
+	return nil
+}
+
 func (v *VppLink) wantInterfaceEvents(on bool) error {
 	client := interfaces.NewServiceClient(v.GetConnection())
 
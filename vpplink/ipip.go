@@ -0,0 +1,51 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpplink
+
+import (
+	"fmt"
+
+	typesv0 "github.com/calico-vpp/vpplink/api/v0"
+
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/ip_types"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/ipip"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/tunnel_types"
+)
+
+// AddIPIPTunnelCopyDscp adds an IPIP tunnel like AddIPIPTunnel, and when
+// copyDscp is true, copies the DSCP of the encapsulated packets into the
+// outer IP header
+func (v *VppLink) AddIPIPTunnelCopyDscp(tunnel *typesv0.IPIPTunnel, copyDscp bool) (uint32, error) {
+	if !copyDscp {
+		return v.AddIPIPTunnel(tunnel)
+	}
+	client := ipip.NewServiceClient(v.GetConnection())
+
+	response, err := client.IpipAddTunnel(v.GetContext(), &ipip.IpipAddTunnel{
+		Tunnel: ipip.IpipTunnel{
+			Instance: ^uint32(0),
+			Src:      ip_types.NewAddress(tunnel.Src),
+			Dst:      ip_types.NewAddress(tunnel.Dst),
+			TableID:  tunnel.TableID,
+			Flags:    tunnel_types.TUNNEL_API_ENCAP_DECAP_FLAG_ENCAP_COPY_DSCP,
+		},
+	})
+	if err != nil {
+		return InvalidSwIfIndex, fmt.Errorf("failed to add IPIP tunnel: %w", err)
+	}
+	tunnel.SwIfIndex = uint32(response.SwIfIndex)
+	return uint32(response.SwIfIndex), nil
+}
@@ -93,7 +93,9 @@ func (v *VppLink) CreateMemif(mif *types.Memif) error {
 		RxQueues:   uint8(mif.NumRxQueues),
 		TxQueues:   uint8(mif.NumTxQueues),
 		SocketID:   mif.SocketID,
-		BufferSize: uint16(mif.QueueSize),
+		RingSize:   uint32(mif.RingSize),
+		BufferSize: uint16(mif.BufferSize),
+		NoZeroCopy: !mif.ZeroCopy,
 	}
 	if mif.MacAddress != nil {
 		request.HwAddr = types.MacAddress(mif.MacAddress)
@@ -123,12 +125,14 @@ func (v *VppLink) ListMemifInterfaces() ([]*types.Memif, error) {
 			return nil, fmt.Errorf("failed to dump memif interfaces: %w", err)
 		}
 		memifs = append(memifs, &types.Memif{
-			SwIfIndex: uint32(response.SwIfIndex),
-			Role:      types.MemifRole(response.Role),
-			Mode:      types.MemifMode(response.Mode),
-			SocketID:  response.SocketID,
-			QueueSize: int(response.BufferSize),
-			Flags:     types.MemifFlag(response.Flags),
+			SwIfIndex:  uint32(response.SwIfIndex),
+			Role:       types.MemifRole(response.Role),
+			Mode:       types.MemifMode(response.Mode),
+			SocketID:   response.SocketID,
+			RingSize:   int(response.RingSize),
+			BufferSize: int(response.BufferSize),
+			ZeroCopy:   response.ZeroCopy,
+			Flags:      types.MemifFlag(response.Flags),
 		})
 	}
 	return memifs, nil
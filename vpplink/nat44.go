@@ -17,6 +17,7 @@ package vpplink
 
 import (
 	"fmt"
+	"io"
 	"net"
 
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface_types"
@@ -38,13 +39,37 @@ func (v *VppLink) EnableNatForwarding() error {
 	return nil
 }
 
-func (v *VppLink) addDelNat44Address(isAdd bool, address net.IP) error {
+// EnableNat44 enables the NAT44 endpoint dependent plugin, with the
+// default session limits
+func (v *VppLink) EnableNat44() error {
+	return v.enableDisableNat44(true)
+}
+
+// DisableNat44 disables the NAT44 plugin, which removes its addresses,
+// interfaces and sessions
+func (v *VppLink) DisableNat44() error {
+	return v.enableDisableNat44(false)
+}
+
+func (v *VppLink) enableDisableNat44(isEnable bool) error {
+	client := nat.NewServiceClient(v.GetConnection())
+
+	_, err := client.Nat44EdPluginEnableDisable(v.GetContext(), &nat.Nat44EdPluginEnableDisable{
+		Enable: isEnable,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %v Nat44 plugin: %w", strEnableDisable[isEnable], err)
+	}
+	return nil
+}
+
+func (v *VppLink) addDelNat44Address(isAdd bool, address net.IP, vrfID uint32) error {
 	client := nat.NewServiceClient(v.GetConnection())
 
 	_, err := client.Nat44AddDelAddressRange(v.GetContext(), &nat.Nat44AddDelAddressRange{
 		FirstIPAddress: types.ToVppIP4Address(address),
 		LastIPAddress:  types.ToVppIP4Address(address),
-		VrfID:          0,
+		VrfID:          vrfID,
 		IsAdd:          isAdd,
 		Flags:          nat_types.NAT_IS_NONE,
 	})
@@ -54,6 +79,31 @@ func (v *VppLink) addDelNat44Address(isAdd bool, address net.IP) error {
 	return nil
 }
 
+// ListNat44Addresses returns the addresses of the NAT44 pool
+func (v *VppLink) ListNat44Addresses() ([]types.Nat44Address, error) {
+	client := nat.NewServiceClient(v.GetConnection())
+
+	stream, err := client.Nat44AddressDump(v.GetContext(), &nat.Nat44AddressDump{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump Nat44 addresses: %w", err)
+	}
+	addresses := make([]types.Nat44Address, 0)
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump Nat44 addresses: %w", err)
+		}
+		addresses = append(addresses, types.Nat44Address{
+			Address: response.IPAddress.ToIP(),
+			VrfID:   response.VrfID,
+		})
+	}
+	return addresses, nil
+}
+
 func (v *VppLink) AddNat44InterfaceAddress(swIfIndex uint32, flags types.NatFlags) error {
 	return v.addDelNat44InterfaceAddress(true, swIfIndex, flags)
 }
@@ -77,11 +127,21 @@ func (v *VppLink) addDelNat44InterfaceAddress(isAdd bool, swIfIndex uint32, flag
 }
 
 func (v *VppLink) AddNat44Address(address net.IP) error {
-	return v.addDelNat44Address(true, address)
+	return v.addDelNat44Address(true, address, 0)
 }
 
 func (v *VppLink) DelNat44Address(address net.IP) error {
-	return v.addDelNat44Address(false, address)
+	return v.addDelNat44Address(false, address, 0)
+}
+
+// AddNat44AddressInVRF adds an address to the NAT44 pool, used for the
+// sessions coming from the inside interfaces in VRF vrfID
+func (v *VppLink) AddNat44AddressInVRF(address net.IP, vrfID uint32) error {
+	return v.addDelNat44Address(true, address, vrfID)
+}
+
+func (v *VppLink) DelNat44AddressInVRF(address net.IP, vrfID uint32) error {
+	return v.addDelNat44Address(false, address, vrfID)
 }
 
 func (v *VppLink) addDelNat44Interface(isAdd bool, flags types.NatFlags, swIfIndex uint32) error {
@@ -0,0 +1,70 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpplink
+
+import (
+	"fmt"
+
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/classify"
+	interfaces "github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface_types"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+func (v *VppLink) PcapTraceOn(trace *types.PcapTrace) error {
+	client := interfaces.NewServiceClient(v.GetConnection())
+
+	_, err := client.PcapTraceOn(v.GetContext(), &interfaces.PcapTraceOn{
+		CaptureRx:         trace.Rx,
+		CaptureTx:         trace.Tx,
+		CaptureDrop:       trace.Drop,
+		Filter:            trace.Filter,
+		MaxPackets:        trace.MaxPackets,
+		MaxBytesPerPacket: trace.MaxBytesPerPacket,
+		SwIfIndex:         interface_types.InterfaceIndex(trace.SwIfIndex),
+		Filename:          trace.Filename,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start pcap trace (%s): %w", trace.String(), err)
+	}
+	return nil
+}
+
+// PcapTraceOff stops the running capture and writes its file
+func (v *VppLink) PcapTraceOff() error {
+	client := interfaces.NewServiceClient(v.GetConnection())
+
+	_, err := client.PcapTraceOff(v.GetContext(), &interfaces.PcapTraceOff{})
+	if err != nil {
+		return fmt.Errorf("failed to stop pcap trace: %w", err)
+	}
+	return nil
+}
+
+// SetPcapClassifyTable sets the classify chain filtering the pcap capture
+// on an interface, InvalidTableID removes it
+func (v *VppLink) SetPcapClassifyTable(swIfIndex uint32, tableIndex uint32) error {
+	client := classify.NewServiceClient(v.GetConnection())
+
+	_, err := client.ClassifyPcapSetTable(v.GetContext(), &classify.ClassifyPcapSetTable{
+		SwIfIndex:  interface_types.InterfaceIndex(swIfIndex),
+		TableIndex: tableIndex,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set pcap classify table %d on swIfIndex %d: %w", tableIndex, swIfIndex, err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpplink
+
+import (
+	"fmt"
+
+	"go.fd.io/govpp/adapter"
+	"go.fd.io/govpp/adapter/statsclient"
+
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface_types"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/policer"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/policer_types"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+func toPolicerConfig(p *types.Policer) policer_types.PolicerConfig {
+	conformAction := policer_types.Sse2QosAction{
+		Type: policer_types.SSE2_QOS_ACTION_API_TRANSMIT,
+	}
+	if p.MarkDscp {
+		conformAction = policer_types.Sse2QosAction{
+			Type: policer_types.SSE2_QOS_ACTION_API_MARK_AND_TRANSMIT,
+			Dscp: p.Dscp,
+		}
+	}
+	return policer_types.PolicerConfig{
+		Cir:           p.CommittedRate,
+		Cb:            p.CommittedBurst,
+		RateType:      policer_types.SSE2_QOS_RATE_API_KBPS,
+		RoundType:     policer_types.SSE2_QOS_ROUND_API_TO_CLOSEST,
+		Type:          policer_types.SSE2_QOS_POLICER_TYPE_API_1R2C,
+		ConformAction: conformAction,
+		ExceedAction: policer_types.Sse2QosAction{
+			Type: policer_types.SSE2_QOS_ACTION_API_DROP,
+		},
+		ViolateAction: policer_types.Sse2QosAction{
+			Type: policer_types.SSE2_QOS_ACTION_API_DROP,
+		},
+	}
+}
+
+func (v *VppLink) AddPolicer(p *types.Policer) error {
+	client := policer.NewServiceClient(v.GetConnection())
+
+	response, err := client.PolicerAdd(v.GetContext(), &policer.PolicerAdd{
+		Name:  p.Name,
+		Infos: toPolicerConfig(p),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add policer %s: %w", p.Name, err)
+	}
+	p.PolicerIndex = response.PolicerIndex
+	return nil
+}
+
+func (v *VppLink) UpdatePolicer(p *types.Policer) error {
+	client := policer.NewServiceClient(v.GetConnection())
+
+	_, err := client.PolicerUpdate(v.GetContext(), &policer.PolicerUpdate{
+		PolicerIndex: p.PolicerIndex,
+		Infos:        toPolicerConfig(p),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update policer %d: %w", p.PolicerIndex, err)
+	}
+	return nil
+}
+
+func (v *VppLink) DelPolicer(policerIndex uint32) error {
+	client := policer.NewServiceClient(v.GetConnection())
+
+	_, err := client.PolicerDel(v.GetContext(), &policer.PolicerDel{
+		PolicerIndex: policerIndex,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete policer %d: %w", policerIndex, err)
+	}
+	return nil
+}
+
+func (v *VppLink) applyPolicerInput(policerIndex uint32, swIfIndex uint32, apply bool) error {
+	client := policer.NewServiceClient(v.GetConnection())
+
+	_, err := client.PolicerInputV2(v.GetContext(), &policer.PolicerInputV2{
+		PolicerIndex: policerIndex,
+		SwIfIndex:    interface_types.InterfaceIndex(swIfIndex),
+		Apply:        apply,
+	})
+	return err
+}
+
+func (v *VppLink) applyPolicerOutput(policerIndex uint32, swIfIndex uint32, apply bool) error {
+	client := policer.NewServiceClient(v.GetConnection())
+
+	_, err := client.PolicerOutputV2(v.GetContext(), &policer.PolicerOutputV2{
+		PolicerIndex: policerIndex,
+		SwIfIndex:    interface_types.InterfaceIndex(swIfIndex),
+		Apply:        apply,
+	})
+	return err
+}
+
+// EnablePolicerInput polices the traffic received on swIfIndex
+func (v *VppLink) EnablePolicerInput(policerIndex uint32, swIfIndex uint32) error {
+	if err := v.applyPolicerInput(policerIndex, swIfIndex, true); err != nil {
+		return fmt.Errorf("failed to enable input policer %d on swIfIndex %d: %w", policerIndex, swIfIndex, err)
+	}
+	return nil
+}
+
+func (v *VppLink) DisablePolicerInput(policerIndex uint32, swIfIndex uint32) error {
+	if err := v.applyPolicerInput(policerIndex, swIfIndex, false); err != nil {
+		return fmt.Errorf("failed to disable input policer %d on swIfIndex %d: %w", policerIndex, swIfIndex, err)
+	}
+	return nil
+}
+
+// EnablePolicerOutput polices the traffic transmitted on swIfIndex
+func (v *VppLink) EnablePolicerOutput(policerIndex uint32, swIfIndex uint32) error {
+	if err := v.applyPolicerOutput(policerIndex, swIfIndex, true); err != nil {
+		return fmt.Errorf("failed to enable output policer %d on swIfIndex %d: %w", policerIndex, swIfIndex, err)
+	}
+	return nil
+}
+
+func (v *VppLink) DisablePolicerOutput(policerIndex uint32, swIfIndex uint32) error {
+	if err := v.applyPolicerOutput(policerIndex, swIfIndex, false); err != nil {
+		return fmt.Errorf("failed to disable output policer %d on swIfIndex %d: %w", policerIndex, swIfIndex, err)
+	}
+	return nil
+}
+
+// GetPolicerDropStats returns the number of packets dropped by each
+// policer, indexed by policer index. A 1R2C policer drops the packets
+// it classifies as exceeding or violating the committed rate.
+func GetPolicerDropStats(sc *statsclient.StatsClient) (map[uint32]uint64, error) {
+	dumpStats, err := sc.DumpStats("/net/policer/exceed", "/net/policer/violate")
+	if err != nil {
+		return nil, fmt.Errorf("dump stats failed: %w", err)
+	}
+	drops := make(map[uint32]uint64)
+	for _, sta := range dumpStats {
+		values, ok := sta.Data.(adapter.CombinedCounterStat)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an adapter.CombinedCounterStat: %v", sta.Name, sta.Data)
+		}
+		for worker := range values {
+			for policerIndex := range values[worker] {
+				drops[uint32(policerIndex)] += values[worker][policerIndex].Packets()
+			}
+		}
+	}
+	return drops, nil
+}
+
+// GetPolicerConformStats returns the packets and bytes transmitted by each
+// policer within its committed rate, indexed by policer index
+func GetPolicerConformStats(sc *statsclient.StatsClient) (map[uint32]adapter.CombinedCounter, error) {
+	dumpStats, err := sc.DumpStats("/net/policer/conform")
+	if err != nil {
+		return nil, fmt.Errorf("dump stats failed: %w", err)
+	}
+	counters := make(map[uint32]adapter.CombinedCounter)
+	for _, sta := range dumpStats {
+		values, ok := sta.Data.(adapter.CombinedCounterStat)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an adapter.CombinedCounterStat: %v", sta.Name, sta.Data)
+		}
+		for worker := range values {
+			for policerIndex := range values[worker] {
+				counter := counters[uint32(policerIndex)]
+				counter[0] += values[worker][policerIndex].Packets()
+				counter[1] += values[worker][policerIndex].Bytes()
+				counters[uint32(policerIndex)] = counter
+			}
+		}
+	}
+	return counters, nil
+}
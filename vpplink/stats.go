@@ -45,6 +45,23 @@ func GetInterfaceStats(sc *statsclient.StatsClient) (ifNames adapter.NameStat, d
 	return ifNames, dumpStats, nil
 }
 
+// GetHeartbeat returns the heartbeat VPP increments each
+// time it updates the stats segment
+func GetHeartbeat(sc *statsclient.StatsClient) (float64, error) {
+	dumpStats, err := sc.DumpStats("^/sys/heartbeat$")
+	if err != nil {
+		return 0, fmt.Errorf("dump stats failed: %w", err)
+	}
+	if len(dumpStats) == 0 {
+		return 0, fmt.Errorf("no heartbeat in stats segment")
+	}
+	heartbeat, ok := dumpStats[0].Data.(adapter.ScalarStat)
+	if !ok {
+		return 0, fmt.Errorf("%s is not an adapter.ScalarStat: %v", dumpStats[0].Name, dumpStats[0].Data)
+	}
+	return float64(heartbeat), nil
+}
+
 func (v *VppLink) GetBufferStats() (available uint32, cached uint32, used uint32, err error) {
 	client := interfaces.NewServiceClient(v.GetConnection())
 
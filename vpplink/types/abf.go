@@ -19,4 +19,5 @@ type AbfPolicy struct {
 	Paths    []RoutePath
 	PolicyID uint32
 	ACLIndex uint32
+	IsIPv6   bool
 }
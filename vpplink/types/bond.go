@@ -0,0 +1,91 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"net"
+)
+
+type BondMode uint32
+
+const (
+	BondModeRoundRobin BondMode = iota + 1
+	BondModeActiveBackup
+	BondModeXor
+	BondModeBroadcast
+	BondModeLacp
+)
+
+func (m BondMode) String() string {
+	switch m {
+	case BondModeRoundRobin:
+		return "round-robin"
+	case BondModeActiveBackup:
+		return "active-backup"
+	case BondModeXor:
+		return "xor"
+	case BondModeBroadcast:
+		return "broadcast"
+	case BondModeLacp:
+		return "lacp"
+	default:
+		return "unknown"
+	}
+}
+
+// BondLoadBalance is the hash used to pick the member transmitting
+// a packet, in xor and lacp modes
+type BondLoadBalance uint32
+
+const (
+	BondLoadBalanceL2 BondLoadBalance = iota
+	BondLoadBalanceL34
+	BondLoadBalanceL23
+)
+
+func (lb BondLoadBalance) String() string {
+	switch lb {
+	case BondLoadBalanceL2:
+		return "l2"
+	case BondLoadBalanceL34:
+		return "l34"
+	case BondLoadBalanceL23:
+		return "l23"
+	default:
+		return "unknown"
+	}
+}
+
+type Bond struct {
+	SwIfIndex    uint32
+	Mode         BondMode
+	LoadBalance  BondLoadBalance
+	HardwareAddr net.HardwareAddr
+	EnableGSO    bool
+}
+
+func (b *Bond) String() string {
+	return fmt.Sprintf("[%d] mode=%s lb=%s mac=%s gso=%t", b.SwIfIndex, b.Mode, b.LoadBalance, b.HardwareAddr, b.EnableGSO)
+}
+
+type BondMember struct {
+	SwIfIndex uint32
+	// IsPassive members wait for the peer to start LACP
+	IsPassive bool
+	// IsLongTimeout uses the 90s LACP timeout instead of 3s
+	IsLongTimeout bool
+}
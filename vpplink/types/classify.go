@@ -83,6 +83,30 @@ func (h UDPv4Header) Bytes() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type IPv6Header struct {
+	VersionTrafficClassFlowLabel uint32
+	PayloadLen                   uint16
+	NextHeader                   uint8
+	HopLimit                     uint8
+	Saddr                        [16]byte
+	Daddr                        [16]byte
+}
+
+// UDPv6Header assumes that there are no IPv6 extension headers
+type UDPv6Header struct {
+	IP  IPv6Header
+	UDP UDPHeader
+}
+
+func (h UDPv6Header) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	err := binary.Write(&buf, binary.BigEndian, h)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 type GeneveV4Header struct {
 	UDPv4Header
 	GeneveHeader
@@ -217,6 +241,50 @@ func (tuple *FiveTuple) GetMask() ([]byte, error) {
 	return maskBytes, nil
 }
 
+// IsIP6 returns whether the tuple matches IPv6 packets
+func (tuple *FiveTuple) IsIP6() bool {
+	return tuple.SrcAddr.Is6() || tuple.DstAddr.Is6()
+}
+
+// GetIP6Match returns the match of the tuple on IPv6 packets
+func (tuple *FiveTuple) GetIP6Match() ([]byte, error) {
+	var match UDPv6Header
+	match.IP.VersionTrafficClassFlowLabel = 6 << 28
+	match.IP.NextHeader = uint8(tuple.Protocol)
+	match.IP.Saddr = tuple.SrcAddr.As16()
+	match.IP.Daddr = tuple.DstAddr.As16()
+	match.UDP.Sport = tuple.SrcPort
+	match.UDP.Dport = tuple.DstPort
+	return match.Bytes()
+}
+
+// GetIP6Mask returns the mask of the tuple on IPv6 packets, it always
+// matches the IP version
+func (tuple *FiveTuple) GetIP6Mask() ([]byte, error) {
+	var mask UDPv6Header
+	mask.IP.VersionTrafficClassFlowLabel = 0xf << 28
+	if tuple.Protocol != IPProto(0) {
+		mask.IP.NextHeader = 0xff
+	}
+	if !tuple.SrcAddr.IsUnspecified() && tuple.SrcAddr.IsValid() {
+		for i := range mask.IP.Saddr {
+			mask.IP.Saddr[i] = 0xff
+		}
+	}
+	if tuple.SrcPort != 0 {
+		mask.UDP.Sport = 0xffff
+	}
+	if !tuple.DstAddr.IsUnspecified() && tuple.DstAddr.IsValid() {
+		for i := range mask.IP.Daddr {
+			mask.IP.Daddr[i] = 0xff
+		}
+	}
+	if tuple.DstPort != 0 {
+		mask.UDP.Dport = 0xffff
+	}
+	return mask.Bytes()
+}
+
 func (tuple *FiveTuple) GetBPF() string {
 	expressions := make([]string, 0, 4)
 	if !tuple.SrcAddr.IsUnspecified() && tuple.SrcAddr.IsValid() {
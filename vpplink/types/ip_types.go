@@ -37,15 +37,16 @@ const (
 	INVALID IPProto = IPProto(ip_types.IP_API_PROTO_RESERVED) //nolint:staticcheck
 )
 
+func (proto IPProto) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(proto.String())), nil
+}
+
 func (proto *IPProto) UnmarshalText(text []byte) error {
-	switch string(text) {
-	case "tcp":
-		*proto = TCP
-	case "udp":
-		*proto = UDP
-	default:
-		*proto = TCP
+	p, err := UnformatProto(string(text))
+	if err != nil {
+		p = TCP
 	}
+	*proto = p
 	return nil
 }
 
@@ -43,11 +43,16 @@ type Memif struct {
 	Mode        MemifMode
 	NumRxQueues int
 	NumTxQueues int
-	QueueSize   int
-	MacAddress  net.HardwareAddr
-	SocketID    uint32
-	SwIfIndex   uint32
-	Flags       MemifFlag
+	// RingSize is the number of slots of the rx and tx rings, a power of 2,
+	// 0 for the VPP default
+	RingSize   int
+	BufferSize int
+	// ZeroCopy is only supported by slaves
+	ZeroCopy   bool
+	MacAddress net.HardwareAddr
+	SocketID   uint32
+	SwIfIndex  uint32
+	Flags      MemifFlag
 }
 
 type MemifSocket struct {
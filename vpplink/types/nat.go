@@ -40,6 +40,13 @@ func ToVppNatConfigFlags(flags NatFlags) nat_types.NatConfigFlags {
 	return nat_types.NatConfigFlags(flags)
 }
 
+// Nat44Address is an address of the NAT44 pool. Sessions from inside
+// interfaces in VrfID use it, or from any VRF when VrfID is ^0
+type Nat44Address struct {
+	Address net.IP
+	VrfID   uint32
+}
+
 type Nat44Entry struct {
 	ServiceIP   net.IP
 	ServicePort int32
@@ -0,0 +1,38 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+)
+
+// PcapTrace is a pcap capture in VPP. VPP writes the packets to
+// /tmp/<Filename> in its own filesystem when the capture stops.
+type PcapTrace struct {
+	SwIfIndex         uint32
+	Rx                bool
+	Tx                bool
+	Drop              bool
+	Filter            bool
+	MaxPackets        uint32
+	MaxBytesPerPacket uint32
+	Filename          string
+}
+
+func (t *PcapTrace) String() string {
+	return fmt.Sprintf("swIfIndex=%d rx=%t tx=%t drop=%t filter=%t max=%d snaplen=%d file=%s",
+		t.SwIfIndex, t.Rx, t.Tx, t.Drop, t.Filter, t.MaxPackets, t.MaxBytesPerPacket, t.Filename)
+}
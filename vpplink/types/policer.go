@@ -0,0 +1,42 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+)
+
+// Policer is a single rate two color policer, packets
+// exceeding the committed rate are dropped
+type Policer struct {
+	PolicerIndex uint32
+	Name         string
+	// CommittedRate is in kbits/s
+	CommittedRate uint32
+	// CommittedBurst is in bytes
+	CommittedBurst uint64
+	// MarkDscp sets Dscp on the packets within the committed rate
+	MarkDscp bool
+	Dscp     uint8
+}
+
+func (p *Policer) String() string {
+	s := fmt.Sprintf("[%d] %s rate=%dkbps burst=%dB", p.PolicerIndex, p.Name, p.CommittedRate, p.CommittedBurst)
+	if p.MarkDscp {
+		s += fmt.Sprintf(" dscp=%d", p.Dscp)
+	}
+	return s
+}
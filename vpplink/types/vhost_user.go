@@ -0,0 +1,36 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"net"
+)
+
+type VhostUser struct {
+	SwIfIndex uint32
+	// SocketFilename is the path of the vhost-user unix socket
+	SocketFilename string
+	// IsServer makes VPP create and listen on the socket
+	IsServer   bool
+	EnableGSO  bool
+	MacAddress net.HardwareAddr
+	Tag        string
+}
+
+func (v *VhostUser) String() string {
+	return fmt.Sprintf("[%d] %s server=%t gso=%t", v.SwIfIndex, v.SocketFilename, v.IsServer, v.EnableGSO)
+}
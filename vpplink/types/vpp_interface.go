@@ -24,12 +24,17 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/af_packet"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/af_xdp"
 	interfaces "github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface_types"
 )
 
 type RxMode uint32
 
+func (mode RxMode) MarshalText() ([]byte, error) {
+	return []byte(FormatRxMode(mode)), nil
+}
+
 func (mode *RxMode) UnmarshalText(text []byte) error {
 	switch string(text) {
 	case "interrupt":
@@ -75,6 +80,7 @@ type GenericVppInterface struct {
 type VppInterfaceDetails struct {
 	SwIfIndex uint32
 	IsUp      bool
+	IsLinkUp  bool
 	Name      string
 	Tag       string
 	Type      string
@@ -95,6 +101,10 @@ const (
 
 type VppXDPInterface struct {
 	GenericVppInterface
+	Mode af_xdp.AfXdpMode
+	// Prog is an XDP program object file loaded by VPP instead
+	// of the default program
+	Prog string
 }
 
 type AfPacketInterface struct {
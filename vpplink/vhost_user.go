@@ -0,0 +1,57 @@
+// Copyright (C) 2025 Cisco Systems Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpplink
+
+import (
+	"fmt"
+
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/interface_types"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/vhost_user"
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/types"
+)
+
+func (v *VppLink) CreateVhostUser(vhostUser *types.VhostUser) error {
+	client := vhost_user.NewServiceClient(v.GetConnection())
+
+	request := &vhost_user.CreateVhostUserIfV2{
+		IsServer:     vhostUser.IsServer,
+		SockFilename: vhostUser.SocketFilename,
+		EnableGso:    vhostUser.EnableGSO,
+		Tag:          vhostUser.Tag,
+	}
+	if vhostUser.MacAddress != nil {
+		request.UseCustomMac = true
+		request.MacAddress = types.MacAddress(vhostUser.MacAddress)
+	}
+	response, err := client.CreateVhostUserIfV2(v.GetContext(), request)
+	if err != nil {
+		return fmt.Errorf("failed to create vhost-user interface %s: %w", vhostUser.SocketFilename, err)
+	}
+	vhostUser.SwIfIndex = uint32(response.SwIfIndex)
+	return nil
+}
+
+func (v *VppLink) DeleteVhostUser(swIfIndex uint32) error {
+	client := vhost_user.NewServiceClient(v.GetConnection())
+
+	_, err := client.DeleteVhostUserIf(v.GetContext(), &vhost_user.DeleteVhostUserIf{
+		SwIfIndex: interface_types.InterfaceIndex(swIfIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete vhost-user interface %d: %w", swIfIndex, err)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ package vpplink
 import (
 	"fmt"
 
+	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/memclnt"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/vlib"
 	"github.com/projectcalico/vpp-dataplane/v3/vpplink/generated/bindings/vpe"
 )
@@ -32,6 +33,17 @@ func (v *VppLink) GetVPPVersion() (string, error) {
 	return response.Version, nil
 }
 
+// ControlPing checks that the VPP API answers
+func (v *VppLink) ControlPing() error {
+	client := memclnt.NewServiceClient(v.GetConnection())
+
+	_, err := client.ControlPing(v.GetContext(), &memclnt.ControlPing{})
+	if err != nil {
+		return fmt.Errorf("control ping failed: %w", err)
+	}
+	return nil
+}
+
 // RunCli sends CLI command to VPP and returns response.
 func (v *VppLink) RunCli(cmd string) (string, error) {
 	client := vlib.NewServiceClient(v.GetConnection())